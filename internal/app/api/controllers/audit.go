@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"godopi/internal/pkg/audit"
+	"net/http"
+	"strconv"
+	"time"
+
+	. "godopi/internal/pkg/logger"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// defaultAuditLimit and maxAuditLimit bound the number of audit records returned at once.
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+type AuditController struct {
+	auditLog audit.AuditLog
+}
+
+func NewAuditController(auditLog audit.AuditLog) AuditController {
+	Logger().Info("Constructing new audit controller..")
+
+	return AuditController{auditLog: auditLog}
+}
+
+// GetAuditRecords godoc
+// @Summary Gets the audit records of the operations on the docker host, newest first
+// @Description Answers with 501 when none of the configured audit sinks can be queried.
+// @Tags    Audit
+// @Accept  json
+// @Produce json
+// @Param   subject query string false "Principal that performed the operation"
+// @Param   action  query string false "Action, e.g. create or exec"
+// @Param   target  query string false "Container, image or other resource the operation acted on"
+// @Param   outcome query string false "Outcome, one of success, failure or denied"
+// @Param   since   query string false "Only records at or after this RFC 3339 time"
+// @Param   until   query string false "Only records at or before this RFC 3339 time"
+// @Param   limit   query int    false "Maximum number of records, 100 by default"
+// @Param   fields  query []string false "Fields of each record to return, e.g. time,subject,action" collectionFormat(csv)
+// @Success 200 {array} audit.Record
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 501 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /audit [get]
+func (ac AuditController) GetAuditRecords(ctx *gin.Context) {
+	filter, err := auditFilter(ctx)
+
+	if err != nil {
+		err = errors.Wrap(err, "there is an error while validating parameters of audit query")
+		abortWithError(ctx, errdefs.InvalidParameter(err), "Error retrieving audit records!")
+		return
+	}
+
+	records, err := ac.auditLog.Query(ctx.Request.Context(), filter)
+
+	if err != nil {
+		err = errors.Wrap(err, "there is an error while querying the audit records")
+		abortWithError(ctx, err, "Error retrieving audit records!")
+		return
+	}
+
+	if records == nil {
+		records = []audit.Record{}
+	}
+
+	writeJSON(ctx, http.StatusOK, records, "Error retrieving audit records!")
+}
+
+func auditFilter(ctx *gin.Context) (audit.Filter, error) {
+	filter := audit.Filter{
+		Subject: ctx.Query("subject"),
+		Action:  ctx.Query("action"),
+		Target:  ctx.Query("target"),
+		Outcome: ctx.Query("outcome"),
+		Limit:   defaultAuditLimit,
+	}
+
+	switch filter.Outcome {
+	case "", audit.OutcomeSuccess, audit.OutcomeFailure, audit.OutcomeDenied:
+	default:
+		return filter, errors.Errorf("outcome must be one of success, failure or denied. Outcome:%s", filter.Outcome)
+	}
+
+	var err error
+
+	if since, ok := ctx.GetQuery("since"); ok {
+		if filter.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			return filter, errors.Errorf("since must be an RFC 3339 time. Since:%s", since)
+		}
+	}
+
+	if until, ok := ctx.GetQuery("until"); ok {
+		if filter.Until, err = time.Parse(time.RFC3339, until); err != nil {
+			return filter, errors.Errorf("until must be an RFC 3339 time. Until:%s", until)
+		}
+	}
+
+	if limit, ok := ctx.GetQuery("limit"); ok {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit < 1 || filter.Limit > maxAuditLimit {
+			return filter, errors.Errorf("limit must be a number between 1 and %d. Limit:%s", maxAuditLimit, limit)
+		}
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"godopi/internal/app/api/models"
+	"godopi/internal/pkg/audit"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gotest.tools/v3/assert"
+)
+
+func serveAuditRecords(auditLog audit.AuditLog, query string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	e.GET("/audit", NewAuditController(auditLog).GetAuditRecords)
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/audit"+query, nil)
+	e.ServeHTTP(w, c.Request)
+
+	return w
+}
+
+func TestGetAuditRecordsNewestFirst(t *testing.T) {
+	fileSink, err := audit.NewFileSink(filepath.Join(t.TempDir(), "audit.jsonl"))
+	assert.NilError(t, err)
+
+	auditLog := audit.NewAuditLog(fileSink)
+	start := time.Date(2022, 4, 20, 10, 0, 0, 0, time.UTC)
+
+	for i, target := range []string{"web", "db", "web", "web"} {
+		auditLog.Record(context.Background(), audit.Record{Time: start.Add(time.Duration(i) * time.Minute), Subject: "ops", Action: "operate", Target: target, Outcome: audit.OutcomeSuccess})
+	}
+
+	w := serveAuditRecords(auditLog, "?target=web&limit=2&since=2022-04-20T10:00:00Z")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var records []audit.Record
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &records))
+
+	assert.Equal(t, 2, len(records))
+	assert.Equal(t, start.Add(3*time.Minute), records[0].Time)
+	assert.Equal(t, start.Add(2*time.Minute), records[1].Time)
+	assert.Assert(t, records[0].Id != "" && records[0].Id != records[1].Id)
+}
+
+func TestGetAuditRecordsErrorInvalidFilter(t *testing.T) {
+	for _, query := range []string{"?limit=0", "?limit=1001", "?since=yesterday", "?outcome=maybe"} {
+		w := serveAuditRecords(audit.NewAuditLog(), query)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestGetAuditRecordsErrorNoQueryableSink(t *testing.T) {
+	w := serveAuditRecords(audit.NewAuditLog(audit.NewZapSink()), "")
+
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+
+	var response models.ErrorResponse
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, models.ErrorCodeNotImplemented, response.Code)
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"context"
+	"godopi/internal/pkg/cache"
+	"godopi/internal/pkg/docker"
+	"strconv"
+	"strings"
+	"time"
+
+	. "godopi/internal/pkg/logger"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// containerEventsRetryDelay is how long to wait before reopening a broken event stream.
+const containerEventsRetryDelay = 5 * time.Second
+
+// WatchContainerEvents invalidates the cached container list whenever the engine reports a change that shows up in it,
+// including changes made outside of godopi, until ctx is cancelled. The event stream is reopened whenever it breaks.
+func (dc DockerController) WatchContainerEvents(ctx context.Context) {
+	Logger().Info("Watching docker events for cache invalidation..")
+
+	for {
+		// Events may have been missed while the stream was not open.
+		dc.invalidateContainersCache(ctx)
+
+		err := dc.dockerClient.StreamEvents(ctx, docker.EventOptions{Types: []string{"container", "network"}}, func(event docker.Event) {
+			if affectsContainerList(event) {
+				dc.invalidateContainersCache(ctx)
+			}
+		})
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		Logger().Warn("Docker event stream ended, reopening it", zap.Error(err), zap.Duration("RetryDelay", containerEventsRetryDelay))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(containerEventsRetryDelay):
+		}
+	}
+}
+
+// affectsContainerList filters out the frequent events that do not change any field of the container list.
+func affectsContainerList(event docker.Event) bool {
+	if event.Type == "network" {
+		return event.Action == "connect" || event.Action == "disconnect"
+	}
+
+	switch {
+	case strings.HasPrefix(event.Action, "exec_"),
+		event.Action == "attach",
+		event.Action == "resize",
+		event.Action == "top",
+		event.Action == "export",
+		event.Action == "archive-path",
+		event.Action == "extract-to-dir":
+		return false
+	}
+
+	return true
+}
+
+// containersCacheGeneration returns the current generation of the cached container lists, empty when none was set yet.
+func (dc DockerController) containersCacheGeneration(ctx context.Context) (string, error) {
+	generation, err := dc.cacheClient.Get(ctx, CONTAINERS_CACHE_GENERATION_KEY)
+
+	if err == cache.CacheNil {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", errors.Wrapf(err, "there is an error while getting the value from the cache storage. Key:%s", CONTAINERS_CACHE_GENERATION_KEY)
+	}
+
+	return generation, nil
+}
+
+// invalidateContainersCache moves the container lists to a new generation. A list read from the engine before the invalidation
+// is then cached under the old generation, where no request looks for it any more, instead of overwriting the fresh list.
+// The entries of the old generations are deleted right away.
+func (dc DockerController) invalidateContainersCache(ctx context.Context) {
+	generation := strconv.FormatInt(time.Now().UnixNano(), 36)
+
+	if err := dc.cacheClient.Set(ctx, CONTAINERS_CACHE_GENERATION_KEY, generation, 0); err != nil {
+		Logger().Error("Error invalidating the cache storage", zap.String("Key", CONTAINERS_CACHE_GENERATION_KEY), zap.Error(err))
+	}
+
+	if err := dc.cacheClient.DelByPrefix(ctx, CONTAINERS_CACHE_KEY_PREFIX); err != nil {
+		Logger().Error("Error invalidating the cache storage", zap.String("KeyPrefix", CONTAINERS_CACHE_KEY_PREFIX), zap.Error(err))
+	}
+}
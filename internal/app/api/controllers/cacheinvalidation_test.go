@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"context"
+	"godopi/internal/pkg/cache"
+	"godopi/internal/pkg/docker"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gotest.tools/v3/assert"
+)
+
+func TestDeleteContainerInvalidatesCache(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	var deletedPrefixes []string
+
+	mockCacheClient := mockCacheClient{}
+	mockCacheClient.MockDelByPrefix = func(ctx context.Context, prefix string) error {
+		deletedPrefixes = append(deletedPrefixes, prefix)
+		return nil
+	}
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockDeleteContainer = func(c context.Context, containerId string) error {
+		return nil
+	}
+
+	dockerController := DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient}
+
+	e.DELETE("/:id", dockerController.DeleteContainer)
+
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodDelete, "/3423ASDF372FA7DF732", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.DeepEqual(t, []string{CONTAINERS_CACHE_KEY_PREFIX}, deletedPrefixes)
+}
+
+func TestWatchContainerEventsInvalidatesCache(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var mu sync.Mutex
+	invalidations := 0
+
+	mockCacheClient := mockCacheClient{}
+	mockCacheClient.MockDelByPrefix = func(ctx context.Context, prefix string) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		invalidations++
+		return nil
+	}
+
+	var receivedOptions docker.EventOptions
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockStreamEvents = func(c context.Context, options docker.EventOptions, onEvent func(docker.Event)) error {
+		receivedOptions = options
+		onEvent(docker.Event{Type: "container", Action: "die"})
+		onEvent(docker.Event{Type: "container", Action: "exec_start: sh"})
+		onEvent(docker.Event{Type: "network", Action: "connect"})
+		onEvent(docker.Event{Type: "network", Action: "create"})
+		cancel()
+		return nil
+	}
+
+	dockerController := DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient}
+	dockerController.WatchContainerEvents(ctx)
+
+	// One invalidation when the stream is opened, one for die and one for connect.
+	assert.Equal(t, 3, invalidations)
+	assert.DeepEqual(t, []string{"container", "network"}, receivedOptions.Types)
+}
+
+func TestGetAllContainersSkipsListReadBeforeInvalidation(t *testing.T) {
+	entries := map[string]string{}
+
+	mockCacheClient := mockCacheClient{}
+	mockCacheClient.MockGet = func(ctx context.Context, key string) (string, error) {
+		if value, ok := entries[key]; ok {
+			return value, nil
+		}
+		return "", cache.CacheNil
+	}
+	mockCacheClient.MockSet = func(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+		entries[key] = value.(string)
+		return nil
+	}
+	mockCacheClient.MockDelByPrefix = func(ctx context.Context, prefix string) error {
+		for key := range entries {
+			if strings.HasPrefix(key, prefix) {
+				delete(entries, key)
+			}
+		}
+		return nil
+	}
+
+	var dockerController DockerController
+	listed := 0
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockListContainers = func(c context.Context, options docker.ListContainersOptions) ([]docker.ContainerSummary, string, error) {
+		listed++
+
+		if listed == 1 {
+			// A container is deleted while the first list is on its way back from the engine.
+			dockerController.invalidateContainersCache(c)
+			return []docker.ContainerSummary{{Id: "deleted"}}, "", nil
+		}
+
+		return []docker.ContainerSummary{}, "", nil
+	}
+
+	dockerController = DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient}
+
+	_, e := gin.CreateTestContext(httptest.NewRecorder())
+	e.GET("/", dockerController.GetAllContainers)
+
+	var bodies []string
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil)
+		e.ServeHTTP(w, request)
+		bodies = append(bodies, w.Body.String())
+	}
+
+	assert.Equal(t, 2, listed)
+	assert.Equal(t, true, strings.Contains(bodies[0], "deleted"))
+	assert.Equal(t, false, strings.Contains(bodies[1], "deleted"))
+}
@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"godopi/internal/pkg/cache"
+	"godopi/internal/pkg/docker"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gotest.tools/v3/assert"
+)
+
+func TestGetAllContainersPassesFiltersAndCachesPage(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	cachedKey, cachedValue := "", ""
+
+	mockCacheClient := mockCacheClient{}
+	mockCacheClient.MockGet = func(ctx context.Context, key string) (string, error) {
+		return "", cache.CacheNil
+	}
+	mockCacheClient.MockSet = func(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+		cachedKey, cachedValue = key, value.(string)
+		return nil
+	}
+
+	var receivedOptions docker.ListContainersOptions
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockListContainers = func(c context.Context, options docker.ListContainersOptions) ([]docker.ContainerSummary, string, error) {
+		receivedOptions = options
+		return []docker.ContainerSummary{{Id: "3423ASDF372FA7DF732"}}, "bmV4dEN1cnNvcg", nil
+	}
+
+	dockerController := DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient}
+
+	e.GET("/", dockerController.GetAllContainers)
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/?all=true&status=running&status=exited&label=app=web&limit=1", nil)
+	e.ServeHTTP(w, c.Request)
+
+	var containers []docker.ContainerSummary
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &containers))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.DeepEqual(t, []docker.ContainerSummary{{Id: "3423ASDF372FA7DF732"}}, containers)
+	assert.Equal(t, "bmV4dEN1cnNvcg", w.Header().Get("X-Next-Cursor"))
+	assert.Equal(t, true, receivedOptions.All)
+	assert.DeepEqual(t, []string{"running", "exited"}, receivedOptions.Statuses)
+	assert.Equal(t, 1, receivedOptions.Limit)
+	assert.Equal(t, true, strings.HasPrefix(cachedKey, CONTAINERS_CACHE_KEY_PREFIX))
+	assert.Equal(t, true, strings.Contains(cachedValue, "bmV4dEN1cnNvcg"))
+}
+
+func TestGetAllContainersCacheKeyIsNormalized(t *testing.T) {
+	first := containersCacheKey("", docker.ListContainersOptions{All: true, Statuses: []string{"running", "exited"}, Labels: []string{"b", "a"}})
+	second := containersCacheKey("", docker.ListContainersOptions{All: true, Statuses: []string{"exited", "running"}, Labels: []string{"a", "b"}})
+	other := containersCacheKey("", docker.ListContainersOptions{All: false, Statuses: []string{"exited", "running"}, Labels: []string{"a", "b"}})
+
+	assert.Equal(t, first, second)
+	assert.Assert(t, first != other)
+}
+
+func TestGetAllContainersErrorInvalidQuery(t *testing.T) {
+	for _, query := range []string{"status=sleeping", "limit=0", "limit=many"} {
+		w := httptest.NewRecorder()
+		c, e := gin.CreateTestContext(w)
+
+		dockerController := DockerController{dockerClient: &mockDockerClient{}, cacheClient: &mockCacheClient{}}
+
+		e.GET("/", dockerController.GetAllContainers)
+		c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/?"+query, nil)
+		e.ServeHTTP(w, c.Request)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
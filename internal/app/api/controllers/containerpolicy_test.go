@@ -0,0 +1,304 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"godopi/internal/app/api/models"
+	"godopi/internal/pkg/docker"
+	"godopi/internal/pkg/policy"
+	"godopi/internal/pkg/stacks"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gotest.tools/v3/assert"
+)
+
+const testContainerPolicy = `
+images:
+  allow: [docker.io/library/*, registry.local/]
+  deny: [docker.io/library/ubuntu]
+  requireDigest: true
+allowedHostPaths: [/srv/data]
+requiredLabels: [team]
+maxResources:
+  cpus: 1
+  memoryBytes: 268435456
+`
+
+const pinnedNginx = "nginx@sha256:2834dc507516af02784808c5f48b7cbe38b8ed5d0f4837f16e78d00deb7e7767"
+
+func testLoadContainerPolicy(t *testing.T, content string) (*policy.ContainerPolicy, error) {
+	policyFile := filepath.Join(t.TempDir(), "container-policy.yaml")
+	assert.NilError(t, os.WriteFile(policyFile, []byte(content), 0600))
+
+	return policy.LoadContainerPolicy(policyFile)
+}
+
+func policyViolationRules(t *testing.T, w *httptest.ResponseRecorder) []string {
+	var response models.ErrorResponse
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, models.ErrorCodePolicyViolation, response.Code)
+
+	rules := make([]string, 0, len(response.Violations))
+
+	for _, violation := range response.Violations {
+		rules = append(rules, violation.Rule)
+	}
+
+	return rules
+}
+
+func serveCreateContainer(t *testing.T, containerPolicy *policy.ContainerPolicy, container models.Container) (*httptest.ResponseRecorder, bool) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	created := false
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockCreateContainer = func(c context.Context, options docker.CreateContainerOptions) (string, error) {
+		created = true
+		return "3423ASDF372FA7DF732", nil
+	}
+
+	dockerController := DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient{}, containerPolicy: containerPolicy}
+
+	e.POST("/", dockerController.CreateContainer)
+
+	data, _ := json.Marshal(container)
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/", bytes.NewBuffer(data))
+	e.ServeHTTP(w, c.Request)
+
+	return w, created
+}
+
+func TestCreateContainerSuccessWithinPolicy(t *testing.T) {
+	containerPolicy, err := testLoadContainerPolicy(t, testContainerPolicy)
+	assert.NilError(t, err)
+
+	w, created := serveCreateContainer(t, containerPolicy, models.Container{
+		ImageName: pinnedNginx,
+		Labels:    map[string]string{"team": "shop"},
+		Mounts:    []models.Mount{{Type: "bind", Source: "/srv/data/web", Target: "/usr/share/nginx/html"}},
+		Resources: &models.Resources{Cpus: 0.5, MemoryBytes: 128 * 1024 * 1024},
+	})
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Assert(t, created)
+}
+
+func TestCreateContainerErrorPolicyViolation(t *testing.T) {
+	containerPolicy, err := testLoadContainerPolicy(t, testContainerPolicy)
+	assert.NilError(t, err)
+
+	w, created := serveCreateContainer(t, containerPolicy, models.Container{
+		ImageName:  "ubuntu:22.04",
+		Privileged: true,
+		Networks:   []models.NetworkAttachment{{Name: "host"}},
+		Mounts:     []models.Mount{{Type: "bind", Source: "/srv/database", Target: "/data"}},
+		Resources:  &models.Resources{Cpus: 2},
+	})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Assert(t, !created)
+	assert.DeepEqual(t, []string{
+		policy.RuleImageDenied,
+		policy.RuleImageDigest,
+		policy.RuleRequiredLabel,
+		policy.RulePrivileged,
+		policy.RuleHostNetwork,
+		policy.RuleHostPathMount,
+		policy.RuleMaxCpus,
+		policy.RuleMaxMemory,
+	}, policyViolationRules(t, w))
+}
+
+func TestCreateContainerSuccessWithoutPolicy(t *testing.T) {
+	w, created := serveCreateContainer(t, nil, models.Container{ImageName: "ubuntu:22.04", Privileged: true})
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Assert(t, created)
+}
+
+func TestPullImageErrorPolicyViolation(t *testing.T) {
+	containerPolicy, err := testLoadContainerPolicy(t, testContainerPolicy)
+	assert.NilError(t, err)
+
+	for image, status := range map[string]int{"nginx": http.StatusOK, "registry.local/team/api": http.StatusOK, "quay.io/acme/api": http.StatusUnprocessableEntity} {
+		w := httptest.NewRecorder()
+		c, e := gin.CreateTestContext(w)
+
+		mockDockerClient := mockDockerClient{}
+		mockDockerClient.MockPullImage = func(c context.Context, imageReference string, onProgress func(docker.PullProgress)) error {
+			return nil
+		}
+
+		imageController := ImageController{dockerClient: &mockDockerClient, containerPolicy: containerPolicy}
+
+		e.POST("/pull", imageController.PullImage)
+
+		data, _ := json.Marshal(models.ImagePull{Image: image})
+		c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/pull", bytes.NewBuffer(data))
+		e.ServeHTTP(w, c.Request)
+
+		assert.Equal(t, status, w.Code, image)
+	}
+}
+
+func TestTagImageErrorPolicyViolation(t *testing.T) {
+	containerPolicy, err := testLoadContainerPolicy(t, "images:\n  allow: [registry.local/]\n  deny: [docker.io/library/ubuntu]\n")
+	assert.NilError(t, err)
+
+	images := map[string]docker.ImageDetail{
+		"sha256:allowed": {RepoTags: []string{"registry.local/team/api:1.0"}},
+		"sha256:denied":  {RepoTags: []string{"ubuntu:22.04"}},
+		"sha256:local":   {},
+	}
+
+	for name, test := range map[string]struct {
+		imageId string
+		tag     models.ImageTag
+		status  int
+		rules   []string
+	}{
+		"allowed source and target": {"sha256:allowed", models.ImageTag{Repository: "registry.local/team/api", Tag: "stable"}, http.StatusCreated, nil},
+		"target not allowed":        {"sha256:allowed", models.ImageTag{Repository: "quay.io/acme/api"}, http.StatusUnprocessableEntity, []string{policy.RuleImageAllowed}},
+		"denied source":             {"sha256:denied", models.ImageTag{Repository: "registry.local/team/base"}, http.StatusUnprocessableEntity, []string{policy.RuleImageAllowed}},
+		"untagged source":           {"sha256:local", models.ImageTag{Repository: "registry.local/team/base"}, http.StatusUnprocessableEntity, []string{policy.RuleImageAllowed}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, e := gin.CreateTestContext(w)
+
+			tagged := false
+
+			mockDockerClient := mockDockerClient{}
+			mockDockerClient.MockInspectImage = func(c context.Context, imageId string) (docker.ImageDetail, error) {
+				return images[imageId], nil
+			}
+			mockDockerClient.MockTagImage = func(c context.Context, sourceImage string, targetImage string) error {
+				tagged = true
+				return nil
+			}
+
+			imageController := ImageController{dockerClient: &mockDockerClient, containerPolicy: containerPolicy}
+
+			e.POST("/:id/tag", imageController.TagImage)
+
+			data, _ := json.Marshal(test.tag)
+			c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/"+test.imageId+"/tag", bytes.NewBuffer(data))
+			e.ServeHTTP(w, c.Request)
+
+			assert.Equal(t, test.status, w.Code)
+			assert.Equal(t, test.status == http.StatusCreated, tagged)
+
+			if test.rules != nil {
+				assert.DeepEqual(t, test.rules, policyViolationRules(t, w))
+			}
+		})
+	}
+}
+
+func TestCreateStackErrorPolicyViolation(t *testing.T) {
+	containerPolicy, err := testLoadContainerPolicy(t, "images:\n  allow: [registry.local/]\n")
+	assert.NilError(t, err)
+
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	fakeEngine := newFakeStackEngine()
+	stackController := StackController{stackManager: stacks.NewStackManager(fakeEngine.dockerClient(), containerPolicy)}
+
+	e.POST("/", stackController.CreateStack)
+
+	data, _ := json.Marshal(models.Stack{Name: "shop", Compose: shopCompose})
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/?async=true", bytes.NewBuffer(data))
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.DeepEqual(t, []string{policy.RuleImageAllowed}, policyViolationRules(t, w))
+	assert.Assert(t, strings.Contains(w.Body.String(), "Service:cache"))
+	assert.Equal(t, 0, len(fakeEngine.created))
+}
+
+func TestCreateVolumeErrorPolicyViolation(t *testing.T) {
+	containerPolicy, err := testLoadContainerPolicy(t, testContainerPolicy)
+	assert.NilError(t, err)
+
+	for name, test := range map[string]struct {
+		volume models.Volume
+		status int
+	}{
+		"binds the host root":      {models.Volume{Name: "root", DriverOpts: map[string]string{"type": "none", "o": "bind", "device": "/"}}, http.StatusUnprocessableEntity},
+		"binds with local driver":  {models.Volume{Name: "etc", Driver: "local", DriverOpts: map[string]string{"type": "none", "o": "ro,rbind", "device": "/etc"}}, http.StatusUnprocessableEntity},
+		"binds an allowed path":    {models.Volume{Name: "web", DriverOpts: map[string]string{"type": "none", "o": "bind", "device": "/srv/data/web"}}, http.StatusCreated},
+		"plain local volume":       {models.Volume{Name: "cache"}, http.StatusCreated},
+		"tmpfs without host paths": {models.Volume{Name: "scratch", DriverOpts: map[string]string{"type": "tmpfs", "device": "tmpfs"}}, http.StatusCreated},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, e := gin.CreateTestContext(w)
+
+			created := false
+
+			mockDockerClient := mockDockerClient{}
+			mockDockerClient.MockCreateVolume = func(c context.Context, options docker.CreateVolumeOptions) (docker.Volume, error) {
+				created = true
+				return docker.Volume{Name: options.Name}, nil
+			}
+
+			volumeController := VolumeController{dockerClient: &mockDockerClient, containerPolicy: containerPolicy}
+
+			e.POST("/", volumeController.CreateVolume)
+
+			data, _ := json.Marshal(test.volume)
+			c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/", bytes.NewBuffer(data))
+			e.ServeHTTP(w, c.Request)
+
+			assert.Equal(t, test.status, w.Code)
+			assert.Equal(t, test.status == http.StatusCreated, created)
+		})
+	}
+}
+
+func TestCreateStackErrorVolumeBindsHostPath(t *testing.T) {
+	containerPolicy, err := testLoadContainerPolicy(t, "allowedHostPaths: [/srv/data]\n")
+	assert.NilError(t, err)
+
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	fakeEngine := newFakeStackEngine()
+	stackController := StackController{stackManager: stacks.NewStackManager(fakeEngine.dockerClient(), containerPolicy)}
+
+	e.POST("/", stackController.CreateStack)
+
+	compose := shopCompose + "    driver_opts:\n      type: none\n      o: bind\n      device: /\n"
+	data, _ := json.Marshal(models.Stack{Name: "shop", Compose: compose})
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/", bytes.NewBuffer(data))
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.DeepEqual(t, []string{policy.RuleHostPathMount}, policyViolationRules(t, w))
+	assert.Assert(t, strings.Contains(w.Body.String(), "Volume:cache-data"))
+	assert.Equal(t, 0, len(fakeEngine.created))
+}
+
+func TestLoadContainerPolicyErrorInvalid(t *testing.T) {
+	for _, content := range []string{
+		"images:\n  allow: ['docker.io/[']\n",
+		"allowedHostPaths: [srv/data]\n",
+		"requiredLabels: ['=shop']\n",
+		"maxResources:\n  cpus: -1\n",
+		"allowPrivileges: true\n",
+	} {
+		_, err := testLoadContainerPolicy(t, content)
+
+		assert.Assert(t, err != nil, content)
+	}
+}
@@ -1,79 +1,205 @@
 package controllers
 
 import (
+	"context"
+	"encoding/json"
+	"godopi/internal/app/api/middlewares"
 	"godopi/internal/app/api/models"
 	"godopi/internal/app/configs"
 	"godopi/internal/pkg/cache"
 	"godopi/internal/pkg/docker"
+	"godopi/internal/pkg/jobs"
+	"godopi/internal/pkg/policy"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
 	"time"
 
 	. "godopi/internal/pkg/logger"
 
+	"github.com/docker/docker/errdefs"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
 
-const ALL_CONTAINERS string = "ALL_CONTAINERS"
+// CONTAINERS_CACHE_KEY_PREFIX prefixes the cache keys of the container list, one key per generation and normalized list query.
+const CONTAINERS_CACHE_KEY_PREFIX string = "CONTAINERS:"
+
+// CONTAINERS_CACHE_GENERATION_KEY holds the generation of the cached container lists, replaced on every invalidation.
+const CONTAINERS_CACHE_GENERATION_KEY string = "CONTAINERS_GENERATION"
+
+// maxContainersLimit caps the page size of the container list.
+const maxContainersLimit = 1000
+
+// cachedContainers is the value stored in the cache storage for a container list query.
+type cachedContainers struct {
+	Containers []docker.ContainerSummary `json:"containers"`
+	NextCursor string                    `json:"nextCursor"`
+}
 
 type DockerController struct {
-	dockerClient docker.DockerClient
-	cacheClient  cache.CacheClient
+	dockerClient    docker.DockerClient
+	cacheClient     cache.CacheClient
+	jobManager      jobs.JobManager
+	containerPolicy *policy.ContainerPolicy
 }
 
-func NewDockerController() DockerController {
+func NewDockerController(jobManager jobs.JobManager, containerPolicy *policy.ContainerPolicy) DockerController {
 	Logger().Info("Constructing new docker controller..")
 
 	redisAddress := configs.Config().GetString(configs.REDIS_ADDRESS)
 
-	return DockerController{dockerClient: docker.NewDockerClient(), cacheClient: cache.NewCacheClient(redisAddress)}
+	return DockerController{dockerClient: docker.NewDockerClient(), cacheClient: cache.NewCacheClient(redisAddress), jobManager: jobManager, containerPolicy: containerPolicy}
 }
 
 // GetAllContainers godoc
-// @Summary Gets all the running containers
+// @Summary Gets the running containers, or all containers with all=true, newest first
+// @Description When more containers than limit match, the X-Next-Cursor response header holds the cursor of the next page.
 // @Tags    Docker
 // @Accept  json
 // @Produce json
-// @Success 200 {string} Status
+// @Param   all      query bool     false "Include stopped containers"
+// @Param   status   query []string false "Container status, e.g. running or exited" collectionFormat(multi)
+// @Param   label    query []string false "Label as key or key=value" collectionFormat(multi)
+// @Param   name     query string   false "Container name"
+// @Param   ancestor query string   false "Image the container was created from"
+// @Param   network  query string   false "Network the container is connected to"
+// @Param   limit    query int      false "Page size"
+// @Param   cursor   query string   false "Cursor of the page to return"
+// @Param   fields   query []string false "Fields of each container to return, e.g. id,name,state" collectionFormat(csv)
+// @Success 200 {array} docker.ContainerSummary
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router  /docker/containers [get]
 func (dc DockerController) GetAllContainers(ctx *gin.Context) {
-	containersJson, err := dc.cacheClient.Get(ctx.Request.Context(), ALL_CONTAINERS)
+	options, err := listContainersOptions(ctx)
+
+	if err != nil {
+		abortWithError(ctx, errdefs.InvalidParameter(err), "Error retrieving containers!")
+		return
+	}
+
+	// A principal confined to a scope only sees the containers within it.
+	if scope, ok := middlewares.GetScope(ctx); ok {
+		options.Labels = append(options.Labels, scope.Labels...)
+		options.NamePrefix = scope.NamePrefix
+	}
+
+	generation, err := dc.containersCacheGeneration(ctx.Request.Context())
+
+	if err != nil {
+		abortWithError(ctx, err, "Error retrieving containers!")
+		return
+	}
+
+	cacheKey := containersCacheKey(generation, options)
+	cachedJson, err := dc.cacheClient.Get(ctx.Request.Context(), cacheKey)
+
+	var cached cachedContainers
 
 	if err == cache.CacheNil {
-		Logger().Info("Key does not exist in the cache storage", zap.String("Key", ALL_CONTAINERS))
+		LoggerFromContext(ctx.Request.Context()).Info("Key does not exist in the cache storage", zap.String("Key", cacheKey))
 	} else if err != nil {
-		err = errors.Wrapf(err, "there is an error while getting the value from the cache storage. Key:%s", ALL_CONTAINERS)
-		Logger().Error(err.Error())
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Message": "Error retrieving containers!", "Error": err.Error()})
-		ctx.Abort()
+		err = errors.Wrapf(err, "there is an error while getting the value from the cache storage. Key:%s", cacheKey)
+		abortWithError(ctx, err, "Error retrieving containers!")
 		return
+	} else if err = json.Unmarshal([]byte(cachedJson), &cached); err != nil {
+		// Entries written in an older format are ignored and overwritten.
+		LoggerFromContext(ctx.Request.Context()).Warn("Ignoring an unreadable value in the cache storage", zap.String("Key", cacheKey), zap.Error(err))
 	} else {
-		ctx.String(http.StatusOK, containersJson)
+		writeContainers(ctx, cached)
 		return
 	}
 
-	containersJson, err = dc.dockerClient.GetAllContainersJson(ctx.Request.Context())
+	containers, nextCursor, err := dc.dockerClient.ListContainers(ctx.Request.Context(), options)
 
 	if err != nil {
 		err = errors.Wrap(err, "there is an error while getting the containers info")
-		Logger().Error(err.Error())
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Message": "Error retrieving containers!", "Error": err.Error()})
-		ctx.Abort()
+		abortWithError(ctx, err, "Error retrieving containers!")
 		return
 	}
 
-	err = dc.cacheClient.Set(ctx.Request.Context(), ALL_CONTAINERS, containersJson, time.Minute)
+	cached = cachedContainers{Containers: containers, NextCursor: nextCursor}
+	cachedBytes, err := json.Marshal(cached)
+
+	if err == nil {
+		err = dc.cacheClient.Set(ctx.Request.Context(), cacheKey, string(cachedBytes), time.Minute)
+	}
 
 	if err != nil {
-		err = errors.Wrapf(err, "there is an error while setting a value into the cache storage. Value:%s", containersJson)
-		Logger().Error(err.Error())
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Message": "Error retrieving containers!", "Error": err.Error()})
-		ctx.Abort()
+		err = errors.Wrapf(err, "there is an error while setting a value into the cache storage. Key:%s", cacheKey)
+		abortWithError(ctx, err, "Error retrieving containers!")
 		return
 	}
 
-	ctx.String(http.StatusOK, containersJson)
+	writeContainers(ctx, cached)
+}
+
+func writeContainers(ctx *gin.Context, cached cachedContainers) {
+	if cached.NextCursor != "" {
+		ctx.Header("X-Next-Cursor", cached.NextCursor)
+	}
+
+	writeJSON(ctx, http.StatusOK, cached.Containers, "Error retrieving containers!")
+}
+
+func listContainersOptions(ctx *gin.Context) (docker.ListContainersOptions, error) {
+	options := docker.ListContainersOptions{
+		All:      ctx.Query("all") == "true",
+		Statuses: ctx.QueryArray("status"),
+		Labels:   ctx.QueryArray("label"),
+		Name:     ctx.Query("name"),
+		Ancestor: ctx.Query("ancestor"),
+		Network:  ctx.Query("network"),
+		Cursor:   ctx.Query("cursor"),
+	}
+
+	for _, status := range options.Statuses {
+		if !containsString(docker.ContainerStatuses, status) {
+			return options, errors.Errorf("unknown container status. Status:%s", status)
+		}
+	}
+
+	if limit, ok := ctx.GetQuery("limit"); ok {
+		var err error
+
+		if options.Limit, err = strconv.Atoi(limit); err != nil || options.Limit < 1 || options.Limit > maxContainersLimit {
+			return options, errors.Errorf("limit must be a number between 1 and %d. Limit:%s", maxContainersLimit, limit)
+		}
+	}
+
+	return options, nil
+}
+
+// containersCacheKey derives the cache key from the cache generation and the parsed query, so equivalent queries share an entry
+// regardless of the order or the spelling of their parameters.
+func containersCacheKey(generation string, options docker.ListContainersOptions) string {
+	statuses := append([]string{}, options.Statuses...)
+	labels := append([]string{}, options.Labels...)
+
+	sort.Strings(statuses)
+	sort.Strings(labels)
+
+	query := url.Values{
+		"all":        {strconv.FormatBool(options.All)},
+		"status":     statuses,
+		"label":      labels,
+		"name":       {options.Name},
+		"namePrefix": {options.NamePrefix},
+		"ancestor":   {options.Ancestor},
+		"network":    {options.Network},
+		"limit":      {strconv.Itoa(options.Limit)},
+		"cursor":     {options.Cursor},
+	}
+
+	return CONTAINERS_CACHE_KEY_PREFIX + generation + ":" + query.Encode()
 }
 
 // GetDetailedContainer godoc
@@ -81,56 +207,136 @@ func (dc DockerController) GetAllContainers(ctx *gin.Context) {
 // @Tags 	Docker
 // @Accept 	json
 // @Produce json
-// @Param   id path string true "Container ID"
-// @Success 200 {string} Status
+// @Param   id     path  string   true  "Container ID"
+// @Param   fields query []string false "Fields to return, e.g. state,config" collectionFormat(csv)
+// @Success 200 {object} docker.ContainerDetail
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router 	/docker/containers/{id} [get]
 func (dc DockerController) GetDetailedContainer(ctx *gin.Context) {
 	containerId := ctx.Param("id")
-	containerDetailJson, err := dc.dockerClient.GetDetailedContainerJson(ctx.Request.Context(), containerId)
+	containerDetail, err := dc.dockerClient.InspectContainer(ctx.Request.Context(), containerId)
 
 	if err != nil {
 		err = errors.Wrapf(err, "there is an error while getting detailed container info. ContainerId:%s", containerId)
-		Logger().Error(err.Error())
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Message": "Error retrieving container detail!", "Error": err.Error()})
-		ctx.Abort()
+		abortWithError(ctx, err, "Error retrieving container detail!")
 		return
 	}
 
-	ctx.String(http.StatusOK, containerDetailJson)
+	writeJSON(ctx, http.StatusOK, containerDetail, "Error retrieving container detail!")
 }
 
 // CreateContainer godoc
-// @Summary Creates a container by the given parameters
+// @Summary Creates a container by the given parameters, pulling its image according to the pull policy and starting it unless autoStart is false
+// @Description With async=true the pull and the create run as a background job whose progress, ending with the id of the container, is available under /jobs/{id}.
 // @Tags 	Docker
 // @Accept  json
 // @Produce json
-// @Param   Container body models.Container true "Create Container"
-// @Success 201 {string} Status
+// @Param   Container body  models.Container true  "Create Container"
+// @Param   async     query bool             false "Run the create as a background job"
+// @Success 201 {object} models.SuccessResponse
+// @Success 202 {object} jobs.Snapshot
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router  /docker/containers [post]
 func (dc DockerController) CreateContainer(ctx *gin.Context) {
 	var newContainer models.Container
-	if err := ctx.BindJSON(&newContainer); err != nil {
+	if err := ctx.ShouldBindJSON(&newContainer); err != nil {
 		err = errors.Wrap(err, "there is an error while validating parameters of container")
-		Logger().Error(err.Error())
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Message": "Error creating container!", "Error": err.Error()})
-		ctx.Abort()
+		abortWithError(ctx, errdefs.InvalidParameter(err), "Error creating container!")
+		return
+	}
+
+	config, hostConfig, networkingConfig, err := newContainer.DockerConfigs()
+
+	if err != nil {
+		err = errors.Wrap(err, "there is an error while validating parameters of container")
+		abortWithError(ctx, errdefs.InvalidParameter(err), "Error creating container!")
+		return
+	}
+
+	if scope, ok := middlewares.GetScope(ctx); ok && !scope.Allows(newContainer.ContainerName, newContainer.Labels) {
+		err = errors.Errorf("container is outside of the scope of the principal. ContainerName:%s", newContainer.ContainerName)
+		abortWithError(ctx, errdefs.Forbidden(err), "Action create is not allowed!")
+		return
+	}
+
+	createOptions := docker.CreateContainerOptions{
+		Name:             newContainer.ContainerName,
+		Config:           config,
+		HostConfig:       hostConfig,
+		NetworkingConfig: networkingConfig,
+		PullPolicy:       docker.PullPolicy(newContainer.PullPolicy),
+		AutoStart:        newContainer.ShouldAutoStart(),
+	}
+
+	// The policy is checked before anything is pulled, so that a rejected image never reaches the host.
+	if err = dc.containerPolicy.Check(createOptions); err != nil {
+		err = errors.Wrap(err, "there is an error while checking the container policy")
+		abortWithError(ctx, err, "Error creating container!")
+		return
+	}
+
+	action := "created"
+
+	if newContainer.ShouldAutoStart() {
+		action = "created and started"
+	}
+
+	// Pulling the image may take minutes, which is why the create can be left to a job.
+	if ctx.Query("async") == "true" {
+		target := newContainer.ContainerName
+
+		if target == "" {
+			target = newContainer.ImageName
+		}
+
+		job := dc.jobManager.Start("container-create", target, jobOwner(ctx), func(jobCtx context.Context, report func(jobs.Progress)) error {
+			createOptions.OnPullProgress = reportPullProgress(newContainer.ImageName, report)
+
+			containerId, err := dc.dockerClient.CreateContainer(jobCtx, createOptions)
+			dc.invalidateContainersCache(jobCtx)
+
+			if err != nil {
+				return errors.Wrap(err, "there is an error while creating container")
+			}
+
+			report(jobs.Progress{Id: containerId, Status: action})
+
+			return nil
+		})
+
+		ctx.Header("Location", "/api/v1/jobs/"+job.Id)
+		ctx.JSON(http.StatusAccepted, job)
 		return
 	}
 
-	containerId, err := dc.dockerClient.CreateContainer(ctx.Request.Context(), newContainer.ImageName, newContainer.ContainerName)
+	containerId, err := dc.dockerClient.CreateContainer(ctx.Request.Context(), createOptions)
+	dc.invalidateContainersCache(ctx.Request.Context())
 
 	if err != nil {
 		err = errors.Wrap(err, "there is an error while creating container")
-		Logger().Error(err.Error())
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Message": "Error creating container!", "Error": err.Error()})
-		ctx.Abort()
+		abortWithError(ctx, err, "Error creating container!")
 		return
 	}
 
 	if newContainer.ContainerName != "" {
-		ctx.JSON(http.StatusCreated, gin.H{"Success": "Container " + newContainer.ContainerName + " created from the image " + newContainer.ImageName + " with id: " + containerId})
+		ctx.JSON(http.StatusCreated, models.SuccessResponse{Success: "Container " + newContainer.ContainerName + " " + action + " from the image " + newContainer.ImageName + " with id: " + containerId})
 	} else {
-		ctx.JSON(http.StatusCreated, gin.H{"Success": "Container created from the image " + newContainer.ImageName + " with id: " + containerId})
+		ctx.JSON(http.StatusCreated, models.SuccessResponse{Success: "Container " + action + " from the image " + newContainer.ImageName + " with id: " + containerId})
 	}
 }
 
@@ -140,19 +346,502 @@ func (dc DockerController) CreateContainer(ctx *gin.Context) {
 // @Accept  json
 // @Produce json
 // @Param   id path string true "Container ID"
-// @Success 200 {string} Status
+// @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router  /docker/containers/{id} [delete]
 func (dc DockerController) DeleteContainer(ctx *gin.Context) {
 	containerId := ctx.Param("id")
 	err := dc.dockerClient.DeleteContainer(ctx.Request.Context(), containerId)
+	dc.invalidateContainersCache(ctx.Request.Context())
 
 	if err != nil {
 		err = errors.Wrapf(err, "there is an error while deleting container. ContainerId:%s", containerId)
-		Logger().Error(err.Error())
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Message": "Error deleting container!", "Error": err.Error()})
-		ctx.Abort()
+		abortWithError(ctx, err, "Error deleting container!")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.SuccessResponse{Success: "Container# " + containerId + " deleted"})
+}
+
+// StartContainer godoc
+// @Summary Starts a container
+// @Tags    Docker
+// @Accept  json
+// @Produce json
+// @Param   id path string true "Container ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/containers/{id}/start [post]
+func (dc DockerController) StartContainer(ctx *gin.Context) {
+	containerId := ctx.Param("id")
+	err := dc.dockerClient.StartContainer(ctx.Request.Context(), containerId)
+	dc.invalidateContainersCache(ctx.Request.Context())
+
+	if err != nil {
+		err = errors.Wrapf(err, "there is an error while starting container. ContainerId:%s", containerId)
+		abortWithError(ctx, err, "Error starting container!")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.SuccessResponse{Success: "Container# " + containerId + " started"})
+}
+
+// StopContainer godoc
+// @Summary Stops a container
+// @Tags    Docker
+// @Accept  json
+// @Produce json
+// @Param   id      path  string true  "Container ID"
+// @Param   timeout query int    false "Seconds to wait before killing the container"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/containers/{id}/stop [post]
+func (dc DockerController) StopContainer(ctx *gin.Context) {
+	containerId := ctx.Param("id")
+	timeout, err := timeoutQuery(ctx)
+
+	if err != nil {
+		abortWithError(ctx, errdefs.InvalidParameter(err), "Error stopping container!")
+		return
+	}
+
+	err = dc.dockerClient.StopContainer(ctx.Request.Context(), containerId, timeout)
+	dc.invalidateContainersCache(ctx.Request.Context())
+
+	if err != nil {
+		err = errors.Wrapf(err, "there is an error while stopping container. ContainerId:%s", containerId)
+		abortWithError(ctx, err, "Error stopping container!")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.SuccessResponse{Success: "Container# " + containerId + " stopped"})
+}
+
+// RestartContainer godoc
+// @Summary Restarts a container
+// @Tags    Docker
+// @Accept  json
+// @Produce json
+// @Param   id      path  string true  "Container ID"
+// @Param   timeout query int    false "Seconds to wait before killing the container"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/containers/{id}/restart [post]
+func (dc DockerController) RestartContainer(ctx *gin.Context) {
+	containerId := ctx.Param("id")
+	timeout, err := timeoutQuery(ctx)
+
+	if err != nil {
+		abortWithError(ctx, errdefs.InvalidParameter(err), "Error restarting container!")
+		return
+	}
+
+	err = dc.dockerClient.RestartContainer(ctx.Request.Context(), containerId, timeout)
+	dc.invalidateContainersCache(ctx.Request.Context())
+
+	if err != nil {
+		err = errors.Wrapf(err, "there is an error while restarting container. ContainerId:%s", containerId)
+		abortWithError(ctx, err, "Error restarting container!")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.SuccessResponse{Success: "Container# " + containerId + " restarted"})
+}
+
+// PauseContainer godoc
+// @Summary Pauses all processes within a container
+// @Tags    Docker
+// @Accept  json
+// @Produce json
+// @Param   id path string true "Container ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/containers/{id}/pause [post]
+func (dc DockerController) PauseContainer(ctx *gin.Context) {
+	containerId := ctx.Param("id")
+	err := dc.dockerClient.PauseContainer(ctx.Request.Context(), containerId)
+	dc.invalidateContainersCache(ctx.Request.Context())
+
+	if err != nil {
+		err = errors.Wrapf(err, "there is an error while pausing container. ContainerId:%s", containerId)
+		abortWithError(ctx, err, "Error pausing container!")
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"Success": "Container# " + containerId + " deleted"})
+	ctx.JSON(http.StatusOK, models.SuccessResponse{Success: "Container# " + containerId + " paused"})
+}
+
+// UnpauseContainer godoc
+// @Summary Resumes all processes within a paused container
+// @Tags    Docker
+// @Accept  json
+// @Produce json
+// @Param   id path string true "Container ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/containers/{id}/unpause [post]
+func (dc DockerController) UnpauseContainer(ctx *gin.Context) {
+	containerId := ctx.Param("id")
+	err := dc.dockerClient.UnpauseContainer(ctx.Request.Context(), containerId)
+	dc.invalidateContainersCache(ctx.Request.Context())
+
+	if err != nil {
+		err = errors.Wrapf(err, "there is an error while unpausing container. ContainerId:%s", containerId)
+		abortWithError(ctx, err, "Error unpausing container!")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.SuccessResponse{Success: "Container# " + containerId + " unpaused"})
+}
+
+// KillContainer godoc
+// @Summary Sends a signal to a container, SIGKILL by default
+// @Tags    Docker
+// @Accept  json
+// @Produce json
+// @Param   id     path  string true  "Container ID"
+// @Param   signal query string false "Signal to send, e.g. SIGTERM or 9"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/containers/{id}/kill [post]
+func (dc DockerController) KillContainer(ctx *gin.Context) {
+	containerId := ctx.Param("id")
+	signal := ctx.DefaultQuery("signal", "SIGKILL")
+	err := dc.dockerClient.KillContainer(ctx.Request.Context(), containerId, signal)
+	dc.invalidateContainersCache(ctx.Request.Context())
+
+	if err != nil {
+		err = errors.Wrapf(err, "there is an error while killing container. ContainerId:%s", containerId)
+		abortWithError(ctx, err, "Error killing container!")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.SuccessResponse{Success: "Container# " + containerId + " killed with " + signal})
+}
+
+// GetContainerLogs godoc
+// @Summary Gets the logs of a container
+// @Description Returns the log lines tagged by stream. With follow=true the lines are streamed as "log" server-sent events until the client disconnects.
+// @Tags    Docker
+// @Accept  json
+// @Produce json
+// @Produce text/event-stream
+// @Param   id         path  string   true  "Container ID"
+// @Param   stdout     query bool     false "Include stdout, true by default"
+// @Param   stderr     query bool     false "Include stderr, true by default"
+// @Param   tail       query string   false "Number of lines to return from the end of the logs, or all"
+// @Param   since      query string   false "Only logs since this timestamp or relative duration, e.g. 2022-04-01T10:00:00Z or 10m"
+// @Param   until      query string   false "Only logs before this timestamp or relative duration"
+// @Param   timestamps query bool     false "Include the timestamp of every line"
+// @Param   follow     query bool     false "Keep streaming new log lines"
+// @Param   fields     query []string false "Fields of each line to return without follow, e.g. text" collectionFormat(csv)
+// @Success 200 {array} docker.LogLine
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/containers/{id}/logs [get]
+func (dc DockerController) GetContainerLogs(ctx *gin.Context) {
+	containerId := ctx.Param("id")
+	options := docker.LogOptions{
+		Stdout:     ctx.DefaultQuery("stdout", "true") == "true",
+		Stderr:     ctx.DefaultQuery("stderr", "true") == "true",
+		Tail:       ctx.DefaultQuery("tail", "all"),
+		Since:      ctx.Query("since"),
+		Until:      ctx.Query("until"),
+		Timestamps: ctx.Query("timestamps") == "true",
+		Follow:     ctx.Query("follow") == "true",
+	}
+
+	if tail, err := strconv.Atoi(options.Tail); options.Tail != "all" && (err != nil || tail < 0) {
+		abortWithError(ctx, errdefs.InvalidParameter(errors.Errorf("tail must be all or a non-negative number of lines. Tail:%s", options.Tail)), "Error retrieving container logs!")
+		return
+	}
+
+	if !options.Stdout && !options.Stderr {
+		abortWithError(ctx, errdefs.InvalidParameter(errors.New("at least one of stdout and stderr must be selected")), "Error retrieving container logs!")
+		return
+	}
+
+	// The container is looked up once for its tty mode, which also reports a missing container before a log stream starts.
+	containerDetail, err := dc.dockerClient.InspectContainer(ctx.Request.Context(), containerId)
+
+	if err != nil {
+		err = errors.Wrapf(err, "there is an error while getting container logs. ContainerId:%s", containerId)
+		abortWithError(ctx, err, "Error retrieving container logs!")
+		return
+	}
+
+	options.Tty = containerDetail.Config.Tty
+
+	if options.Follow {
+		dc.followContainerLogs(ctx, containerId, options)
+		return
+	}
+
+	logLines := []docker.LogLine{}
+	err = dc.dockerClient.StreamContainerLogs(ctx.Request.Context(), containerId, options, func(line docker.LogLine) {
+		logLines = append(logLines, line)
+	})
+
+	if err != nil {
+		err = errors.Wrapf(err, "there is an error while getting container logs. ContainerId:%s", containerId)
+		abortWithError(ctx, err, "Error retrieving container logs!")
+		return
+	}
+
+	writeJSON(ctx, http.StatusOK, logLines, "Error retrieving container logs!")
+}
+
+func (dc DockerController) followContainerLogs(ctx *gin.Context, containerId string, options docker.LogOptions) {
+	startEventStream(ctx)
+	ctx.Writer.Flush()
+
+	err := dc.dockerClient.StreamContainerLogs(ctx.Request.Context(), containerId, options, func(line docker.LogLine) {
+		sendEvent(ctx, "log", line)
+	})
+
+	if err != nil {
+		err = errors.Wrapf(err, "there is an error while following container logs. ContainerId:%s", containerId)
+		sendErrorEvent(ctx, err, "Error following container logs!")
+	}
+}
+
+// GetContainerStats godoc
+// @Summary Gets the resource usage of a container
+// @Description With stream=true samples are sent as "stats" server-sent events until the client disconnects, at most once per interval.
+// @Tags    Docker
+// @Accept  json
+// @Produce json
+// @Produce text/event-stream
+// @Param   id       path  string   true  "Container ID"
+// @Param   stream   query bool     false "Keep streaming samples"
+// @Param   interval query int      false "Minimum seconds between streamed samples"
+// @Param   fields   query []string false "Fields to return without stream, e.g. cpuPercent,memoryUsage" collectionFormat(csv)
+// @Success 200 {object} docker.ContainerStats
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/containers/{id}/stats [get]
+func (dc DockerController) GetContainerStats(ctx *gin.Context) {
+	containerId := ctx.Param("id")
+
+	if ctx.Query("stream") == "true" {
+		dc.streamContainerStats(ctx, containerId)
+		return
+	}
+
+	stats, err := dc.dockerClient.GetContainerStats(ctx.Request.Context(), containerId)
+
+	if err != nil {
+		err = errors.Wrapf(err, "there is an error while getting container stats. ContainerId:%s", containerId)
+		abortWithError(ctx, err, "Error retrieving container stats!")
+		return
+	}
+
+	writeJSON(ctx, http.StatusOK, stats, "Error retrieving container stats!")
+}
+
+func (dc DockerController) streamContainerStats(ctx *gin.Context, containerId string) {
+	intervalSeconds, err := strconv.Atoi(ctx.DefaultQuery("interval", "0"))
+
+	if err != nil || intervalSeconds < 0 {
+		abortWithError(ctx, errdefs.InvalidParameter(errors.Errorf("interval must be a non-negative number of seconds. Interval:%s", ctx.Query("interval"))), "Error streaming container stats!")
+		return
+	}
+
+	interval := time.Duration(intervalSeconds) * time.Second
+
+	var lastSent time.Time
+	started := false
+
+	// The event stream only starts with the first sample, so that the engine reports a missing container as a plain error response.
+	err = dc.dockerClient.StreamContainerStats(ctx.Request.Context(), containerId, func(stats docker.ContainerStats) {
+		if !started {
+			startEventStream(ctx)
+			started = true
+		}
+
+		if stats.Read.Sub(lastSent) < interval {
+			return
+		}
+
+		lastSent = stats.Read
+		sendEvent(ctx, "stats", stats)
+	})
+
+	if err != nil {
+		err = errors.Wrapf(err, "there is an error while streaming container stats. ContainerId:%s", containerId)
+
+		if !started {
+			abortWithError(ctx, err, "Error streaming container stats!")
+			return
+		}
+
+		sendErrorEvent(ctx, err, "Error streaming container stats!")
+	}
+}
+
+// GetAggregatedStats godoc
+// @Summary Gets the resource usage of every running container and their totals
+// @Tags    Docker
+// @Accept  json
+// @Produce json
+// @Param   fields query []string false "Fields to return, e.g. cpuPercent,memoryUsage" collectionFormat(csv)
+// @Success 200 {object} docker.AggregatedStats
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/stats [get]
+func (dc DockerController) GetAggregatedStats(ctx *gin.Context) {
+	stats, err := dc.dockerClient.GetAggregatedStats(ctx.Request.Context())
+
+	if err != nil {
+		err = errors.Wrap(err, "there is an error while getting aggregated container stats")
+		abortWithError(ctx, err, "Error retrieving container stats!")
+		return
+	}
+
+	writeJSON(ctx, http.StatusOK, stats, "Error retrieving container stats!")
+}
+
+// GetEvents godoc
+// @Summary Streams the events of the docker engine as server-sent events
+// @Description Every event is sent as an "event" server-sent event. Filters can be repeated, e.g. action=die&action=oom.
+// @Description With since, past events are replayed first. With until, the stream ends once that time is reached.
+// @Tags    Docker
+// @Produce text/event-stream
+// @Param   type      query []string false "Object type, e.g. container or image" collectionFormat(multi)
+// @Param   action    query []string false "Action, e.g. die, oom or health_status" collectionFormat(multi)
+// @Param   container query []string false "Container ID or name" collectionFormat(multi)
+// @Param   image     query []string false "Image ID or reference" collectionFormat(multi)
+// @Param   label     query []string false "Label as key or key=value" collectionFormat(multi)
+// @Param   since     query string   false "Replay events since this timestamp or relative duration, e.g. 2022-04-01T10:00:00Z or 10m"
+// @Param   until     query string   false "Stop streaming at this timestamp or relative duration"
+// @Success 200 {object} docker.Event
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/events [get]
+func (dc DockerController) GetEvents(ctx *gin.Context) {
+	options := docker.EventOptions{
+		Types:      ctx.QueryArray("type"),
+		Actions:    ctx.QueryArray("action"),
+		Containers: ctx.QueryArray("container"),
+		Images:     ctx.QueryArray("image"),
+		Labels:     ctx.QueryArray("label"),
+		Since:      ctx.Query("since"),
+		Until:      ctx.Query("until"),
+	}
+
+	for _, eventType := range options.Types {
+		if !containsString(docker.EventTypes, eventType) {
+			abortWithError(ctx, errdefs.InvalidParameter(errors.Errorf("unknown event type. Type:%s", eventType)), "Error streaming events!")
+			return
+		}
+	}
+
+	startEventStream(ctx)
+	ctx.Writer.Flush()
+
+	err := dc.dockerClient.StreamEvents(ctx.Request.Context(), options, func(event docker.Event) {
+		sendEvent(ctx, "event", event)
+	})
+
+	if err != nil {
+		err = errors.Wrap(err, "there is an error while streaming events")
+		sendErrorEvent(ctx, err, "Error streaming events!")
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// timeoutQuery reads the optional "timeout" query parameter in seconds.
+// A nil duration lets the docker engine use the container's own stop timeout.
+func timeoutQuery(ctx *gin.Context) (*time.Duration, error) {
+	value, ok := ctx.GetQuery("timeout")
+
+	if !ok {
+		return nil, nil
+	}
+
+	seconds, err := strconv.Atoi(value)
+
+	if err != nil || seconds < 0 {
+		return nil, errors.Errorf("timeout must be a non-negative number of seconds. Timeout:%s", value)
+	}
+
+	timeout := time.Duration(seconds) * time.Second
+
+	return &timeout, nil
 }
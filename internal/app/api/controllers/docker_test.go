@@ -7,12 +7,14 @@ import (
 	"errors"
 	"godopi/internal/app/api/models"
 	"godopi/internal/pkg/cache"
+	"godopi/internal/pkg/docker"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/docker/docker/errdefs"
 	"github.com/gin-gonic/gin"
 	"gotest.tools/v3/assert"
 )
@@ -21,37 +23,187 @@ type mockCacheClient struct {
 	MockGet func(ctx context.Context, key string) (string, error)
 	MockSet func(ctx context.Context, key string, value interface{}, expiration time.Duration) error
 	MockDel func(ctx context.Context, keys ...string) error
+
+	MockDelByPrefix func(ctx context.Context, prefix string) error
 }
 
 func (mcc *mockCacheClient) Get(ctx context.Context, key string) (string, error) {
 	return mcc.MockGet(ctx, key)
 }
 func (mcc *mockCacheClient) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	if mcc.MockSet == nil {
+		return nil
+	}
 	return mcc.MockSet(ctx, key, value, expiration)
 }
 func (mcc *mockCacheClient) Del(ctx context.Context, keys ...string) error {
+	if mcc.MockDel == nil {
+		return nil
+	}
 	return mcc.MockDel(ctx, keys...)
 }
+func (mcc *mockCacheClient) DelByPrefix(ctx context.Context, prefix string) error {
+	if mcc.MockDelByPrefix == nil {
+		return nil
+	}
+	return mcc.MockDelByPrefix(ctx, prefix)
+}
 
 type mockDockerClient struct {
-	MockGetAllContainersJson     func(c context.Context) (string, error)
-	MockGetDetailedContainerJson func(c context.Context, containerId string) (string, error)
-	MockCreateContainer          func(c context.Context, imageName string, containerName string) (string, error)
-	MockDeleteContainer          func(c context.Context, containerId string) error
+	MockListContainers       func(c context.Context, options docker.ListContainersOptions) ([]docker.ContainerSummary, string, error)
+	MockInspectContainer     func(c context.Context, containerId string) (docker.ContainerDetail, error)
+	MockCreateContainer      func(c context.Context, options docker.CreateContainerOptions) (string, error)
+	MockDeleteContainer      func(c context.Context, containerId string) error
+	MockStartContainer       func(c context.Context, containerId string) error
+	MockStopContainer        func(c context.Context, containerId string, timeout *time.Duration) error
+	MockRestartContainer     func(c context.Context, containerId string, timeout *time.Duration) error
+	MockPauseContainer       func(c context.Context, containerId string) error
+	MockUnpauseContainer     func(c context.Context, containerId string) error
+	MockKillContainer        func(c context.Context, containerId string, signal string) error
+	MockStreamContainerLogs  func(c context.Context, containerId string, options docker.LogOptions, onLine func(docker.LogLine)) error
+	MockGetContainerStats    func(c context.Context, containerId string) (docker.ContainerStats, error)
+	MockStreamContainerStats func(c context.Context, containerId string, onStats func(docker.ContainerStats)) error
+	MockGetAggregatedStats   func(c context.Context) (docker.AggregatedStats, error)
+	MockStreamEvents         func(c context.Context, options docker.EventOptions, onEvent func(docker.Event)) error
+	MockCreateExec           func(c context.Context, containerId string, options docker.ExecOptions) (string, error)
+	MockAttachExec           func(c context.Context, execId string, tty bool) (docker.ExecStream, error)
+	MockInspectExec          func(c context.Context, execId string) (docker.ExecState, error)
+	MockResizeExec           func(c context.Context, execId string, height uint, width uint) error
+	MockListImages           func(c context.Context, all bool, dangling bool) ([]docker.ImageSummary, error)
+	MockInspectImage         func(c context.Context, imageId string) (docker.ImageDetail, error)
+	MockPullImage            func(c context.Context, imageReference string, onProgress func(docker.PullProgress)) error
+	MockTagImage             func(c context.Context, sourceImage string, targetImage string) error
+	MockDeleteImage          func(c context.Context, imageId string, force bool, pruneChildren bool) ([]docker.ImageDeleteItem, error)
+	MockGetImageHistory      func(c context.Context, imageId string) ([]docker.ImageHistoryItem, error)
+	MockPruneImages          func(c context.Context, all bool) (docker.ImagePruneReport, error)
+	MockListVolumes          func(c context.Context, options docker.ListVolumesOptions) ([]docker.Volume, error)
+	MockCreateVolume         func(c context.Context, options docker.CreateVolumeOptions) (docker.Volume, error)
+	MockInspectVolume        func(c context.Context, volumeName string) (docker.Volume, error)
+	MockDeleteVolume         func(c context.Context, volumeName string, force bool) error
+	MockPruneVolumes         func(c context.Context, labels []string) (docker.VolumePruneReport, error)
+	MockListNetworks         func(c context.Context, options docker.ListNetworksOptions) ([]docker.Network, error)
+	MockInspectNetwork       func(c context.Context, networkId string) (docker.Network, error)
+	MockCreateNetwork        func(c context.Context, options docker.CreateNetworkOptions) (docker.Network, error)
+	MockDeleteNetwork        func(c context.Context, networkId string) error
+	MockPruneNetworks        func(c context.Context, labels []string) (docker.NetworkPruneReport, error)
+	MockConnectNetwork       func(c context.Context, networkId string, containerId string, options docker.ConnectNetworkOptions) error
+	MockDisconnectNetwork    func(c context.Context, networkId string, containerId string, force bool) error
 }
 
-func (mdc *mockDockerClient) GetAllContainersJson(ctx context.Context) (string, error) {
-	return mdc.MockGetAllContainersJson(ctx)
+func (mdc *mockDockerClient) ListContainers(ctx context.Context, options docker.ListContainersOptions) ([]docker.ContainerSummary, string, error) {
+	return mdc.MockListContainers(ctx, options)
 }
-func (mdc *mockDockerClient) GetDetailedContainerJson(ctx context.Context, containerId string) (string, error) {
-	return mdc.MockGetDetailedContainerJson(ctx, containerId)
+func (mdc *mockDockerClient) InspectContainer(ctx context.Context, containerId string) (docker.ContainerDetail, error) {
+	return mdc.MockInspectContainer(ctx, containerId)
 }
-func (mdc *mockDockerClient) CreateContainer(ctx context.Context, imageName string, containerName string) (string, error) {
-	return mdc.MockCreateContainer(ctx, imageName, containerName)
+func (mdc *mockDockerClient) CreateContainer(ctx context.Context, options docker.CreateContainerOptions) (string, error) {
+	return mdc.MockCreateContainer(ctx, options)
 }
 func (mdc *mockDockerClient) DeleteContainer(ctx context.Context, containerId string) error {
 	return mdc.MockDeleteContainer(ctx, containerId)
 }
+func (mdc *mockDockerClient) StartContainer(ctx context.Context, containerId string) error {
+	return mdc.MockStartContainer(ctx, containerId)
+}
+func (mdc *mockDockerClient) StopContainer(ctx context.Context, containerId string, timeout *time.Duration) error {
+	return mdc.MockStopContainer(ctx, containerId, timeout)
+}
+func (mdc *mockDockerClient) RestartContainer(ctx context.Context, containerId string, timeout *time.Duration) error {
+	return mdc.MockRestartContainer(ctx, containerId, timeout)
+}
+func (mdc *mockDockerClient) PauseContainer(ctx context.Context, containerId string) error {
+	return mdc.MockPauseContainer(ctx, containerId)
+}
+func (mdc *mockDockerClient) UnpauseContainer(ctx context.Context, containerId string) error {
+	return mdc.MockUnpauseContainer(ctx, containerId)
+}
+func (mdc *mockDockerClient) KillContainer(ctx context.Context, containerId string, signal string) error {
+	return mdc.MockKillContainer(ctx, containerId, signal)
+}
+func (mdc *mockDockerClient) StreamContainerLogs(ctx context.Context, containerId string, options docker.LogOptions, onLine func(docker.LogLine)) error {
+	return mdc.MockStreamContainerLogs(ctx, containerId, options, onLine)
+}
+func (mdc *mockDockerClient) GetContainerStats(ctx context.Context, containerId string) (docker.ContainerStats, error) {
+	return mdc.MockGetContainerStats(ctx, containerId)
+}
+func (mdc *mockDockerClient) StreamContainerStats(ctx context.Context, containerId string, onStats func(docker.ContainerStats)) error {
+	return mdc.MockStreamContainerStats(ctx, containerId, onStats)
+}
+func (mdc *mockDockerClient) GetAggregatedStats(ctx context.Context) (docker.AggregatedStats, error) {
+	return mdc.MockGetAggregatedStats(ctx)
+}
+func (mdc *mockDockerClient) StreamEvents(ctx context.Context, options docker.EventOptions, onEvent func(docker.Event)) error {
+	return mdc.MockStreamEvents(ctx, options, onEvent)
+}
+func (mdc *mockDockerClient) CreateExec(ctx context.Context, containerId string, options docker.ExecOptions) (string, error) {
+	return mdc.MockCreateExec(ctx, containerId, options)
+}
+func (mdc *mockDockerClient) AttachExec(ctx context.Context, execId string, tty bool) (docker.ExecStream, error) {
+	return mdc.MockAttachExec(ctx, execId, tty)
+}
+func (mdc *mockDockerClient) InspectExec(ctx context.Context, execId string) (docker.ExecState, error) {
+	return mdc.MockInspectExec(ctx, execId)
+}
+func (mdc *mockDockerClient) ResizeExec(ctx context.Context, execId string, height uint, width uint) error {
+	return mdc.MockResizeExec(ctx, execId, height, width)
+}
+func (mdc *mockDockerClient) ListImages(ctx context.Context, all bool, dangling bool) ([]docker.ImageSummary, error) {
+	return mdc.MockListImages(ctx, all, dangling)
+}
+func (mdc *mockDockerClient) InspectImage(ctx context.Context, imageId string) (docker.ImageDetail, error) {
+	return mdc.MockInspectImage(ctx, imageId)
+}
+func (mdc *mockDockerClient) PullImage(ctx context.Context, imageReference string, onProgress func(docker.PullProgress)) error {
+	return mdc.MockPullImage(ctx, imageReference, onProgress)
+}
+func (mdc *mockDockerClient) TagImage(ctx context.Context, sourceImage string, targetImage string) error {
+	return mdc.MockTagImage(ctx, sourceImage, targetImage)
+}
+func (mdc *mockDockerClient) DeleteImage(ctx context.Context, imageId string, force bool, pruneChildren bool) ([]docker.ImageDeleteItem, error) {
+	return mdc.MockDeleteImage(ctx, imageId, force, pruneChildren)
+}
+func (mdc *mockDockerClient) GetImageHistory(ctx context.Context, imageId string) ([]docker.ImageHistoryItem, error) {
+	return mdc.MockGetImageHistory(ctx, imageId)
+}
+func (mdc *mockDockerClient) PruneImages(ctx context.Context, all bool) (docker.ImagePruneReport, error) {
+	return mdc.MockPruneImages(ctx, all)
+}
+func (mdc *mockDockerClient) ListVolumes(ctx context.Context, options docker.ListVolumesOptions) ([]docker.Volume, error) {
+	return mdc.MockListVolumes(ctx, options)
+}
+func (mdc *mockDockerClient) CreateVolume(ctx context.Context, options docker.CreateVolumeOptions) (docker.Volume, error) {
+	return mdc.MockCreateVolume(ctx, options)
+}
+func (mdc *mockDockerClient) InspectVolume(ctx context.Context, volumeName string) (docker.Volume, error) {
+	return mdc.MockInspectVolume(ctx, volumeName)
+}
+func (mdc *mockDockerClient) DeleteVolume(ctx context.Context, volumeName string, force bool) error {
+	return mdc.MockDeleteVolume(ctx, volumeName, force)
+}
+func (mdc *mockDockerClient) PruneVolumes(ctx context.Context, labels []string) (docker.VolumePruneReport, error) {
+	return mdc.MockPruneVolumes(ctx, labels)
+}
+func (mdc *mockDockerClient) ListNetworks(ctx context.Context, options docker.ListNetworksOptions) ([]docker.Network, error) {
+	return mdc.MockListNetworks(ctx, options)
+}
+func (mdc *mockDockerClient) InspectNetwork(ctx context.Context, networkId string) (docker.Network, error) {
+	return mdc.MockInspectNetwork(ctx, networkId)
+}
+func (mdc *mockDockerClient) CreateNetwork(ctx context.Context, options docker.CreateNetworkOptions) (docker.Network, error) {
+	return mdc.MockCreateNetwork(ctx, options)
+}
+func (mdc *mockDockerClient) DeleteNetwork(ctx context.Context, networkId string) error {
+	return mdc.MockDeleteNetwork(ctx, networkId)
+}
+func (mdc *mockDockerClient) PruneNetworks(ctx context.Context, labels []string) (docker.NetworkPruneReport, error) {
+	return mdc.MockPruneNetworks(ctx, labels)
+}
+func (mdc *mockDockerClient) ConnectNetwork(ctx context.Context, networkId string, containerId string, options docker.ConnectNetworkOptions) error {
+	return mdc.MockConnectNetwork(ctx, networkId, containerId, options)
+}
+func (mdc *mockDockerClient) DisconnectNetwork(ctx context.Context, networkId string, containerId string, force bool) error {
+	return mdc.MockDisconnectNetwork(ctx, networkId, containerId, force)
+}
 
 func TestGetAllContainersSuccessCaching(t *testing.T) {
 	w := httptest.NewRecorder()
@@ -59,7 +211,7 @@ func TestGetAllContainersSuccessCaching(t *testing.T) {
 
 	mockCacheClient := mockCacheClient{}
 	mockCacheClient.MockGet = func(ctx context.Context, key string) (string, error) {
-		return "cachedContainersMetadataTest", nil
+		return `{"containers":[{"id":"3423ASDF372FA7DF732","name":"cachedContainerTest"}],"nextCursor":""}`, nil
 	}
 
 	dockerController := DockerController{dockerClient: &mockDockerClient{}, cacheClient: &mockCacheClient}
@@ -68,8 +220,13 @@ func TestGetAllContainersSuccessCaching(t *testing.T) {
 	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/", nil)
 	e.ServeHTTP(w, c.Request)
 
+	var containers []docker.ContainerSummary
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &containers))
+
 	assert.Equal(t, http.StatusOK, w.Code)
-	assert.Equal(t, "cachedContainersMetadataTest", w.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, 1, len(containers))
+	assert.Equal(t, "cachedContainerTest", containers[0].Name)
 }
 
 func TestGetAllContainersErrorDockerClient(t *testing.T) {
@@ -84,8 +241,8 @@ func TestGetAllContainersErrorDockerClient(t *testing.T) {
 	errorMessage := "could not get any container info"
 
 	mockDockerClient := mockDockerClient{}
-	mockDockerClient.MockGetAllContainersJson = func(c context.Context) (string, error) {
-		return "", errors.New(errorMessage)
+	mockDockerClient.MockListContainers = func(c context.Context, options docker.ListContainersOptions) ([]docker.ContainerSummary, string, error) {
+		return nil, "", errors.New(errorMessage)
 	}
 
 	dockerController := DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient}
@@ -102,11 +259,11 @@ func TestGetDetailedContainerSuccessDockerClient(t *testing.T) {
 	w := httptest.NewRecorder()
 	c, e := gin.CreateTestContext(w)
 
-	containerDetailedInfoTest := "containerDetailedInfoTest"
+	containerDetailTest := docker.ContainerDetail{Id: "3423ASDF372FA7DF732", Name: "web", State: docker.ContainerState{Status: "running", Running: true}}
 
 	mockDockerClient := mockDockerClient{}
-	mockDockerClient.MockGetDetailedContainerJson = func(c context.Context, containerId string) (string, error) {
-		return containerDetailedInfoTest, nil
+	mockDockerClient.MockInspectContainer = func(c context.Context, containerId string) (docker.ContainerDetail, error) {
+		return containerDetailTest, nil
 	}
 
 	dockerController := DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient{}}
@@ -117,8 +274,12 @@ func TestGetDetailedContainerSuccessDockerClient(t *testing.T) {
 	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/"+containerId, nil)
 	e.ServeHTTP(w, c.Request)
 
+	var containerDetail docker.ContainerDetail
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &containerDetail))
+
 	assert.Equal(t, http.StatusOK, w.Code)
-	assert.Equal(t, containerDetailedInfoTest, w.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.DeepEqual(t, containerDetailTest, containerDetail)
 }
 
 func TestGetDetailedContainerErrorDockerClient(t *testing.T) {
@@ -128,8 +289,8 @@ func TestGetDetailedContainerErrorDockerClient(t *testing.T) {
 	errorMessage := "could not get detailed container info"
 
 	mockDockerClient := mockDockerClient{}
-	mockDockerClient.MockGetDetailedContainerJson = func(c context.Context, containerId string) (string, error) {
-		return "", errors.New(errorMessage)
+	mockDockerClient.MockInspectContainer = func(c context.Context, containerId string) (docker.ContainerDetail, error) {
+		return docker.ContainerDetail{}, errors.New(errorMessage)
 	}
 
 	dockerController := DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient{}}
@@ -151,7 +312,7 @@ func TestCreateContainerSuccessDockerClient(t *testing.T) {
 	createdContainerId := "3423ASDF372FA7DF732"
 
 	mockDockerClient := mockDockerClient{}
-	mockDockerClient.MockCreateContainer = func(c context.Context, imageName, containerName string) (string, error) {
+	mockDockerClient.MockCreateContainer = func(c context.Context, options docker.CreateContainerOptions) (string, error) {
 		return createdContainerId, nil
 	}
 
@@ -174,6 +335,103 @@ func TestCreateContainerSuccessDockerClient(t *testing.T) {
 	assert.Equal(t, true, strings.Contains(w.Body.String(), container.ImageName))
 }
 
+func TestCreateContainerSuccessTranslatesSpec(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	var receivedOptions docker.CreateContainerOptions
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockCreateContainer = func(c context.Context, options docker.CreateContainerOptions) (string, error) {
+		receivedOptions = options
+		return "3423ASDF372FA7DF732", nil
+	}
+
+	dockerController := DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient{}}
+
+	e.POST("/", dockerController.CreateContainer)
+
+	container := models.Container{
+		ImageName:     "nginx:1.21",
+		ContainerName: "web",
+		Env:           map[string]string{"MODE": "production"},
+		Ports:         []models.PortBinding{{ContainerPort: 80, HostPort: 8081}},
+		Mounts:        []models.Mount{{Type: "volume", Source: "web-data", Target: "/usr/share/nginx/html", ReadOnly: true}},
+		RestartPolicy: &models.RestartPolicy{Name: "on-failure", MaximumRetryCount: 3},
+		Resources:     &models.Resources{Cpus: 0.5, MemoryBytes: 64 * 1024 * 1024},
+		Networks:      []models.NetworkAttachment{{Name: "frontend", Aliases: []string{"web"}}, {Name: "backend"}},
+	}
+
+	data, _ := json.Marshal(container)
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/", bytes.NewBuffer(data))
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "web", receivedOptions.Name)
+	assert.Equal(t, "nginx:1.21", receivedOptions.Config.Image)
+	assert.DeepEqual(t, []string{"MODE=production"}, receivedOptions.Config.Env)
+	assert.Equal(t, "8081", receivedOptions.HostConfig.PortBindings["80/tcp"][0].HostPort)
+	assert.Equal(t, "web-data", receivedOptions.HostConfig.Mounts[0].Source)
+	assert.Equal(t, 3, receivedOptions.HostConfig.RestartPolicy.MaximumRetryCount)
+	assert.Equal(t, int64(5e8), receivedOptions.HostConfig.Resources.NanoCPUs)
+	assert.Equal(t, "frontend", string(receivedOptions.HostConfig.NetworkMode))
+	assert.Equal(t, 2, len(receivedOptions.NetworkingConfig.EndpointsConfig))
+}
+
+func TestCreateContainerSuccessWithoutAutoStart(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	var receivedOptions docker.CreateContainerOptions
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockCreateContainer = func(c context.Context, options docker.CreateContainerOptions) (string, error) {
+		receivedOptions = options
+		return "3423ASDF372FA7DF732", nil
+	}
+
+	dockerController := DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient{}}
+
+	e.POST("/", dockerController.CreateContainer)
+
+	autoStart := false
+	container := models.Container{
+		ImageName:  "nginx:1.21",
+		PullPolicy: "if-not-present",
+		AutoStart:  &autoStart,
+	}
+
+	data, _ := json.Marshal(container)
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/", bytes.NewBuffer(data))
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, docker.PullIfNotPresent, receivedOptions.PullPolicy)
+	assert.Equal(t, false, receivedOptions.AutoStart)
+	assert.Equal(t, false, strings.Contains(w.Body.String(), "started"))
+}
+
+func TestCreateContainerErrorInvalidSpec(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	dockerController := DockerController{dockerClient: &mockDockerClient{}, cacheClient: &mockCacheClient{}}
+
+	e.POST("/", dockerController.CreateContainer)
+
+	container := models.Container{
+		ImageName: "nginx:1.21",
+		Mounts:    []models.Mount{{Type: "bind", Source: "relative/path", Target: "/data"}},
+	}
+
+	data, _ := json.Marshal(container)
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/", bytes.NewBuffer(data))
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, true, strings.Contains(w.Body.String(), "relative/path"))
+}
+
 func TestCreateContainerErrorDockerClient(t *testing.T) {
 	w := httptest.NewRecorder()
 	c, e := gin.CreateTestContext(w)
@@ -181,7 +439,7 @@ func TestCreateContainerErrorDockerClient(t *testing.T) {
 	errorMessage := "could not create container"
 
 	mockDockerClient := mockDockerClient{}
-	mockDockerClient.MockCreateContainer = func(c context.Context, imageName, containerName string) (string, error) {
+	mockDockerClient.MockCreateContainer = func(c context.Context, options docker.CreateContainerOptions) (string, error) {
 		return "", errors.New(errorMessage)
 	}
 
@@ -247,3 +505,302 @@ func TestDeleteContainerErrorDockerClient(t *testing.T) {
 	assert.Equal(t, true, strings.Contains(w.Body.String(), deletedContainerId))
 	assert.Equal(t, true, strings.Contains(w.Body.String(), errorMessage))
 }
+
+func TestStopContainerSuccessDockerClient(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	stoppedContainerId := "3423ASDF372FA7DF732"
+	var receivedTimeout *time.Duration
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockStopContainer = func(c context.Context, containerId string, timeout *time.Duration) error {
+		receivedTimeout = timeout
+		return nil
+	}
+
+	dockerController := DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient{}}
+
+	e.POST("/:id/stop", dockerController.StopContainer)
+
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/"+stoppedContainerId+"/stop?timeout=5", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, true, strings.Contains(w.Body.String(), stoppedContainerId))
+	assert.Equal(t, 5*time.Second, *receivedTimeout)
+}
+
+func TestStopContainerErrorInvalidTimeout(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	dockerController := DockerController{dockerClient: &mockDockerClient{}, cacheClient: &mockCacheClient{}}
+
+	e.POST("/:id/stop", dockerController.StopContainer)
+
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/3423ASDF372FA7DF732/stop?timeout=soon", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, true, strings.Contains(w.Body.String(), "timeout"))
+}
+
+func TestKillContainerSuccessDockerClient(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	killedContainerId := "3423ASDF372FA7DF732"
+	receivedSignal := ""
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockKillContainer = func(c context.Context, containerId string, signal string) error {
+		receivedSignal = signal
+		return nil
+	}
+
+	dockerController := DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient{}}
+
+	e.POST("/:id/kill", dockerController.KillContainer)
+
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/"+killedContainerId+"/kill?signal=SIGTERM", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "SIGTERM", receivedSignal)
+}
+
+func TestPauseContainerErrorDockerClient(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	pausedContainerId := "3423ASDF372FA7DF732"
+	errorMessage := "could not pause the container"
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockPauseContainer = func(c context.Context, containerId string) error {
+		return errors.New(errorMessage)
+	}
+
+	dockerController := DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient{}}
+
+	e.POST("/:id/pause", dockerController.PauseContainer)
+
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/"+pausedContainerId+"/pause", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, true, strings.Contains(w.Body.String(), pausedContainerId))
+	assert.Equal(t, true, strings.Contains(w.Body.String(), errorMessage))
+}
+
+func TestGetContainerLogsSuccessDockerClient(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	var receivedOptions docker.LogOptions
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockInspectContainer = func(c context.Context, containerId string) (docker.ContainerDetail, error) {
+		return docker.ContainerDetail{Id: containerId, Config: docker.ContainerConfig{Tty: true}}, nil
+	}
+	mockDockerClient.MockStreamContainerLogs = func(c context.Context, containerId string, options docker.LogOptions, onLine func(docker.LogLine)) error {
+		receivedOptions = options
+		onLine(docker.LogLine{Stream: docker.StreamStdout, Text: "listening on :80"})
+		onLine(docker.LogLine{Stream: docker.StreamStderr, Text: "worker crashed"})
+		return nil
+	}
+
+	dockerController := DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient{}}
+
+	e.GET("/:id/logs", dockerController.GetContainerLogs)
+
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/3423ASDF372FA7DF732/logs?stdout=false&tail=10", nil)
+	e.ServeHTTP(w, c.Request)
+
+	var logLines []docker.LogLine
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &logLines))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, len(logLines))
+	assert.Equal(t, docker.StreamStderr, logLines[1].Stream)
+	assert.Equal(t, false, receivedOptions.Stdout)
+	assert.Equal(t, true, receivedOptions.Stderr)
+	assert.Equal(t, "10", receivedOptions.Tail)
+	assert.Equal(t, true, receivedOptions.Tty)
+}
+
+func TestGetContainerLogsFollowStreamsEvents(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockInspectContainer = func(c context.Context, containerId string) (docker.ContainerDetail, error) {
+		return docker.ContainerDetail{Id: containerId}, nil
+	}
+	mockDockerClient.MockStreamContainerLogs = func(c context.Context, containerId string, options docker.LogOptions, onLine func(docker.LogLine)) error {
+		onLine(docker.LogLine{Stream: docker.StreamStdout, Text: "listening on :80"})
+		return nil
+	}
+
+	dockerController := DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient{}}
+
+	e.GET("/:id/logs", dockerController.GetContainerLogs)
+
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/3423ASDF372FA7DF732/logs?follow=true", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, true, strings.Contains(w.Body.String(), "event:log"))
+	assert.Equal(t, true, strings.Contains(w.Body.String(), "listening on :80"))
+}
+
+func TestGetContainerLogsErrorInvalidTail(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	dockerController := DockerController{dockerClient: &mockDockerClient{}, cacheClient: &mockCacheClient{}}
+
+	e.GET("/:id/logs", dockerController.GetContainerLogs)
+
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/3423ASDF372FA7DF732/logs?tail=last", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetContainerStatsSuccessDockerClient(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockGetContainerStats = func(c context.Context, containerId string) (docker.ContainerStats, error) {
+		return docker.ContainerStats{ContainerId: containerId, CpuPercent: 12.5, MemoryUsage: 1024, MemoryLimit: 4096, MemoryPercent: 25}, nil
+	}
+
+	dockerController := DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient{}}
+
+	e.GET("/:id/stats", dockerController.GetContainerStats)
+
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/3423ASDF372FA7DF732/stats", nil)
+	e.ServeHTTP(w, c.Request)
+
+	var stats docker.ContainerStats
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "3423ASDF372FA7DF732", stats.ContainerId)
+	assert.Equal(t, 25.0, stats.MemoryPercent)
+}
+
+func TestGetContainerStatsStreamHonoursInterval(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockStreamContainerStats = func(c context.Context, containerId string, onStats func(docker.ContainerStats)) error {
+		start := time.Date(2022, 4, 1, 10, 0, 0, 0, time.UTC)
+
+		for i := 0; i < 5; i++ {
+			onStats(docker.ContainerStats{ContainerId: containerId, Read: start.Add(time.Duration(i) * time.Second)})
+		}
+
+		return nil
+	}
+
+	dockerController := DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient{}}
+
+	e.GET("/:id/stats", dockerController.GetContainerStats)
+
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/3423ASDF372FA7DF732/stats?stream=true&interval=2", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 3, strings.Count(w.Body.String(), "event:stats"))
+}
+
+func TestGetContainerStatsStreamErrorNotFound(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockStreamContainerStats = func(c context.Context, containerId string, onStats func(docker.ContainerStats)) error {
+		return errdefs.NotFound(errors.New("no such container: " + containerId))
+	}
+
+	dockerController := DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient{}}
+
+	e.GET("/:id/stats", dockerController.GetContainerStats)
+
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/3423ASDF372FA7DF732/stats?stream=true", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+}
+
+func TestGetAggregatedStatsErrorDockerClient(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	errorMessage := "could not list containers"
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockGetAggregatedStats = func(c context.Context) (docker.AggregatedStats, error) {
+		return docker.AggregatedStats{}, errors.New(errorMessage)
+	}
+
+	dockerController := DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient{}}
+
+	e.GET("/stats", dockerController.GetAggregatedStats)
+
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/stats", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, true, strings.Contains(w.Body.String(), errorMessage))
+}
+
+func TestGetEventsStreamsFilteredEvents(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	var receivedOptions docker.EventOptions
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockStreamEvents = func(c context.Context, options docker.EventOptions, onEvent func(docker.Event)) error {
+		receivedOptions = options
+		onEvent(docker.Event{Type: "container", Action: "die", ActorId: "3423ASDF372FA7DF732"})
+		onEvent(docker.Event{Type: "container", Action: "oom", ActorId: "3423ASDF372FA7DF732"})
+		return nil
+	}
+
+	dockerController := DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient{}}
+
+	e.GET("/events", dockerController.GetEvents)
+
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/events?type=container&action=die&action=oom&label=app=web&since=10m", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, strings.Count(w.Body.String(), "event:event"))
+	assert.DeepEqual(t, []string{"die", "oom"}, receivedOptions.Actions)
+	assert.DeepEqual(t, []string{"app=web"}, receivedOptions.Labels)
+	assert.Equal(t, "10m", receivedOptions.Since)
+}
+
+func TestGetEventsErrorUnknownType(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	dockerController := DockerController{dockerClient: &mockDockerClient{}, cacheClient: &mockCacheClient{}}
+
+	e.GET("/events", dockerController.GetEvents)
+
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/events?type=spaceship", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
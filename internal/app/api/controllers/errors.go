@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"godopi/internal/app/api/middlewares"
+	"godopi/internal/app/api/models"
+	"godopi/internal/pkg/policy"
+	"net/http"
+
+	. "godopi/internal/pkg/logger"
+
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// abortWithError logs the error and answers with the status and error code that match its cause.
+// Errors are classified with the docker errdefs, which the docker client already attaches to the errors of the engine,
+// so validation errors of the API should be marked with errdefs.InvalidParameter and missing resources with errdefs.NotFound.
+func abortWithError(ctx *gin.Context, err error, message string) {
+	status, response := errorResponse(ctx, err, message)
+
+	if status >= http.StatusInternalServerError {
+		LoggerFromContext(ctx.Request.Context()).Error(err.Error())
+	} else {
+		LoggerFromContext(ctx.Request.Context()).Warn(err.Error())
+	}
+
+	ctx.AbortWithStatusJSON(status, response)
+}
+
+// sendErrorEvent reports an error that occurs after an event stream has started and the status can no longer change.
+func sendErrorEvent(ctx *gin.Context, err error, message string) {
+	_, response := errorResponse(ctx, err, message)
+
+	LoggerFromContext(ctx.Request.Context()).Error(err.Error())
+	sendEvent(ctx, "error", response)
+}
+
+func errorResponse(ctx *gin.Context, err error, message string) (int, models.ErrorResponse) {
+	status, code := errorStatus(err)
+	response := models.ErrorResponse{Code: code, Message: message, Details: err.Error(), RequestId: middlewares.GetRequestId(ctx)}
+
+	var violationErr policy.ViolationError
+
+	if errors.As(err, &violationErr) {
+		response.Violations = violationErr.Violations
+	}
+
+	return status, response
+}
+
+func errorStatus(err error) (int, string) {
+	var violationErr policy.ViolationError
+
+	switch {
+	case errors.As(err, &violationErr):
+		return http.StatusUnprocessableEntity, models.ErrorCodePolicyViolation
+	case errdefs.IsInvalidParameter(err):
+		return http.StatusBadRequest, models.ErrorCodeInvalidParameter
+	case errdefs.IsUnauthorized(err):
+		return http.StatusUnauthorized, models.ErrorCodeUnauthorized
+	case errdefs.IsForbidden(err):
+		return http.StatusForbidden, models.ErrorCodeForbidden
+	case errdefs.IsNotFound(err):
+		return http.StatusNotFound, models.ErrorCodeNotFound
+	case errdefs.IsConflict(err):
+		return http.StatusConflict, models.ErrorCodeConflict
+	case errdefs.IsNotImplemented(err):
+		return http.StatusNotImplemented, models.ErrorCodeNotImplemented
+	case errdefs.IsUnavailable(err), client.IsErrConnectionFailed(err):
+		return http.StatusServiceUnavailable, models.ErrorCodeUnavailable
+	default:
+		return http.StatusInternalServerError, models.ErrorCodeInternal
+	}
+}
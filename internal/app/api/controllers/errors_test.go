@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"godopi/internal/app/api/middlewares"
+	"godopi/internal/app/api/models"
+	"godopi/internal/pkg/docker"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/gin-gonic/gin"
+	pkgerrors "github.com/pkg/errors"
+	"gotest.tools/v3/assert"
+)
+
+func TestErrorStatusMapsDockerErrors(t *testing.T) {
+	cause := errors.New("cause")
+
+	testCases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{errdefs.InvalidParameter(cause), http.StatusBadRequest, models.ErrorCodeInvalidParameter},
+		{errdefs.Unauthorized(cause), http.StatusUnauthorized, models.ErrorCodeUnauthorized},
+		{errdefs.NotFound(cause), http.StatusNotFound, models.ErrorCodeNotFound},
+		{errdefs.Conflict(cause), http.StatusConflict, models.ErrorCodeConflict},
+		{errdefs.NotImplemented(cause), http.StatusNotImplemented, models.ErrorCodeNotImplemented},
+		{errdefs.Unavailable(cause), http.StatusServiceUnavailable, models.ErrorCodeUnavailable},
+		{pkgerrors.Wrap(errdefs.NotFound(cause), "there is an error while getting detailed container info"), http.StatusNotFound, models.ErrorCodeNotFound},
+		{cause, http.StatusInternalServerError, models.ErrorCodeInternal},
+	}
+
+	for _, testCase := range testCases {
+		status, code := errorStatus(testCase.err)
+
+		assert.Equal(t, testCase.status, status, testCase.err.Error())
+		assert.Equal(t, testCase.code, code, testCase.err.Error())
+	}
+}
+
+func TestGetDetailedContainerErrorNotFound(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockInspectContainer = func(c context.Context, containerId string) (docker.ContainerDetail, error) {
+		return docker.ContainerDetail{}, errdefs.NotFound(errors.New("No such container: " + containerId))
+	}
+
+	dockerController := DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient{}}
+
+	e.Use(middlewares.RequestId())
+	e.GET("/:id", dockerController.GetDetailedContainer)
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/3423ASDF372FA7DF732", nil)
+	c.Request.Header.Set(middlewares.REQUEST_ID_HEADER, "req-42")
+	e.ServeHTTP(w, c.Request)
+
+	var errorResponse models.ErrorResponse
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &errorResponse))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, models.ErrorCodeNotFound, errorResponse.Code)
+	assert.Equal(t, "Error retrieving container detail!", errorResponse.Message)
+	assert.Equal(t, "req-42", errorResponse.RequestId)
+	assert.Equal(t, "req-42", w.Header().Get(middlewares.REQUEST_ID_HEADER))
+}
+
+func TestCreateContainerErrorConflict(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockCreateContainer = func(c context.Context, options docker.CreateContainerOptions) (string, error) {
+		return "", errdefs.Conflict(errors.New(`the container name "/web" is already in use`))
+	}
+
+	dockerController := DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient{}}
+
+	e.POST("/", dockerController.CreateContainer)
+
+	data, _ := json.Marshal(models.Container{ImageName: "nginx", ContainerName: "web"})
+
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/", bytes.NewBuffer(data))
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestCreateContainerErrorMalformedBody(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	dockerController := DockerController{dockerClient: &mockDockerClient{}, cacheClient: &mockCacheClient{}}
+
+	e.Use(middlewares.RequestId())
+	e.POST("/", dockerController.CreateContainer)
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/", bytes.NewBufferString(`{"containerName":"web"}`))
+	e.ServeHTTP(w, c.Request)
+
+	var errorResponse models.ErrorResponse
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &errorResponse))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, models.ErrorCodeInvalidParameter, errorResponse.Code)
+	assert.Assert(t, errorResponse.RequestId != "")
+}
@@ -0,0 +1,293 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"godopi/internal/app/api/models"
+	"godopi/internal/app/configs"
+	"godopi/internal/pkg/docker"
+	"net/http"
+	"strconv"
+	"time"
+
+	. "godopi/internal/pkg/logger"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// execCloseTimeout is how long the client gets to acknowledge the close message once the process has exited.
+const execCloseTimeout = 5 * time.Second
+
+// execMessage is the JSON message exchanged over the exec WebSocket.
+// Clients send "stdin" (data), "resize" (height, width) and "eof" messages, binary frames are written to stdin as they are.
+// The server sends "stdout" and "stderr" (data) messages and a final "exit" (exitCode) message.
+type execMessage struct {
+	Type     string `json:"type"`
+	Data     []byte `json:"data,omitempty"`
+	Height   uint   `json:"height,omitempty"`
+	Width    uint   `json:"width,omitempty"`
+	ExitCode *int   `json:"exitCode,omitempty"`
+}
+
+type ExecController struct {
+	dockerClient docker.DockerClient
+	upgrader     websocket.Upgrader
+}
+
+func NewExecController() ExecController {
+	Logger().Info("Constructing new exec controller..")
+
+	allowedOrigins := configs.Config().GetString(configs.WEBSOCKET_ALLOWED_ORIGINS)
+
+	return ExecController{dockerClient: docker.NewDockerClient(), upgrader: newWebSocketUpgrader(allowedOrigins)}
+}
+
+// CreateExec godoc
+// @Summary Creates an exec instance in a running container
+// @Description The returned attach URL opens a WebSocket session to the process.
+// @Tags    Exec
+// @Accept  json
+// @Produce json
+// @Param   id   path string      true "Container ID"
+// @Param   Exec body models.Exec true "Create Exec"
+// @Success 201 {object} models.ExecCreated
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/containers/{id}/exec [post]
+func (ec ExecController) CreateExec(ctx *gin.Context) {
+	containerId := ctx.Param("id")
+
+	var newExec models.Exec
+	if err := ctx.ShouldBindJSON(&newExec); err != nil {
+		err = errors.Wrap(err, "there is an error while validating parameters of exec")
+		abortWithError(ctx, errdefs.InvalidParameter(err), "Error creating exec!")
+		return
+	}
+
+	env, err := newExec.DockerEnv()
+
+	if err != nil {
+		err = errors.Wrap(err, "there is an error while validating parameters of exec")
+		abortWithError(ctx, errdefs.InvalidParameter(err), "Error creating exec!")
+		return
+	}
+
+	execId, err := ec.dockerClient.CreateExec(ctx.Request.Context(), containerId, docker.ExecOptions{
+		Cmd:        newExec.Cmd,
+		Env:        env,
+		User:       newExec.User,
+		WorkingDir: newExec.WorkingDir,
+		Tty:        newExec.Tty,
+	})
+
+	if err != nil {
+		err = errors.Wrapf(err, "there is an error while creating exec. ContainerId:%s", containerId)
+		abortWithError(ctx, err, "Error creating exec!")
+		return
+	}
+
+	attachUrl := "/api/v1/docker/exec/" + execId + "/attach"
+
+	ctx.JSON(http.StatusCreated, models.ExecCreated{Id: execId, Attach: attachUrl})
+}
+
+// GetExec godoc
+// @Summary Gets the state of an exec instance
+// @Tags    Exec
+// @Accept  json
+// @Produce json
+// @Param   id     path  string   true  "Exec ID"
+// @Param   fields query []string false "Fields to return, e.g. running,exitCode" collectionFormat(csv)
+// @Success 200 {object} docker.ExecState
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/exec/{id} [get]
+func (ec ExecController) GetExec(ctx *gin.Context) {
+	execId := ctx.Param("id")
+	execInspect, err := ec.dockerClient.InspectExec(ctx.Request.Context(), execId)
+
+	if err != nil {
+		err = errors.Wrapf(err, "there is an error while getting exec info. ExecId:%s", execId)
+		abortWithError(ctx, err, "Error retrieving exec!")
+		return
+	}
+
+	writeJSON(ctx, http.StatusOK, execInspect, "Error retrieving exec!")
+}
+
+// ResizeExec godoc
+// @Summary Resizes the TTY of an exec instance
+// @Tags    Exec
+// @Accept  json
+// @Produce json
+// @Param   id     path  string true "Exec ID"
+// @Param   height query int    true "Height in rows"
+// @Param   width  query int    true "Width in columns"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/exec/{id}/resize [post]
+func (ec ExecController) ResizeExec(ctx *gin.Context) {
+	execId := ctx.Param("id")
+	height, heightErr := strconv.ParseUint(ctx.Query("height"), 10, 32)
+	width, widthErr := strconv.ParseUint(ctx.Query("width"), 10, 32)
+
+	if heightErr != nil || widthErr != nil {
+		abortWithError(ctx, errdefs.InvalidParameter(errors.New("height and width must be non-negative numbers")), "Error resizing exec!")
+		return
+	}
+
+	if err := ec.dockerClient.ResizeExec(ctx.Request.Context(), execId, uint(height), uint(width)); err != nil {
+		err = errors.Wrapf(err, "there is an error while resizing exec. ExecId:%s", execId)
+		abortWithError(ctx, err, "Error resizing exec!")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.SuccessResponse{Success: "Exec# " + execId + " resized"})
+}
+
+// AttachExec godoc
+// @Summary Starts an exec instance and attaches to it over a WebSocket
+// @Description Clients send {"type":"stdin","data":<base64>}, {"type":"resize","height":24,"width":80} and {"type":"eof"} messages or raw binary frames for stdin.
+// @Description The server sends {"type":"stdout"|"stderr","data":<base64>} messages and a final {"type":"exit","exitCode":0} message.
+// @Tags    Exec
+// @Param   id path string true "Exec ID"
+// @Success 101 {string} Status
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/exec/{id}/attach [get]
+func (ec ExecController) AttachExec(ctx *gin.Context) {
+	execId := ctx.Param("id")
+
+	// The stream framing depends on the TTY, so the instance is attached in the mode it was created with.
+	execState, err := ec.dockerClient.InspectExec(ctx.Request.Context(), execId)
+
+	if err != nil {
+		err = errors.Wrapf(err, "there is an error while inspecting exec. ExecId:%s", execId)
+		abortWithError(ctx, err, "Error attaching to exec!")
+		return
+	}
+
+	// The exec instance is attached before upgrading so that failures are reported with a proper status.
+	execStream, err := ec.dockerClient.AttachExec(ctx.Request.Context(), execId, execState.Tty)
+
+	if err != nil {
+		err = errors.Wrapf(err, "there is an error while attaching to exec. ExecId:%s", execId)
+		abortWithError(ctx, err, "Error attaching to exec!")
+		return
+	}
+
+	conn, err := ec.upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+
+	if err != nil {
+		// The upgrader has already replied to the client.
+		LoggerFromContext(ctx.Request.Context()).Error("Error upgrading exec attach to WebSocket", zap.String("ExecId", execId), zap.Error(err))
+		execStream.Close()
+		return
+	}
+
+	defer conn.Close()
+
+	outputDone := make(chan struct{})
+
+	go func() {
+		defer close(outputDone)
+		ec.relayOutput(LoggerFromContext(ctx.Request.Context()), conn, execStream, execId)
+	}()
+
+	ec.relayInput(ctx.Request.Context(), conn, execStream, execId)
+
+	execStream.Close()
+	<-outputDone
+}
+
+func (ec ExecController) relayOutput(requestLogger *zap.Logger, conn *websocket.Conn, execStream docker.ExecStream, execId string) {
+	err := execStream.ReadOutput(func(stream string, data []byte) {
+		if err := conn.WriteJSON(execMessage{Type: stream, Data: data}); err != nil {
+			requestLogger.Warn("Error writing exec output to WebSocket", zap.String("ExecId", execId), zap.Error(err))
+		}
+	})
+
+	if err != nil {
+		requestLogger.Warn("Exec output ended with an error", zap.String("ExecId", execId), zap.Error(err))
+	}
+
+	exitMessage := execMessage{Type: "exit"}
+
+	if execInspect, err := ec.dockerClient.InspectExec(context.Background(), execId); err == nil {
+		exitMessage.ExitCode = &execInspect.ExitCode
+	}
+
+	_ = conn.WriteJSON(exitMessage)
+	_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+
+	// Give the client a moment to acknowledge the close before the input loop gives up on reading.
+	_ = conn.SetReadDeadline(time.Now().Add(execCloseTimeout))
+}
+
+func (ec ExecController) relayInput(ctx context.Context, conn *websocket.Conn, execStream docker.ExecStream, execId string) {
+	for {
+		messageType, payload, err := conn.ReadMessage()
+
+		if err != nil {
+			return
+		}
+
+		if messageType == websocket.BinaryMessage {
+			if _, err = execStream.Write(payload); err != nil {
+				LoggerFromContext(ctx).Warn("Error writing to exec stdin", zap.String("ExecId", execId), zap.Error(err))
+			}
+
+			continue
+		}
+
+		var message execMessage
+
+		if err = json.Unmarshal(payload, &message); err != nil {
+			LoggerFromContext(ctx).Warn("Received an invalid exec message", zap.String("ExecId", execId), zap.Error(err))
+			continue
+		}
+
+		switch message.Type {
+		case "stdin":
+			_, err = execStream.Write(message.Data)
+		case "resize":
+			err = ec.dockerClient.ResizeExec(ctx, execId, message.Height, message.Width)
+		case "eof":
+			err = execStream.CloseStdin()
+		default:
+			err = errors.Errorf("unknown exec message type. Type:%s", message.Type)
+		}
+
+		if err != nil {
+			LoggerFromContext(ctx).Warn("Error handling exec message", zap.String("ExecId", execId), zap.String("Type", message.Type), zap.Error(err))
+		}
+	}
+}
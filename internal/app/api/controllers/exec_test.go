@@ -0,0 +1,200 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"godopi/internal/app/api/models"
+	"godopi/internal/pkg/docker"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"gotest.tools/v3/assert"
+)
+
+type mockExecStream struct {
+	mu     sync.Mutex
+	stdin  bytes.Buffer
+	input  chan struct{}
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newMockExecStream() *mockExecStream {
+	return &mockExecStream{input: make(chan struct{}, 1), closed: make(chan struct{})}
+}
+
+func (mes *mockExecStream) Write(p []byte) (int, error) {
+	mes.mu.Lock()
+	defer mes.mu.Unlock()
+
+	mes.stdin.Write(p)
+
+	select {
+	case mes.input <- struct{}{}:
+	default:
+	}
+
+	return len(p), nil
+}
+func (mes *mockExecStream) ReadOutput(onOutput func(stream string, data []byte)) error {
+	select {
+	case <-mes.input:
+	case <-mes.closed:
+		return nil
+	}
+
+	mes.mu.Lock()
+	received := mes.stdin.String()
+	mes.mu.Unlock()
+
+	onOutput(docker.StreamStdout, []byte("echo: "+received))
+	onOutput(docker.StreamStderr, []byte("warning"))
+
+	return nil
+}
+func (mes *mockExecStream) CloseStdin() error {
+	return nil
+}
+func (mes *mockExecStream) Close() {
+	mes.once.Do(func() { close(mes.closed) })
+}
+
+func TestCreateExecSuccessDockerClient(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	var receivedOptions docker.ExecOptions
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockCreateExec = func(c context.Context, containerId string, options docker.ExecOptions) (string, error) {
+		receivedOptions = options
+		return "8bd7e6e2f1c4", nil
+	}
+
+	execController := ExecController{dockerClient: &mockDockerClient}
+
+	e.POST("/:id/exec", execController.CreateExec)
+
+	data, _ := json.Marshal(models.Exec{Cmd: []string{"/bin/sh"}, Env: map[string]string{"TERM": "xterm"}, Tty: true})
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/3423ASDF372FA7DF732/exec", bytes.NewBuffer(data))
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, true, strings.Contains(w.Body.String(), "/api/v1/docker/exec/8bd7e6e2f1c4/attach"))
+	assert.DeepEqual(t, []string{"TERM=xterm"}, receivedOptions.Env)
+	assert.Equal(t, true, receivedOptions.Tty)
+}
+
+func TestCreateExecErrorMissingCmd(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	execController := ExecController{dockerClient: &mockDockerClient{}}
+
+	e.POST("/:id/exec", execController.CreateExec)
+
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/3423ASDF372FA7DF732/exec", strings.NewReader(`{"tty":true}`))
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAttachExecRelaysStreams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+
+	execStream := newMockExecStream()
+	var resizedHeight, resizedWidth uint
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockAttachExec = func(c context.Context, execId string, tty bool) (docker.ExecStream, error) {
+		return execStream, nil
+	}
+	mockDockerClient.MockResizeExec = func(c context.Context, execId string, height uint, width uint) error {
+		resizedHeight, resizedWidth = height, width
+		return nil
+	}
+	mockDockerClient.MockInspectExec = func(c context.Context, execId string) (docker.ExecState, error) {
+		return docker.ExecState{Id: execId, ExitCode: 3}, nil
+	}
+
+	execController := ExecController{dockerClient: &mockDockerClient, upgrader: newWebSocketUpgrader("")}
+
+	e.GET("/exec/:id/attach", execController.AttachExec)
+
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/exec/8bd7e6e2f1c4/attach", nil)
+	assert.NilError(t, err)
+	defer conn.Close()
+
+	assert.NilError(t, conn.WriteJSON(execMessage{Type: "resize", Height: 40, Width: 120}))
+	assert.NilError(t, conn.WriteJSON(execMessage{Type: "stdin", Data: []byte("ls\n")}))
+
+	var messages []execMessage
+
+	for {
+		var message execMessage
+		if err := conn.ReadJSON(&message); err != nil {
+			break
+		}
+		messages = append(messages, message)
+	}
+
+	assert.Equal(t, 3, len(messages))
+	assert.Equal(t, docker.StreamStdout, messages[0].Type)
+	assert.Equal(t, "echo: ls\n", string(messages[0].Data))
+	assert.Equal(t, docker.StreamStderr, messages[1].Type)
+	assert.Equal(t, "exit", messages[2].Type)
+	assert.Equal(t, 3, *messages[2].ExitCode)
+	assert.Equal(t, uint(40), resizedHeight)
+	assert.Equal(t, uint(120), resizedWidth)
+}
+
+func TestAttachExecUsesTtyOfInspect(t *testing.T) {
+	e := gin.New()
+
+	attachedTty := map[string]bool{}
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockInspectExec = func(c context.Context, execId string) (docker.ExecState, error) {
+		if execId == "5f2a91c0d3e7" {
+			return docker.ExecState{}, errdefs.NotFound(errors.New("no such exec"))
+		}
+
+		return docker.ExecState{Id: execId, Tty: true}, nil
+	}
+	mockDockerClient.MockAttachExec = func(c context.Context, execId string, tty bool) (docker.ExecStream, error) {
+		attachedTty[execId] = tty
+		return nil, errdefs.Conflict(errors.New("exec is already running"))
+	}
+
+	execController := ExecController{dockerClient: &mockDockerClient, upgrader: newWebSocketUpgrader("")}
+
+	e.GET("/exec/:id/attach", execController.AttachExec)
+
+	// A tty query parameter cannot switch the mode the exec instance was created with.
+	w := httptest.NewRecorder()
+	request, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "/exec/8bd7e6e2f1c4/attach?tty=false", nil)
+	e.ServeHTTP(w, request)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, true, attachedTty["8bd7e6e2f1c4"])
+
+	// Unknown exec instances are reported before anything is attached.
+	w = httptest.NewRecorder()
+	request, _ = http.NewRequestWithContext(context.Background(), http.MethodGet, "/exec/5f2a91c0d3e7/attach", nil)
+	e.ServeHTTP(w, request)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, 1, len(attachedTty))
+}
@@ -0,0 +1,320 @@
+package controllers
+
+import (
+	"context"
+	"godopi/internal/app/api/models"
+	"godopi/internal/pkg/docker"
+	"godopi/internal/pkg/jobs"
+	"godopi/internal/pkg/policy"
+	"net/http"
+
+	. "godopi/internal/pkg/logger"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type ImageController struct {
+	dockerClient    docker.DockerClient
+	jobManager      jobs.JobManager
+	containerPolicy *policy.ContainerPolicy
+}
+
+func NewImageController(jobManager jobs.JobManager, containerPolicy *policy.ContainerPolicy) ImageController {
+	Logger().Info("Constructing new image controller..")
+
+	return ImageController{dockerClient: docker.NewDockerClient(), jobManager: jobManager, containerPolicy: containerPolicy}
+}
+
+// GetAllImages godoc
+// @Summary Gets all the images
+// @Tags    Image
+// @Accept  json
+// @Produce json
+// @Param   all      query bool     false "Include intermediate images"
+// @Param   dangling query bool     false "Only list dangling images"
+// @Param   fields   query []string false "Fields of each image to return, e.g. id,repoTags" collectionFormat(csv)
+// @Success 200 {array} docker.ImageSummary
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/images [get]
+func (ic ImageController) GetAllImages(ctx *gin.Context) {
+	images, err := ic.dockerClient.ListImages(ctx.Request.Context(), ctx.Query("all") == "true", ctx.Query("dangling") == "true")
+
+	if err != nil {
+		err = errors.Wrap(err, "there is an error while getting the images info")
+		abortWithError(ctx, err, "Error retrieving images!")
+		return
+	}
+
+	writeJSON(ctx, http.StatusOK, images, "Error retrieving images!")
+}
+
+// GetDetailedImage godoc
+// @Summary Gets detail for an image
+// @Tags    Image
+// @Accept  json
+// @Produce json
+// @Param   id     path  string   true  "Image ID or reference"
+// @Param   fields query []string false "Fields to return, e.g. id,config" collectionFormat(csv)
+// @Success 200 {object} docker.ImageDetail
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/images/{id} [get]
+func (ic ImageController) GetDetailedImage(ctx *gin.Context) {
+	imageId := ctx.Param("id")
+	imageDetail, err := ic.dockerClient.InspectImage(ctx.Request.Context(), imageId)
+
+	if err != nil {
+		err = errors.Wrapf(err, "there is an error while getting detailed image info. ImageId:%s", imageId)
+		abortWithError(ctx, err, "Error retrieving image detail!")
+		return
+	}
+
+	writeJSON(ctx, http.StatusOK, imageDetail, "Error retrieving image detail!")
+}
+
+// PullImage godoc
+// @Summary Pulls an image by tag or digest
+// @Description With async=true the pull runs as a background job whose progress is available under /jobs/{id}.
+// @Tags    Image
+// @Accept  json
+// @Produce json
+// @Param   Image body  models.ImagePull true  "Pull Image"
+// @Param   async query bool             false "Run the pull as a background job"
+// @Success 200 {object} models.SuccessResponse
+// @Success 202 {object} jobs.Snapshot
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/images/pull [post]
+func (ic ImageController) PullImage(ctx *gin.Context) {
+	var imagePull models.ImagePull
+	if err := ctx.ShouldBindJSON(&imagePull); err != nil {
+		err = errors.Wrap(err, "there is an error while validating parameters of image pull")
+		abortWithError(ctx, errdefs.InvalidParameter(err), "Error pulling image!")
+		return
+	}
+
+	imageReference, err := imagePull.Reference()
+
+	if err != nil {
+		err = errors.Wrap(err, "there is an error while validating parameters of image pull")
+		abortWithError(ctx, errdefs.InvalidParameter(err), "Error pulling image!")
+		return
+	}
+
+	if err = ic.containerPolicy.CheckPull(imageReference); err != nil {
+		err = errors.Wrap(err, "there is an error while checking the container policy")
+		abortWithError(ctx, err, "Error pulling image!")
+		return
+	}
+
+	if ctx.Query("async") == "true" {
+		job := ic.jobManager.Start("image-pull", imageReference, jobOwner(ctx), func(jobCtx context.Context, report func(jobs.Progress)) error {
+			return ic.dockerClient.PullImage(jobCtx, imageReference, reportPullProgress(imageReference, report))
+		})
+
+		ctx.Header("Location", "/api/v1/jobs/"+job.Id)
+		ctx.JSON(http.StatusAccepted, job)
+		return
+	}
+
+	if err = ic.dockerClient.PullImage(ctx.Request.Context(), imageReference, nil); err != nil {
+		err = errors.Wrapf(err, "there is an error while pulling image. ImageReference:%s", imageReference)
+		abortWithError(ctx, err, "Error pulling image!")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.SuccessResponse{Success: "Image " + imageReference + " pulled"})
+}
+
+// TagImage godoc
+// @Summary Tags an image into a repository
+// @Tags    Image
+// @Accept  json
+// @Produce json
+// @Param   id  path string          true "Image ID or reference"
+// @Param   Tag body models.ImageTag true "Tag Image"
+// @Success 201 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/images/{id}/tag [post]
+func (ic ImageController) TagImage(ctx *gin.Context) {
+	imageId := ctx.Param("id")
+
+	var imageTag models.ImageTag
+	if err := ctx.ShouldBindJSON(&imageTag); err != nil {
+		err = errors.Wrap(err, "there is an error while validating parameters of image tag")
+		abortWithError(ctx, errdefs.InvalidParameter(err), "Error tagging image!")
+		return
+	}
+
+	targetImage := imageTag.Reference()
+
+	if ic.containerPolicy != nil {
+		// The references of the source are those the engine resolves the id to, not the one given by the client.
+		imageDetail, err := ic.dockerClient.InspectImage(ctx.Request.Context(), imageId)
+
+		if err != nil {
+			err = errors.Wrapf(err, "there is an error while getting detailed image info. ImageId:%s", imageId)
+			abortWithError(ctx, err, "Error tagging image!")
+			return
+		}
+
+		sourceReferences := append(append([]string{}, imageDetail.RepoTags...), imageDetail.RepoDigests...)
+
+		if err = ic.containerPolicy.CheckTag(imageId, sourceReferences, targetImage); err != nil {
+			err = errors.Wrap(err, "there is an error while checking the container policy")
+			abortWithError(ctx, err, "Error tagging image!")
+			return
+		}
+	}
+
+	if err := ic.dockerClient.TagImage(ctx.Request.Context(), imageId, targetImage); err != nil {
+		err = errors.Wrapf(err, "there is an error while tagging image. ImageId:%s", imageId)
+		abortWithError(ctx, err, "Error tagging image!")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.SuccessResponse{Success: "Image " + imageId + " tagged as " + targetImage})
+}
+
+// DeleteImage godoc
+// @Summary Deletes an image
+// @Tags    Image
+// @Accept  json
+// @Produce json
+// @Param   id            path  string   true  "Image ID or reference"
+// @Param   force         query bool     false "Remove the image even if it is being used by stopped containers or has other tags"
+// @Param   pruneChildren query bool     false "Delete untagged parent images, true by default"
+// @Param   fields        query []string false "Fields of each item to return, e.g. deleted" collectionFormat(csv)
+// @Success 200 {array} docker.ImageDeleteItem
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/images/{id} [delete]
+func (ic ImageController) DeleteImage(ctx *gin.Context) {
+	if err := checkFields(ctx, []docker.ImageDeleteItem{}); err != nil {
+		abortWithError(ctx, errdefs.InvalidParameter(err), "Error deleting image!")
+		return
+	}
+
+	imageId := ctx.Param("id")
+	deletedItems, err := ic.dockerClient.DeleteImage(ctx.Request.Context(), imageId, ctx.Query("force") == "true", ctx.DefaultQuery("pruneChildren", "true") == "true")
+
+	if err != nil {
+		err = errors.Wrapf(err, "there is an error while deleting image. ImageId:%s", imageId)
+		abortWithError(ctx, err, "Error deleting image!")
+		return
+	}
+
+	writeJSON(ctx, http.StatusOK, deletedItems, "Error deleting image!")
+}
+
+// GetImageHistory godoc
+// @Summary Gets the layer history of an image
+// @Tags    Image
+// @Accept  json
+// @Produce json
+// @Param   id     path  string   true  "Image ID or reference"
+// @Param   fields query []string false "Fields of each layer to return, e.g. createdBy,size" collectionFormat(csv)
+// @Success 200 {array} docker.ImageHistoryItem
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/images/{id}/history [get]
+func (ic ImageController) GetImageHistory(ctx *gin.Context) {
+	imageId := ctx.Param("id")
+	history, err := ic.dockerClient.GetImageHistory(ctx.Request.Context(), imageId)
+
+	if err != nil {
+		err = errors.Wrapf(err, "there is an error while getting image history. ImageId:%s", imageId)
+		abortWithError(ctx, err, "Error retrieving image history!")
+		return
+	}
+
+	writeJSON(ctx, http.StatusOK, history, "Error retrieving image history!")
+}
+
+// PruneImages godoc
+// @Summary Deletes dangling images, or all unused images when all is true
+// @Tags    Image
+// @Accept  json
+// @Produce json
+// @Param   all    query bool     false "Prune all images without a container, not only dangling ones"
+// @Param   fields query []string false "Fields to return, e.g. spaceReclaimed" collectionFormat(csv)
+// @Success 200 {object} docker.ImagePruneReport
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/images/prune [post]
+func (ic ImageController) PruneImages(ctx *gin.Context) {
+	if err := checkFields(ctx, docker.ImagePruneReport{}); err != nil {
+		abortWithError(ctx, errdefs.InvalidParameter(err), "Error pruning images!")
+		return
+	}
+
+	pruneReport, err := ic.dockerClient.PruneImages(ctx.Request.Context(), ctx.Query("all") == "true")
+
+	if err != nil {
+		err = errors.Wrap(err, "there is an error while pruning images")
+		abortWithError(ctx, err, "Error pruning images!")
+		return
+	}
+
+	writeJSON(ctx, http.StatusOK, pruneReport, "Error pruning images!")
+}
+
+// reportPullProgress turns the pull progress into job progress, one entry per layer and one for the pull as a whole.
+func reportPullProgress(imageReference string, report func(jobs.Progress)) func(docker.PullProgress) {
+	return func(progress docker.PullProgress) {
+		layerId := progress.LayerId
+
+		if layerId == "" {
+			layerId = imageReference
+		}
+
+		report(jobs.Progress{Id: layerId, Status: progress.Status, Current: progress.Current, Total: progress.Total})
+	}
+}
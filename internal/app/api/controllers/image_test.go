@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"godopi/internal/app/api/models"
+	"godopi/internal/pkg/docker"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gotest.tools/v3/assert"
+)
+
+func TestGetAllImagesSuccessDockerClient(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	receivedDangling := false
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockListImages = func(c context.Context, all bool, dangling bool) ([]docker.ImageSummary, error) {
+		receivedDangling = dangling
+		return []docker.ImageSummary{{Id: "sha256:7425d3a7c478", RepoTags: []string{"nginx:latest"}}}, nil
+	}
+
+	imageController := ImageController{dockerClient: &mockDockerClient}
+
+	e.GET("/", imageController.GetAllImages)
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/?dangling=true", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, true, strings.Contains(w.Body.String(), `"repoTags":["nginx:latest"]`))
+	assert.Equal(t, true, receivedDangling)
+}
+
+func TestPullImageSuccessWithDigest(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	receivedReference := ""
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockPullImage = func(c context.Context, imageReference string, onProgress func(docker.PullProgress)) error {
+		receivedReference = imageReference
+		return nil
+	}
+
+	imageController := ImageController{dockerClient: &mockDockerClient}
+
+	e.POST("/pull", imageController.PullImage)
+
+	imagePull := models.ImagePull{Image: "nginx", Digest: "sha256:2834dc507516af02784808c5f48b7cbe38b8ed5d0f4837f16e78d00deb7e7767"}
+
+	data, _ := json.Marshal(imagePull)
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/pull", bytes.NewBuffer(data))
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "nginx@sha256:2834dc507516af02784808c5f48b7cbe38b8ed5d0f4837f16e78d00deb7e7767", receivedReference)
+}
+
+func TestPullImageErrorTagAndDigest(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	imageController := ImageController{dockerClient: &mockDockerClient{}}
+
+	e.POST("/pull", imageController.PullImage)
+
+	imagePull := models.ImagePull{Image: "nginx", Tag: "1.21", Digest: "sha256:2834dc507516af02784808c5f48b7cbe38b8ed5d0f4837f16e78d00deb7e7767"}
+
+	data, _ := json.Marshal(imagePull)
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/pull", bytes.NewBuffer(data))
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestTagImageSuccessDockerClient(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	receivedSource, receivedTarget := "", ""
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockTagImage = func(c context.Context, sourceImage string, targetImage string) error {
+		receivedSource, receivedTarget = sourceImage, targetImage
+		return nil
+	}
+
+	imageController := ImageController{dockerClient: &mockDockerClient}
+
+	e.POST("/:id/tag", imageController.TagImage)
+
+	data, _ := json.Marshal(models.ImageTag{Repository: "registry.local/nginx", Tag: "stable"})
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/nginx:1.21/tag", bytes.NewBuffer(data))
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "nginx:1.21", receivedSource)
+	assert.Equal(t, "registry.local/nginx:stable", receivedTarget)
+}
+
+func TestDeleteImageErrorDockerClient(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	deletedImageId := "sha256:7425d3a7c478"
+	errorMessage := "image is being used by running container"
+	receivedForce, receivedPruneChildren := false, false
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockDeleteImage = func(c context.Context, imageId string, force bool, pruneChildren bool) ([]docker.ImageDeleteItem, error) {
+		receivedForce, receivedPruneChildren = force, pruneChildren
+		return nil, errors.New(errorMessage)
+	}
+
+	imageController := ImageController{dockerClient: &mockDockerClient}
+
+	e.DELETE("/:id", imageController.DeleteImage)
+
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodDelete, "/"+deletedImageId+"?force=true", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, true, strings.Contains(w.Body.String(), errorMessage))
+	assert.Equal(t, true, receivedForce)
+	assert.Equal(t, true, receivedPruneChildren)
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"godopi/internal/app/api/middlewares"
+	"godopi/internal/pkg/jobs"
+	"net/http"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type JobController struct {
+	jobManager jobs.JobManager
+}
+
+func NewJobController(jobManager jobs.JobManager) JobController {
+	return JobController{jobManager: jobManager}
+}
+
+// GetJob godoc
+// @Summary Gets the status and progress of a background job
+// @Tags    Job
+// @Accept  json
+// @Produce json
+// @Param   id     path  string   true  "Job ID"
+// @Param   fields query []string false "Fields to return, e.g. status,error" collectionFormat(csv)
+// @Success 200 {object} jobs.Snapshot
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /jobs/{id} [get]
+func (jc JobController) GetJob(ctx *gin.Context) {
+	jobId := ctx.Param("id")
+	job, ok := jc.getJob(ctx, jobId)
+
+	if !ok {
+		abortWithError(ctx, errdefs.NotFound(errors.Errorf("job does not exist. JobId:%s", jobId)), "Error retrieving job!")
+		return
+	}
+
+	writeJSON(ctx, http.StatusOK, job.Snapshot(), "Error retrieving job!")
+}
+
+// StreamJob godoc
+// @Summary Streams the progress of a background job as server-sent events
+// @Description Sends a "progress" event for every progress update and a final "status" event with the job snapshot once the job finishes.
+// @Tags    Job
+// @Produce text/event-stream
+// @Param   id path string true "Job ID"
+// @Success 200 {object} jobs.Progress
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /jobs/{id}/stream [get]
+func (jc JobController) StreamJob(ctx *gin.Context) {
+	jobId := ctx.Param("id")
+	job, ok := jc.getJob(ctx, jobId)
+
+	if !ok {
+		abortWithError(ctx, errdefs.NotFound(errors.Errorf("job does not exist. JobId:%s", jobId)), "Error streaming job!")
+		return
+	}
+
+	progress, updates, unsubscribe := job.Subscribe()
+	defer unsubscribe()
+
+	startEventStream(ctx)
+
+	for _, p := range progress {
+		sendEvent(ctx, "progress", p)
+	}
+
+	for {
+		select {
+		case p := <-updates:
+			sendEvent(ctx, "progress", p)
+		case <-job.Done():
+			for {
+				select {
+				case p := <-updates:
+					sendEvent(ctx, "progress", p)
+				default:
+					sendEvent(ctx, "status", job.Snapshot())
+					return
+				}
+			}
+		case <-ctx.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// getJob returns the job with the id. Principals confined to a scope only get the jobs they started,
+// the others are reported as missing so that their ids reveal nothing.
+func (jc JobController) getJob(ctx *gin.Context, jobId string) (*jobs.Job, bool) {
+	job, ok := jc.jobManager.Get(jobId)
+
+	if !ok {
+		return nil, false
+	}
+
+	if _, scoped := middlewares.GetScope(ctx); scoped && job.Snapshot().Owner != jobOwner(ctx) {
+		return nil, false
+	}
+
+	return job, true
+}
+
+// jobOwner returns the subject of the principal of the request, which owns the jobs the request starts.
+func jobOwner(ctx *gin.Context) string {
+	principal, _ := middlewares.GetPrincipal(ctx)
+	return principal.Subject
+}
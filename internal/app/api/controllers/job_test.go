@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"godopi/internal/app/api/middlewares"
+	"godopi/internal/app/api/models"
+	"godopi/internal/pkg/docker"
+	"godopi/internal/pkg/jobs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gotest.tools/v3/assert"
+)
+
+func TestPullImageAsyncJobProgress(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	releasePull := make(chan struct{})
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockPullImage = func(c context.Context, imageReference string, onProgress func(docker.PullProgress)) error {
+		onProgress(docker.PullProgress{LayerId: "a2abf6c4d29d", Status: "Downloading", Current: 512, Total: 1024})
+		<-releasePull
+		onProgress(docker.PullProgress{LayerId: "a2abf6c4d29d", Status: "Pull complete"})
+		return nil
+	}
+
+	jobManager := jobs.NewJobManager(time.Minute)
+	imageController := ImageController{dockerClient: &mockDockerClient, jobManager: jobManager}
+	jobController := NewJobController(jobManager)
+
+	e.POST("/pull", imageController.PullImage)
+	e.GET("/jobs/:id", jobController.GetJob)
+	e.GET("/jobs/:id/stream", jobController.StreamJob)
+
+	data, _ := json.Marshal(models.ImagePull{Image: "nginx", Tag: "1.21"})
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/pull?async=true", bytes.NewBuffer(data))
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	var startedJob jobs.Snapshot
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &startedJob))
+	assert.Equal(t, "nginx:1.21", startedJob.Target)
+
+	close(releasePull)
+
+	streamRecorder := httptest.NewRecorder()
+	streamRequest, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "/jobs/"+startedJob.Id+"/stream", nil)
+	e.ServeHTTP(streamRecorder, streamRequest)
+
+	assert.Equal(t, http.StatusOK, streamRecorder.Code)
+	assert.Equal(t, true, strings.Contains(streamRecorder.Body.String(), "event:status"))
+
+	statusRecorder := httptest.NewRecorder()
+	statusRequest, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "/jobs/"+startedJob.Id, nil)
+	e.ServeHTTP(statusRecorder, statusRequest)
+
+	var finishedJob jobs.Snapshot
+	assert.NilError(t, json.Unmarshal(statusRecorder.Body.Bytes(), &finishedJob))
+	assert.Equal(t, jobs.StatusSucceeded, finishedJob.Status)
+	assert.Equal(t, 1, len(finishedJob.Progress))
+	assert.Equal(t, "Pull complete", finishedJob.Progress[0].Status)
+}
+
+func TestCreateContainerAsyncJobProgress(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockCreateContainer = func(c context.Context, options docker.CreateContainerOptions) (string, error) {
+		options.OnPullProgress(docker.PullProgress{LayerId: "a2abf6c4d29d", Status: "Pull complete"})
+		return "3423ASDF372FA7DF732", nil
+	}
+
+	jobManager := jobs.NewJobManager(time.Minute)
+	dockerController := DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient{}, jobManager: jobManager}
+
+	e.POST("/containers", dockerController.CreateContainer)
+
+	data, _ := json.Marshal(models.Container{ImageName: "nginx:1.21", ContainerName: "web"})
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/containers?async=true", bytes.NewBuffer(data))
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	var startedJob jobs.Snapshot
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &startedJob))
+	assert.Equal(t, "container-create", startedJob.Type)
+	assert.Equal(t, "web", startedJob.Target)
+	assert.Equal(t, "/api/v1/jobs/"+startedJob.Id, w.Header().Get("Location"))
+
+	job, _ := jobManager.Get(startedJob.Id)
+	<-job.Done()
+
+	finishedJob := job.Snapshot()
+	assert.Equal(t, jobs.StatusSucceeded, finishedJob.Status)
+	assert.Equal(t, 2, len(finishedJob.Progress))
+	assert.Equal(t, "Pull complete", finishedJob.Progress[0].Status)
+	assert.Equal(t, "3423ASDF372FA7DF732", finishedJob.Progress[1].Id)
+	assert.Equal(t, "created and started", finishedJob.Progress[1].Status)
+}
+
+func TestGetJobErrorNotFound(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	jobController := NewJobController(jobs.NewJobManager(time.Minute))
+
+	e.GET("/jobs/:id", jobController.GetJob)
+
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/jobs/unknown", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetJobOfAnotherPrincipalIsHiddenFromScopedPrincipal(t *testing.T) {
+	apiKeys := []string{}
+
+	for _, subject := range []string{"ops", "shop-ci"} {
+		hash := sha256.Sum256([]byte(subject + "-key"))
+		apiKeys = append(apiKeys, subject+":"+hex.EncodeToString(hash[:]))
+	}
+
+	authenticate, err := middlewares.Authenticate(middlewares.AuthOptions{ApiKeys: strings.Join(apiKeys, ",")})
+	assert.NilError(t, err)
+
+	policyFile := filepath.Join(t.TempDir(), "policy.yaml")
+	assert.NilError(t, os.WriteFile(policyFile, []byte("bindings:\n  - subject: ops\n    role: admin\n  - subject: shop-ci\n    role: operator\n    scope:\n      namePrefix: shop-\n"), 0600))
+
+	policy, err := middlewares.LoadPolicy(policyFile)
+	assert.NilError(t, err)
+
+	jobManager := jobs.NewJobManager(time.Minute)
+	jobController := NewJobController(jobManager)
+
+	_, e := gin.CreateTestContext(httptest.NewRecorder())
+	e.GET("/api/v1/jobs/:id", authenticate, middlewares.Authorize(policy, &mockDockerClient{}), jobController.GetJob)
+
+	run := func(ctx context.Context, report func(jobs.Progress)) error { return nil }
+	opsJob := jobManager.Start("image-pull", "nginx:1.21", "ops", run)
+	shopJob := jobManager.Start("container-create", "shop-web", "shop-ci", run)
+
+	for name, test := range map[string]struct {
+		subject string
+		jobId   string
+		status  int
+	}{
+		"scoped principal reads own job":     {"shop-ci", shopJob.Id, http.StatusOK},
+		"scoped principal reads foreign job": {"shop-ci", opsJob.Id, http.StatusNotFound},
+		"unscoped principal reads any job":   {"ops", shopJob.Id, http.StatusOK},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			request, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/jobs/"+test.jobId, nil)
+			request.Header.Set(middlewares.API_KEY_HEADER, test.subject+"-key")
+			e.ServeHTTP(w, request)
+
+			assert.Equal(t, test.status, w.Code)
+		})
+	}
+}
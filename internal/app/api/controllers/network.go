@@ -0,0 +1,288 @@
+package controllers
+
+import (
+	"godopi/internal/app/api/models"
+	"godopi/internal/pkg/docker"
+	"net/http"
+
+	. "godopi/internal/pkg/logger"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type NetworkController struct {
+	dockerClient docker.DockerClient
+}
+
+func NewNetworkController() NetworkController {
+	Logger().Info("Constructing new network controller..")
+
+	return NetworkController{dockerClient: docker.NewDockerClient()}
+}
+
+// GetAllNetworks godoc
+// @Summary Gets the networks
+// @Tags    Network
+// @Accept  json
+// @Produce json
+// @Param   name   query string   false "Network name or a part of it"
+// @Param   driver query string   false "Network driver"
+// @Param   label  query []string false "Label as key or key=value" collectionFormat(multi)
+// @Param   type   query string   false "Only list builtin or custom networks" Enums(builtin, custom)
+// @Param   fields query []string false "Fields of each network to return, e.g. id,name" collectionFormat(csv)
+// @Success 200 {array} docker.Network
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/networks [get]
+func (nc NetworkController) GetAllNetworks(ctx *gin.Context) {
+	options := docker.ListNetworksOptions{Name: ctx.Query("name"), Driver: ctx.Query("driver"), Labels: ctx.QueryArray("label"), Type: ctx.Query("type")}
+
+	if options.Type != "" && options.Type != "builtin" && options.Type != "custom" {
+		abortWithError(ctx, errdefs.InvalidParameter(errors.Errorf("type must be builtin or custom. Type:%s", options.Type)), "Error retrieving networks!")
+		return
+	}
+
+	networks, err := nc.dockerClient.ListNetworks(ctx.Request.Context(), options)
+
+	if err != nil {
+		err = errors.Wrap(err, "there is an error while getting the networks info")
+		abortWithError(ctx, err, "Error retrieving networks!")
+		return
+	}
+
+	writeJSON(ctx, http.StatusOK, networks, "Error retrieving networks!")
+}
+
+// GetNetwork godoc
+// @Summary Gets detail for a network, including its connected containers
+// @Tags    Network
+// @Accept  json
+// @Produce json
+// @Param   id     path  string   true  "Network ID or name"
+// @Param   fields query []string false "Fields to return, e.g. name,containers" collectionFormat(csv)
+// @Success 200 {object} docker.Network
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/networks/{id} [get]
+func (nc NetworkController) GetNetwork(ctx *gin.Context) {
+	networkId := ctx.Param("id")
+	network, err := nc.dockerClient.InspectNetwork(ctx.Request.Context(), networkId)
+
+	if err != nil {
+		err = errors.Wrapf(err, "there is an error while getting network info. NetworkId:%s", networkId)
+		abortWithError(ctx, err, "Error retrieving network!")
+		return
+	}
+
+	writeJSON(ctx, http.StatusOK, network, "Error retrieving network!")
+}
+
+// CreateNetwork godoc
+// @Summary Creates a network
+// @Tags    Network
+// @Accept  json
+// @Produce json
+// @Param   Network body  models.Network true  "Create Network"
+// @Param   fields  query []string       false "Fields to return, e.g. id,name" collectionFormat(csv)
+// @Success 201 {object} docker.Network
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/networks [post]
+func (nc NetworkController) CreateNetwork(ctx *gin.Context) {
+	if err := checkFields(ctx, docker.Network{}); err != nil {
+		abortWithError(ctx, errdefs.InvalidParameter(err), "Error creating network!")
+		return
+	}
+
+	var newNetwork models.Network
+	if err := ctx.ShouldBindJSON(&newNetwork); err != nil {
+		err = errors.Wrap(err, "there is an error while validating parameters of network")
+		abortWithError(ctx, errdefs.InvalidParameter(err), "Error creating network!")
+		return
+	}
+
+	if err := newNetwork.Validate(); err != nil {
+		err = errors.Wrap(err, "there is an error while validating parameters of network")
+		abortWithError(ctx, errdefs.InvalidParameter(err), "Error creating network!")
+		return
+	}
+
+	options := docker.CreateNetworkOptions{
+		Name:       newNetwork.Name,
+		Driver:     newNetwork.Driver,
+		Internal:   newNetwork.Internal,
+		Attachable: newNetwork.Attachable,
+		Labels:     newNetwork.Labels,
+		Options:    newNetwork.Options,
+	}
+
+	for _, ipamConfig := range newNetwork.Ipam {
+		options.Ipam = append(options.Ipam, docker.NetworkIpamConfig{Subnet: ipamConfig.Subnet, Gateway: ipamConfig.Gateway, IpRange: ipamConfig.IpRange})
+	}
+
+	network, err := nc.dockerClient.CreateNetwork(ctx.Request.Context(), options)
+
+	if err != nil {
+		err = errors.Wrap(err, "there is an error while creating network")
+		abortWithError(ctx, err, "Error creating network!")
+		return
+	}
+
+	writeJSON(ctx, http.StatusCreated, network, "Error creating network!")
+}
+
+// DeleteNetwork godoc
+// @Summary Deletes a network
+// @Tags    Network
+// @Accept  json
+// @Produce json
+// @Param   id path string true "Network ID or name"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/networks/{id} [delete]
+func (nc NetworkController) DeleteNetwork(ctx *gin.Context) {
+	networkId := ctx.Param("id")
+
+	if err := nc.dockerClient.DeleteNetwork(ctx.Request.Context(), networkId); err != nil {
+		err = errors.Wrapf(err, "there is an error while deleting network. NetworkId:%s", networkId)
+		abortWithError(ctx, err, "Error deleting network!")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.SuccessResponse{Success: "Network " + networkId + " deleted"})
+}
+
+// PruneNetworks godoc
+// @Summary Deletes the custom networks that no container is connected to
+// @Tags    Network
+// @Accept  json
+// @Produce json
+// @Param   label  query []string false "Only prune networks with the label, as key or key=value" collectionFormat(multi)
+// @Param   fields query []string false "Fields to return, e.g. networksDeleted" collectionFormat(csv)
+// @Success 200 {object} docker.NetworkPruneReport
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/networks/prune [post]
+func (nc NetworkController) PruneNetworks(ctx *gin.Context) {
+	if err := checkFields(ctx, docker.NetworkPruneReport{}); err != nil {
+		abortWithError(ctx, errdefs.InvalidParameter(err), "Error pruning networks!")
+		return
+	}
+
+	pruneReport, err := nc.dockerClient.PruneNetworks(ctx.Request.Context(), ctx.QueryArray("label"))
+
+	if err != nil {
+		err = errors.Wrap(err, "there is an error while pruning networks")
+		abortWithError(ctx, err, "Error pruning networks!")
+		return
+	}
+
+	writeJSON(ctx, http.StatusOK, pruneReport, "Error pruning networks!")
+}
+
+// ConnectNetwork godoc
+// @Summary Connects a container to a network
+// @Description A static ipv4Address is only accepted on networks created with a subnet.
+// @Tags    Network
+// @Accept  json
+// @Produce json
+// @Param   id         path string                   true "Network ID or name"
+// @Param   Connection body models.NetworkConnection true "Connect Container"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/networks/{id}/connect [post]
+func (nc NetworkController) ConnectNetwork(ctx *gin.Context) {
+	networkId := ctx.Param("id")
+
+	var connection models.NetworkConnection
+	if err := ctx.ShouldBindJSON(&connection); err != nil {
+		err = errors.Wrap(err, "there is an error while validating parameters of network connection")
+		abortWithError(ctx, errdefs.InvalidParameter(err), "Error connecting container to network!")
+		return
+	}
+
+	options := docker.ConnectNetworkOptions{Aliases: connection.Aliases, IPv4Address: connection.IPv4Address}
+
+	if err := nc.dockerClient.ConnectNetwork(ctx.Request.Context(), networkId, connection.Container, options); err != nil {
+		err = errors.Wrapf(err, "there is an error while connecting container to network. NetworkId:%s ContainerId:%s", networkId, connection.Container)
+		abortWithError(ctx, err, "Error connecting container to network!")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.SuccessResponse{Success: "Container " + connection.Container + " connected to network " + networkId})
+}
+
+// DisconnectNetwork godoc
+// @Summary Disconnects a container from a network
+// @Tags    Network
+// @Accept  json
+// @Produce json
+// @Param   id            path string                      true "Network ID or name"
+// @Param   Disconnection body models.NetworkDisconnection true "Disconnect Container"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/networks/{id}/disconnect [post]
+func (nc NetworkController) DisconnectNetwork(ctx *gin.Context) {
+	networkId := ctx.Param("id")
+
+	var disconnection models.NetworkDisconnection
+	if err := ctx.ShouldBindJSON(&disconnection); err != nil {
+		err = errors.Wrap(err, "there is an error while validating parameters of network disconnection")
+		abortWithError(ctx, errdefs.InvalidParameter(err), "Error disconnecting container from network!")
+		return
+	}
+
+	if err := nc.dockerClient.DisconnectNetwork(ctx.Request.Context(), networkId, disconnection.Container, disconnection.Force); err != nil {
+		err = errors.Wrapf(err, "there is an error while disconnecting container from network. NetworkId:%s ContainerId:%s", networkId, disconnection.Container)
+		abortWithError(ctx, err, "Error disconnecting container from network!")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.SuccessResponse{Success: "Container " + disconnection.Container + " disconnected from network " + networkId})
+}
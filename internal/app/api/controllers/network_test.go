@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"godopi/internal/app/api/models"
+	"godopi/internal/pkg/docker"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/gin-gonic/gin"
+	"gotest.tools/v3/assert"
+)
+
+func TestGetAllNetworksPassesFilters(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	var receivedOptions docker.ListNetworksOptions
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockListNetworks = func(c context.Context, options docker.ListNetworksOptions) ([]docker.Network, error) {
+		receivedOptions = options
+		return []docker.Network{{Id: "9f3c1e2a", Name: "backend", Driver: "bridge"}}, nil
+	}
+
+	networkController := NetworkController{dockerClient: &mockDockerClient}
+
+	e.GET("/", networkController.GetAllNetworks)
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/?driver=bridge&label=app=shop&type=custom", nil)
+	e.ServeHTTP(w, c.Request)
+
+	var networks []docker.Network
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &networks))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "backend", networks[0].Name)
+	assert.Equal(t, "bridge", receivedOptions.Driver)
+	assert.Equal(t, "custom", receivedOptions.Type)
+	assert.DeepEqual(t, []string{"app=shop"}, receivedOptions.Labels)
+}
+
+func TestGetAllNetworksErrorInvalidType(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	networkController := NetworkController{dockerClient: &mockDockerClient{}}
+
+	e.GET("/", networkController.GetAllNetworks)
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/?type=overlay", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCreateNetworkSuccessDockerClient(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	var receivedOptions docker.CreateNetworkOptions
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockCreateNetwork = func(c context.Context, options docker.CreateNetworkOptions) (docker.Network, error) {
+		receivedOptions = options
+		return docker.Network{Id: "9f3c1e2a", Name: options.Name, Ipam: options.Ipam}, nil
+	}
+
+	networkController := NetworkController{dockerClient: &mockDockerClient}
+
+	e.POST("/", networkController.CreateNetwork)
+
+	newNetwork := models.Network{
+		Name:     "backend",
+		Driver:   "bridge",
+		Internal: true,
+		Labels:   map[string]string{"app": "shop"},
+		Ipam:     []models.IpamConfig{{Subnet: "172.28.0.0/16", Gateway: "172.28.0.1", IpRange: "172.28.5.0/24"}},
+	}
+	data, _ := json.Marshal(newNetwork)
+
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/", bytes.NewBuffer(data))
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "backend", receivedOptions.Name)
+	assert.Equal(t, true, receivedOptions.Internal)
+	assert.DeepEqual(t, []docker.NetworkIpamConfig{{Subnet: "172.28.0.0/16", Gateway: "172.28.0.1", IpRange: "172.28.5.0/24"}}, receivedOptions.Ipam)
+}
+
+func TestCreateNetworkErrorInvalidIpam(t *testing.T) {
+	for name, ipamConfig := range map[string]models.IpamConfig{
+		"invalid subnet":       {Subnet: "172.28.0.0"},
+		"gateway outside":      {Subnet: "172.28.0.0/16", Gateway: "10.0.0.1"},
+		"ip range outside":     {Subnet: "172.28.0.0/16", IpRange: "10.0.0.0/24"},
+		"ip range wider":       {Subnet: "172.28.0.0/16", IpRange: "172.28.0.0/8"},
+		"ip range not a range": {Subnet: "172.28.0.0/16", IpRange: "172.28.5.1"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, e := gin.CreateTestContext(w)
+
+			networkController := NetworkController{dockerClient: &mockDockerClient{}}
+
+			e.POST("/", networkController.CreateNetwork)
+
+			data, _ := json.Marshal(models.Network{Name: "backend", Ipam: []models.IpamConfig{ipamConfig}})
+			c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/", bytes.NewBuffer(data))
+			e.ServeHTTP(w, c.Request)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestConnectNetworkSuccessDockerClient(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	receivedNetworkId, receivedContainerId := "", ""
+	var receivedOptions docker.ConnectNetworkOptions
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockConnectNetwork = func(c context.Context, networkId string, containerId string, options docker.ConnectNetworkOptions) error {
+		receivedNetworkId, receivedContainerId, receivedOptions = networkId, containerId, options
+		return nil
+	}
+
+	networkController := NetworkController{dockerClient: &mockDockerClient}
+
+	e.POST("/:id/connect", networkController.ConnectNetwork)
+
+	data, _ := json.Marshal(models.NetworkConnection{Container: "shop-db", Aliases: []string{"db"}, IPv4Address: "172.28.5.10"})
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/backend/connect", bytes.NewBuffer(data))
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "backend", receivedNetworkId)
+	assert.Equal(t, "shop-db", receivedContainerId)
+	assert.DeepEqual(t, docker.ConnectNetworkOptions{Aliases: []string{"db"}, IPv4Address: "172.28.5.10"}, receivedOptions)
+}
+
+func TestConnectNetworkErrorInvalidAddress(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	networkController := NetworkController{dockerClient: &mockDockerClient{}}
+
+	e.POST("/:id/connect", networkController.ConnectNetwork)
+
+	data, _ := json.Marshal(models.NetworkConnection{Container: "shop-db", IPv4Address: "fd00::10"})
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/backend/connect", bytes.NewBuffer(data))
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDeleteNetworkErrorInUse(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockDeleteNetwork = func(c context.Context, networkId string) error {
+		return errdefs.Forbidden(errors.New("error while removing network: network backend has active endpoints"))
+	}
+
+	networkController := NetworkController{dockerClient: &mockDockerClient}
+
+	e.DELETE("/:id", networkController.DeleteNetwork)
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodDelete, "/backend", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// writeJSON serializes the response, keeping only the top-level fields named by the fields query parameter when it is given.
+// The fields are comma separated or repeated, e.g. fields=id,name&fields=state.
+func writeJSON(ctx *gin.Context, status int, value interface{}, errorMessage string) {
+	fields := projectionFields(ctx)
+
+	if len(fields) == 0 {
+		ctx.JSON(status, value)
+		return
+	}
+
+	projected, err := project(value, fields)
+
+	if err != nil {
+		abortWithError(ctx, errdefs.InvalidParameter(err), errorMessage)
+		return
+	}
+
+	ctx.JSON(status, projected)
+}
+
+// checkFields rejects a fields query parameter that the response type cannot be projected on.
+// Handlers that change something call it first, so that a typo fails the request before the change is made.
+func checkFields(ctx *gin.Context, value interface{}) error {
+	fields := projectionFields(ctx)
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return validateFields(reflect.TypeOf(value), fields)
+}
+
+func projectionFields(ctx *gin.Context) []string {
+	var fields []string
+
+	for _, value := range ctx.QueryArray("fields") {
+		for _, field := range strings.Split(value, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				fields = append(fields, field)
+			}
+		}
+	}
+
+	return fields
+}
+
+// project keeps the given fields of a struct, or of every struct of a slice. Unknown fields are rejected
+// instead of being silently dropped so that typos do not go unnoticed.
+func project(value interface{}, fields []string) (interface{}, error) {
+	if err := validateFields(reflect.TypeOf(value), fields); err != nil {
+		return nil, err
+	}
+
+	byteData, err := json.Marshal(value)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "there is an error while marshalling the response into json")
+	}
+
+	var decoded interface{}
+
+	if err = json.Unmarshal(byteData, &decoded); err != nil {
+		return nil, errors.Wrap(err, "there is an error while unmarshalling the response from json")
+	}
+
+	switch decoded := decoded.(type) {
+	case []interface{}:
+		for i, item := range decoded {
+			if object, ok := item.(map[string]interface{}); ok {
+				decoded[i] = pickFields(object, fields)
+			}
+		}
+
+		return decoded, nil
+	case map[string]interface{}:
+		return pickFields(decoded, fields), nil
+	default:
+		return decoded, nil
+	}
+}
+
+func validateFields(valueType reflect.Type, fields []string) error {
+	known := jsonFieldNames(valueType)
+
+	if known == nil {
+		return errors.New("fields projection is not supported by this resource")
+	}
+
+	for _, field := range fields {
+		if !known[field] {
+			return errors.Errorf("unknown field. Field:%s", field)
+		}
+	}
+
+	return nil
+}
+
+func pickFields(object map[string]interface{}, fields []string) map[string]interface{} {
+	picked := make(map[string]interface{}, len(fields))
+
+	for _, field := range fields {
+		if fieldValue, ok := object[field]; ok {
+			picked[field] = fieldValue
+		}
+	}
+
+	return picked
+}
+
+// jsonFieldNames returns the json names of the fields of a struct type, or of the element type of a slice, and nil for any other type.
+func jsonFieldNames(valueType reflect.Type) map[string]bool {
+	for valueType != nil && (valueType.Kind() == reflect.Ptr || valueType.Kind() == reflect.Slice || valueType.Kind() == reflect.Array) {
+		valueType = valueType.Elem()
+	}
+
+	if valueType == nil || valueType.Kind() != reflect.Struct {
+		return nil
+	}
+
+	names := map[string]bool{}
+
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		names[name] = true
+	}
+
+	return names
+}
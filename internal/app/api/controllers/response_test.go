@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"godopi/internal/pkg/docker"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gotest.tools/v3/assert"
+)
+
+func TestGetDetailedContainerProjectsFields(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockInspectContainer = func(c context.Context, containerId string) (docker.ContainerDetail, error) {
+		return docker.ContainerDetail{Id: containerId, Name: "web", State: docker.ContainerState{Status: "running"}}, nil
+	}
+
+	dockerController := DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient{}}
+
+	e.GET("/:id", dockerController.GetDetailedContainer)
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/3423ASDF372FA7DF732?fields=id,state", nil)
+	e.ServeHTTP(w, c.Request)
+
+	var projected map[string]json.RawMessage
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &projected))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, len(projected))
+	assert.Equal(t, `"3423ASDF372FA7DF732"`, string(projected["id"]))
+	assert.Equal(t, true, json.Valid(projected["state"]))
+}
+
+func TestGetAllImagesProjectsFieldsOfEveryItem(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockListImages = func(c context.Context, all bool, dangling bool) ([]docker.ImageSummary, error) {
+		return []docker.ImageSummary{{Id: "sha256:7425d3a7c478", Size: 10}, {Id: "sha256:2834dc507516", Size: 20}}, nil
+	}
+
+	imageController := ImageController{dockerClient: &mockDockerClient}
+
+	e.GET("/", imageController.GetAllImages)
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/?fields=id&fields=size", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `[{"id":"sha256:7425d3a7c478","size":10},{"id":"sha256:2834dc507516","size":20}]`, w.Body.String())
+}
+
+func TestGetDetailedContainerErrorUnknownField(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockInspectContainer = func(c context.Context, containerId string) (docker.ContainerDetail, error) {
+		return docker.ContainerDetail{Id: containerId}, nil
+	}
+
+	dockerController := DockerController{dockerClient: &mockDockerClient, cacheClient: &mockCacheClient{}}
+
+	e.GET("/:id", dockerController.GetDetailedContainer)
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/3423ASDF372FA7DF732?fields=id,Id", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetImageHistoryProjectsFields(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockGetImageHistory = func(c context.Context, imageId string) ([]docker.ImageHistoryItem, error) {
+		return []docker.ImageHistoryItem{{Id: "sha256:7425d3a7c478", CreatedBy: "CMD [\"nginx\"]", Size: 0}, {Id: "<missing>", CreatedBy: "ADD file:09675d11695f", Size: 80}}, nil
+	}
+
+	imageController := ImageController{dockerClient: &mockDockerClient}
+
+	e.GET("/:id/history", imageController.GetImageHistory)
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/nginx/history?fields=size", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `[{"size":0},{"size":80}]`, w.Body.String())
+}
+
+func TestPruneVolumesErrorUnknownFieldPrunesNothing(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	pruned := false
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockPruneVolumes = func(c context.Context, labels []string) (docker.VolumePruneReport, error) {
+		pruned = true
+		return docker.VolumePruneReport{}, nil
+	}
+
+	volumeController := VolumeController{dockerClient: &mockDockerClient}
+
+	e.POST("/prune", volumeController.PruneVolumes)
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/prune?fields=spaceReclamed", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, false, pruned)
+}
@@ -0,0 +1,256 @@
+package controllers
+
+import (
+	"context"
+	"godopi/internal/app/api/models"
+	"godopi/internal/pkg/docker"
+	"godopi/internal/pkg/jobs"
+	"godopi/internal/pkg/policy"
+	"godopi/internal/pkg/stacks"
+	"net/http"
+
+	. "godopi/internal/pkg/logger"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type StackController struct {
+	stackManager stacks.StackManager
+	jobManager   jobs.JobManager
+}
+
+func NewStackController(jobManager jobs.JobManager, containerPolicy *policy.ContainerPolicy) StackController {
+	Logger().Info("Constructing new stack controller..")
+
+	return StackController{stackManager: stacks.NewStackManager(docker.NewDockerClient(), containerPolicy), jobManager: jobManager}
+}
+
+// GetAllStacks godoc
+// @Summary Gets the stacks deployed from compose files
+// @Tags    Stack
+// @Accept  json
+// @Produce json
+// @Param   fields query []string false "Fields of each stack to return, e.g. name,services" collectionFormat(csv)
+// @Success 200 {array} stacks.Stack
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /stacks [get]
+func (sc StackController) GetAllStacks(ctx *gin.Context) {
+	stackList, err := sc.stackManager.ListStacks(ctx.Request.Context())
+
+	if err != nil {
+		err = errors.Wrap(err, "there is an error while getting the stacks info")
+		abortWithError(ctx, err, "Error retrieving stacks!")
+		return
+	}
+
+	writeJSON(ctx, http.StatusOK, stackList, "Error retrieving stacks!")
+}
+
+// GetStack godoc
+// @Summary Gets the services, networks and volumes of a stack
+// @Tags    Stack
+// @Accept  json
+// @Produce json
+// @Param   name   path  string   true  "Stack name"
+// @Param   fields query []string false "Fields to return, e.g. services" collectionFormat(csv)
+// @Success 200 {object} stacks.Stack
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /stacks/{name} [get]
+func (sc StackController) GetStack(ctx *gin.Context) {
+	stackName := ctx.Param("name")
+	stack, err := sc.stackManager.GetStack(ctx.Request.Context(), stackName)
+
+	if err != nil {
+		err = errors.Wrapf(err, "there is an error while getting stack info. Stack:%s", stackName)
+		abortWithError(ctx, err, "Error retrieving stack!")
+		return
+	}
+
+	writeJSON(ctx, http.StatusOK, stack, "Error retrieving stack!")
+}
+
+// CreateStack godoc
+// @Summary Deploys a compose file as a new stack
+// @Description Creates the networks, volumes and containers of the compose file in dependency order and labels them with the stack name.
+// @Description Services must name an image, building images is not supported. depends_on only orders the creation, its conditions are not awaited.
+// @Description With async=true the deployment runs as a background job whose progress is available under /jobs/{id}.
+// @Tags    Stack
+// @Accept  json
+// @Produce json
+// @Param   Stack body  models.Stack true  "Create Stack"
+// @Param   async query bool         false "Run the deployment as a background job"
+// @Success 201 {object} stacks.Stack
+// @Success 202 {object} jobs.Snapshot
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /stacks [post]
+func (sc StackController) CreateStack(ctx *gin.Context) {
+	var newStack models.Stack
+	if err := ctx.ShouldBindJSON(&newStack); err != nil {
+		err = errors.Wrap(err, "there is an error while validating parameters of stack")
+		abortWithError(ctx, errdefs.InvalidParameter(err), "Error creating stack!")
+		return
+	}
+
+	if err := stacks.ValidateStackName(newStack.Name); err != nil {
+		err = errors.Wrap(err, "there is an error while validating parameters of stack")
+		abortWithError(ctx, err, "Error creating stack!")
+		return
+	}
+
+	composeFile, err := stacks.ParseCompose([]byte(newStack.Compose))
+
+	if err != nil {
+		err = errors.Wrapf(err, "there is an error while validating compose file of stack. Stack:%s", newStack.Name)
+		abortWithError(ctx, err, "Error creating stack!")
+		return
+	}
+
+	if err = sc.stackManager.CheckPolicy(newStack.Name, composeFile); err != nil {
+		err = errors.Wrapf(err, "there is an error while checking the container policy of stack. Stack:%s", newStack.Name)
+		abortWithError(ctx, err, "Error creating stack!")
+		return
+	}
+
+	sc.deploy(ctx, "stack-create", newStack.Name, http.StatusCreated, "Error creating stack!", func(c context.Context, onProgress func(stacks.DeployProgress)) (stacks.Stack, error) {
+		return sc.stackManager.CreateStack(c, newStack.Name, composeFile, onProgress)
+	})
+}
+
+// UpdateStack godoc
+// @Summary Updates a stack to a new version of its compose file
+// @Description Recreates the services whose configuration changed and removes the services and networks the compose file no longer defines. Volumes are kept.
+// @Description With async=true the deployment runs as a background job whose progress is available under /jobs/{id}.
+// @Tags    Stack
+// @Accept  json
+// @Produce json
+// @Param   name  path  string             true  "Stack name"
+// @Param   Stack body  models.StackUpdate true  "Update Stack"
+// @Param   async query bool               false "Run the deployment as a background job"
+// @Success 200 {object} stacks.Stack
+// @Success 202 {object} jobs.Snapshot
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /stacks/{name} [put]
+func (sc StackController) UpdateStack(ctx *gin.Context) {
+	stackName := ctx.Param("name")
+
+	var stackUpdate models.StackUpdate
+	if err := ctx.ShouldBindJSON(&stackUpdate); err != nil {
+		err = errors.Wrap(err, "there is an error while validating parameters of stack update")
+		abortWithError(ctx, errdefs.InvalidParameter(err), "Error updating stack!")
+		return
+	}
+
+	if err := stacks.ValidateStackName(stackName); err != nil {
+		err = errors.Wrap(err, "there is an error while validating parameters of stack update")
+		abortWithError(ctx, err, "Error updating stack!")
+		return
+	}
+
+	composeFile, err := stacks.ParseCompose([]byte(stackUpdate.Compose))
+
+	if err != nil {
+		err = errors.Wrapf(err, "there is an error while validating compose file of stack. Stack:%s", stackName)
+		abortWithError(ctx, err, "Error updating stack!")
+		return
+	}
+
+	if err = sc.stackManager.CheckPolicy(stackName, composeFile); err != nil {
+		err = errors.Wrapf(err, "there is an error while checking the container policy of stack. Stack:%s", stackName)
+		abortWithError(ctx, err, "Error updating stack!")
+		return
+	}
+
+	sc.deploy(ctx, "stack-update", stackName, http.StatusOK, "Error updating stack!", func(c context.Context, onProgress func(stacks.DeployProgress)) (stacks.Stack, error) {
+		return sc.stackManager.UpdateStack(c, stackName, composeFile, onProgress)
+	})
+}
+
+// DeleteStack godoc
+// @Summary Deletes the containers and networks of a stack
+// @Tags    Stack
+// @Accept  json
+// @Produce json
+// @Param   name    path  string true  "Stack name"
+// @Param   volumes query bool   false "Delete the volumes of the stack as well"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /stacks/{name} [delete]
+func (sc StackController) DeleteStack(ctx *gin.Context) {
+	stackName := ctx.Param("name")
+
+	if err := sc.stackManager.DeleteStack(ctx.Request.Context(), stackName, ctx.Query("volumes") == "true"); err != nil {
+		err = errors.Wrapf(err, "there is an error while deleting stack. Stack:%s", stackName)
+		abortWithError(ctx, err, "Error deleting stack!")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.SuccessResponse{Success: "Stack " + stackName + " deleted"})
+}
+
+// deploy runs a stack deployment either in the request or, with async=true, as a background job like an image pull.
+func (sc StackController) deploy(ctx *gin.Context, jobType string, stackName string, status int, errorMessage string, run func(context.Context, func(stacks.DeployProgress)) (stacks.Stack, error)) {
+	if ctx.Query("async") == "true" {
+		job := sc.jobManager.Start(jobType, stackName, jobOwner(ctx), func(jobCtx context.Context, report func(jobs.Progress)) error {
+			_, err := run(jobCtx, func(progress stacks.DeployProgress) {
+				report(jobs.Progress{Id: progress.Resource, Status: progress.Status})
+			})
+
+			return err
+		})
+
+		ctx.Header("Location", "/api/v1/jobs/"+job.Id)
+		ctx.JSON(http.StatusAccepted, job)
+		return
+	}
+
+	stack, err := run(ctx.Request.Context(), nil)
+
+	if err != nil {
+		err = errors.Wrapf(err, "there is an error while deploying stack. Stack:%s", stackName)
+		abortWithError(ctx, err, errorMessage)
+		return
+	}
+
+	ctx.JSON(status, stack)
+}
@@ -0,0 +1,371 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"godopi/internal/app/api/models"
+	"godopi/internal/pkg/docker"
+	"godopi/internal/pkg/stacks"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/go-connections/nat"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"gotest.tools/v3/assert"
+)
+
+const shopCompose = `
+version: "3.9"
+services:
+  api:
+    image: registry.local/godopi:1.0
+    ports:
+      - 8080:8080
+    depends_on:
+      - cache
+    environment:
+      - REDIS_ADDRESS=cache:6379
+  cache:
+    image: redis:6.2.6-alpine
+    command: redis-server --appendonly yes
+    volumes:
+      - cache-data:/data
+volumes:
+  cache-data:
+`
+
+// fakeStackEngine keeps the resources created through its mock docker client, so that a stack can be deployed and then updated.
+type fakeStackEngine struct {
+	containers       []docker.ContainerSummary
+	networks         []docker.Network
+	volumes          []docker.Volume
+	created          []string
+	deleted          []string
+	containerOptions map[string]docker.CreateContainerOptions
+}
+
+func newFakeStackEngine() *fakeStackEngine {
+	return &fakeStackEngine{containerOptions: map[string]docker.CreateContainerOptions{}}
+}
+
+func (fe *fakeStackEngine) dockerClient() *mockDockerClient {
+	mockDockerClient := mockDockerClient{}
+
+	mockDockerClient.MockListContainers = func(c context.Context, options docker.ListContainersOptions) ([]docker.ContainerSummary, string, error) {
+		var containers []docker.ContainerSummary
+
+		for _, container := range fe.containers {
+			if hasLabels(container.Labels, options.Labels) {
+				containers = append(containers, container)
+			}
+		}
+
+		return containers, "", nil
+	}
+	mockDockerClient.MockListNetworks = func(c context.Context, options docker.ListNetworksOptions) ([]docker.Network, error) {
+		var networks []docker.Network
+
+		for _, network := range fe.networks {
+			if hasLabels(network.Labels, options.Labels) {
+				networks = append(networks, network)
+			}
+		}
+
+		return networks, nil
+	}
+	mockDockerClient.MockListVolumes = func(c context.Context, options docker.ListVolumesOptions) ([]docker.Volume, error) {
+		var volumes []docker.Volume
+
+		for _, volume := range fe.volumes {
+			if hasLabels(volume.Labels, options.Labels) {
+				volumes = append(volumes, volume)
+			}
+		}
+
+		return volumes, nil
+	}
+	mockDockerClient.MockCreateNetwork = func(c context.Context, options docker.CreateNetworkOptions) (docker.Network, error) {
+		network := docker.Network{Id: options.Name + "-id", Name: options.Name, Labels: options.Labels}
+		fe.networks = append(fe.networks, network)
+		fe.created = append(fe.created, options.Name)
+		return network, nil
+	}
+	mockDockerClient.MockCreateVolume = func(c context.Context, options docker.CreateVolumeOptions) (docker.Volume, error) {
+		volume := docker.Volume{Name: options.Name, Labels: options.Labels}
+		fe.volumes = append(fe.volumes, volume)
+		fe.created = append(fe.created, options.Name)
+		return volume, nil
+	}
+	mockDockerClient.MockCreateContainer = func(c context.Context, options docker.CreateContainerOptions) (string, error) {
+		containerId := options.Name + "-" + options.Config.Labels[stacks.STACK_CONFIG_HASH_LABEL][:8]
+		container := docker.ContainerSummary{Id: containerId, Name: options.Name, Image: options.Config.Image, State: "running", Labels: options.Config.Labels}
+
+		for networkName := range options.NetworkingConfig.EndpointsConfig {
+			container.Networks = append(container.Networks, networkName)
+		}
+
+		fe.containers = append(fe.containers, container)
+		fe.created = append(fe.created, options.Name)
+		fe.containerOptions[options.Name] = options
+		return containerId, nil
+	}
+	mockDockerClient.MockDeleteContainer = func(c context.Context, containerId string) error {
+		for i, container := range fe.containers {
+			if container.Id == containerId {
+				fe.containers = append(fe.containers[:i], fe.containers[i+1:]...)
+				break
+			}
+		}
+
+		fe.deleted = append(fe.deleted, containerId)
+		return nil
+	}
+	mockDockerClient.MockDeleteNetwork = func(c context.Context, networkId string) error {
+		for i, network := range fe.networks {
+			if network.Id == networkId {
+				for _, container := range fe.containers {
+					for _, networkName := range container.Networks {
+						if networkName == network.Name {
+							return errdefs.Conflict(errors.Errorf("network has active endpoints. Network:%s", network.Name))
+						}
+					}
+				}
+
+				fe.networks = append(fe.networks[:i], fe.networks[i+1:]...)
+				break
+			}
+		}
+
+		fe.deleted = append(fe.deleted, networkId)
+		return nil
+	}
+
+	return &mockDockerClient
+}
+
+// hasLabels matches labels against filters given as key or key=value, like the engine does.
+func hasLabels(labels map[string]string, filters []string) bool {
+	for _, filter := range filters {
+		parts := strings.SplitN(filter, "=", 2)
+		value, ok := labels[parts[0]]
+
+		if !ok || (len(parts) == 2 && value != parts[1]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestCreateStackDeploysInDependencyOrder(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	fakeEngine := newFakeStackEngine()
+	stackController := StackController{stackManager: stacks.NewStackManager(fakeEngine.dockerClient(), nil)}
+
+	e.POST("/", stackController.CreateStack)
+
+	data, _ := json.Marshal(models.Stack{Name: "shop", Compose: shopCompose})
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/", bytes.NewBuffer(data))
+	e.ServeHTTP(w, c.Request)
+
+	var stack stacks.Stack
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &stack))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.DeepEqual(t, []string{"shop_default", "shop_cache-data", "shop-cache", "shop-api"}, fakeEngine.created)
+	assert.Equal(t, 2, len(stack.Services))
+	assert.DeepEqual(t, []string{"shop_default"}, stack.Networks)
+
+	api := fakeEngine.containerOptions["shop-api"]
+	assert.DeepEqual(t, []string{"REDIS_ADDRESS=cache:6379"}, api.Config.Env)
+	assert.Equal(t, "8080", api.HostConfig.PortBindings[nat.Port("8080/tcp")][0].HostPort)
+	assert.Equal(t, "shop", api.Config.Labels[stacks.STACK_LABEL])
+	assert.Equal(t, "api", api.Config.Labels[stacks.STACK_SERVICE_LABEL])
+
+	cache := fakeEngine.containerOptions["shop-cache"]
+	assert.DeepEqual(t, []string{"redis-server", "--appendonly", "yes"}, []string(cache.Config.Cmd))
+	assert.DeepEqual(t, []string{"cache"}, cache.NetworkingConfig.EndpointsConfig["shop_default"].Aliases)
+	assert.DeepEqual(t, mount.Mount{Type: mount.TypeVolume, Source: "shop_cache-data", Target: "/data"}, cache.HostConfig.Mounts[0])
+}
+
+func TestCreateStackErrorExists(t *testing.T) {
+	fakeEngine := newFakeStackEngine()
+	stackManager := stacks.NewStackManager(fakeEngine.dockerClient(), nil)
+
+	composeFile, err := stacks.ParseCompose([]byte(shopCompose))
+	assert.NilError(t, err)
+
+	_, err = stackManager.CreateStack(context.Background(), "shop", composeFile, nil)
+	assert.NilError(t, err)
+
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	stackController := StackController{stackManager: stackManager}
+
+	e.POST("/", stackController.CreateStack)
+
+	data, _ := json.Marshal(models.Stack{Name: "shop", Compose: shopCompose})
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/", bytes.NewBuffer(data))
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestCreateStackErrorInvalidCompose(t *testing.T) {
+	// The compose file of the repo builds its api image, which godopi cannot do.
+	repoCompose, err := os.ReadFile("../../../../docker-compose.yml")
+	assert.NilError(t, err)
+
+	for name, newStack := range map[string]models.Stack{
+		"build without image": {Name: "godopi", Compose: string(repoCompose)},
+		"invalid stack name":  {Name: "Shop!", Compose: shopCompose},
+		"cyclic dependencies": {Name: "shop", Compose: "services:\n  a:\n    image: busybox\n    depends_on: [b]\n  b:\n    image: busybox\n    depends_on: [a]\n"},
+		"undefined volume":    {Name: "shop", Compose: "services:\n  a:\n    image: busybox\n    volumes:\n      - data:/data\n"},
+		"relative bind mount": {Name: "shop", Compose: "services:\n  a:\n    image: busybox\n    volumes:\n      - ./data:/data\n"},
+		"not yaml":            {Name: "shop", Compose: "services: [a"},
+		"privileged service":  {Name: "shop", Compose: "services:\n  a:\n    image: busybox\n    privileged: true\n"},
+		"host network mode":   {Name: "shop", Compose: "services:\n  a:\n    image: busybox\n    network_mode: host\n"},
+		"unknown network key": {Name: "shop", Compose: "services:\n  a:\n    image: busybox\nnetworks:\n  back:\n    enable_ipv6: true\n"},
+		"secrets":             {Name: "shop", Compose: "services:\n  a:\n    image: busybox\nsecrets:\n  token:\n    file: ./token\n"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, e := gin.CreateTestContext(w)
+
+			stackController := StackController{stackManager: stacks.NewStackManager(newFakeStackEngine().dockerClient(), nil)}
+
+			e.POST("/", stackController.CreateStack)
+
+			data, _ := json.Marshal(newStack)
+			c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/", bytes.NewBuffer(data))
+			e.ServeHTTP(w, c.Request)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestParseComposeIgnoresVersionAndExtensions(t *testing.T) {
+	composeFile, err := stacks.ParseCompose([]byte("version: \"3.9\"\nname: shop\nx-common: &common\n  restart: always\nservices:\n  a:\n    image: busybox\n    x-owner: shop-team\n"))
+	assert.NilError(t, err)
+
+	assert.Equal(t, "busybox", composeFile.Services["a"].Image)
+}
+
+func TestUpdateStackRecreatesChangedServices(t *testing.T) {
+	fakeEngine := newFakeStackEngine()
+	stackManager := stacks.NewStackManager(fakeEngine.dockerClient(), nil)
+
+	composeFile, err := stacks.ParseCompose([]byte(strings.Replace(shopCompose, "  cache:\n", "  worker:\n    image: busybox\n  cache:\n", 1)))
+	assert.NilError(t, err)
+
+	stack, err := stackManager.CreateStack(context.Background(), "shop", composeFile, nil)
+	assert.NilError(t, err)
+
+	containerIds := map[string]string{}
+
+	for _, service := range stack.Services {
+		containerIds[service.Name] = service.ContainerId
+	}
+
+	fakeEngine.created = nil
+
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	stackController := StackController{stackManager: stackManager}
+
+	e.PUT("/:name", stackController.UpdateStack)
+
+	data, _ := json.Marshal(models.StackUpdate{Compose: strings.Replace(shopCompose, "cache:6379", "cache:6380", 1)})
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPut, "/shop", bytes.NewBuffer(data))
+	e.ServeHTTP(w, c.Request)
+
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &stack))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.DeepEqual(t, []string{"shop-api"}, fakeEngine.created)
+	assert.DeepEqual(t, []string{containerIds["api"], containerIds["worker"]}, fakeEngine.deleted)
+	assert.Equal(t, 2, len(stack.Services))
+	assert.Equal(t, containerIds["cache"], stack.Services[1].ContainerId)
+}
+
+func TestUpdateStackRecreatesChangedNetworks(t *testing.T) {
+	const networkCompose = `
+services:
+  api:
+    image: registry.local/godopi:1.0
+    networks: [front, back]
+  cache:
+    image: redis:6.2.6-alpine
+    networks: [back]
+  web:
+    image: nginx:1.21
+    networks: [front]
+networks:
+  front:
+  back:
+`
+
+	fakeEngine := newFakeStackEngine()
+	stackManager := stacks.NewStackManager(fakeEngine.dockerClient(), nil)
+
+	composeFile, err := stacks.ParseCompose([]byte(networkCompose))
+	assert.NilError(t, err)
+
+	stack, err := stackManager.CreateStack(context.Background(), "shop", composeFile, nil)
+	assert.NilError(t, err)
+
+	containerIds := map[string]string{}
+
+	for _, service := range stack.Services {
+		containerIds[service.Name] = service.ContainerId
+	}
+
+	fakeEngine.created = nil
+
+	composeFile, err = stacks.ParseCompose([]byte(strings.Replace(networkCompose, "  back:\n", "  back:\n    internal: true\n", 1)))
+	assert.NilError(t, err)
+
+	var progress []stacks.DeployProgress
+
+	_, err = stackManager.UpdateStack(context.Background(), "shop", composeFile, func(deployProgress stacks.DeployProgress) {
+		progress = append(progress, deployProgress)
+	})
+	assert.NilError(t, err)
+
+	// Only the containers attached to the changed network are recreated with it.
+	assert.DeepEqual(t, []string{"shop_back", "shop-api", "shop-cache"}, fakeEngine.created)
+	assert.DeepEqual(t, []string{containerIds["api"], containerIds["cache"], "shop_back-id"}, fakeEngine.deleted)
+	assert.Equal(t, true, fakeEngine.networks[1].Labels[stacks.STACK_CONFIG_HASH_LABEL] != "")
+	assert.DeepEqual(t, []stacks.DeployProgress{
+		{Resource: "shop_back", Status: "Recreated"},
+		{Resource: "shop_front", Status: "Unchanged"},
+		{Resource: "shop-api", Status: "Recreated"},
+		{Resource: "shop-cache", Status: "Recreated"},
+		{Resource: "shop-web", Status: "Unchanged"},
+	}, progress)
+}
+
+func TestDeleteStackErrorNotFound(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	stackController := StackController{stackManager: stacks.NewStackManager(newFakeStackEngine().dockerClient(), nil)}
+
+	e.DELETE("/:name", stackController.DeleteStack)
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodDelete, "/shop", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
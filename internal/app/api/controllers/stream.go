@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// startEventStream prepares the response for server-sent events.
+func startEventStream(ctx *gin.Context) {
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+}
+
+// sendEvent writes a single server-sent event and flushes it to the client right away.
+func sendEvent(ctx *gin.Context, name string, data interface{}) {
+	ctx.SSEvent(name, data)
+	ctx.Writer.Flush()
+}
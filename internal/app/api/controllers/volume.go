@@ -0,0 +1,218 @@
+package controllers
+
+import (
+	"godopi/internal/app/api/models"
+	"godopi/internal/pkg/docker"
+	"godopi/internal/pkg/policy"
+	"net/http"
+	"strconv"
+
+	. "godopi/internal/pkg/logger"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type VolumeController struct {
+	dockerClient    docker.DockerClient
+	containerPolicy *policy.ContainerPolicy
+}
+
+func NewVolumeController(containerPolicy *policy.ContainerPolicy) VolumeController {
+	Logger().Info("Constructing new volume controller..")
+
+	return VolumeController{dockerClient: docker.NewDockerClient(), containerPolicy: containerPolicy}
+}
+
+// GetAllVolumes godoc
+// @Summary Gets the volumes
+// @Tags    Volume
+// @Accept  json
+// @Produce json
+// @Param   name     query string   false "Volume name or a part of it"
+// @Param   driver   query string   false "Volume driver"
+// @Param   label    query []string false "Label as key or key=value" collectionFormat(multi)
+// @Param   dangling query bool     false "Only list volumes that are, or are not, used by any container"
+// @Param   fields   query []string false "Fields of each volume to return, e.g. name,mountpoint" collectionFormat(csv)
+// @Success 200 {array} docker.Volume
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/volumes [get]
+func (vc VolumeController) GetAllVolumes(ctx *gin.Context) {
+	options := docker.ListVolumesOptions{Name: ctx.Query("name"), Driver: ctx.Query("driver"), Labels: ctx.QueryArray("label")}
+
+	if value, ok := ctx.GetQuery("dangling"); ok {
+		dangling, err := strconv.ParseBool(value)
+
+		if err != nil {
+			abortWithError(ctx, errdefs.InvalidParameter(errors.Errorf("dangling must be true or false. Dangling:%s", value)), "Error retrieving volumes!")
+			return
+		}
+
+		options.Dangling = &dangling
+	}
+
+	volumes, err := vc.dockerClient.ListVolumes(ctx.Request.Context(), options)
+
+	if err != nil {
+		err = errors.Wrap(err, "there is an error while getting the volumes info")
+		abortWithError(ctx, err, "Error retrieving volumes!")
+		return
+	}
+
+	writeJSON(ctx, http.StatusOK, volumes, "Error retrieving volumes!")
+}
+
+// GetVolume godoc
+// @Summary Gets detail for a volume
+// @Tags    Volume
+// @Accept  json
+// @Produce json
+// @Param   name   path  string   true  "Volume name"
+// @Param   fields query []string false "Fields to return, e.g. name,labels" collectionFormat(csv)
+// @Success 200 {object} docker.Volume
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/volumes/{name} [get]
+func (vc VolumeController) GetVolume(ctx *gin.Context) {
+	volumeName := ctx.Param("name")
+	volume, err := vc.dockerClient.InspectVolume(ctx.Request.Context(), volumeName)
+
+	if err != nil {
+		err = errors.Wrapf(err, "there is an error while getting volume info. VolumeName:%s", volumeName)
+		abortWithError(ctx, err, "Error retrieving volume!")
+		return
+	}
+
+	writeJSON(ctx, http.StatusOK, volume, "Error retrieving volume!")
+}
+
+// CreateVolume godoc
+// @Summary Creates a volume, named by the engine when no name is given
+// @Tags    Volume
+// @Accept  json
+// @Produce json
+// @Param   Volume body  models.Volume true  "Create Volume"
+// @Param   fields query []string      false "Fields to return, e.g. name,mountpoint" collectionFormat(csv)
+// @Success 201 {object} docker.Volume
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/volumes [post]
+func (vc VolumeController) CreateVolume(ctx *gin.Context) {
+	if err := checkFields(ctx, docker.Volume{}); err != nil {
+		abortWithError(ctx, errdefs.InvalidParameter(err), "Error creating volume!")
+		return
+	}
+
+	var newVolume models.Volume
+	if err := ctx.ShouldBindJSON(&newVolume); err != nil {
+		err = errors.Wrap(err, "there is an error while validating parameters of volume")
+		abortWithError(ctx, errdefs.InvalidParameter(err), "Error creating volume!")
+		return
+	}
+
+	createOptions := docker.CreateVolumeOptions{
+		Name:       newVolume.Name,
+		Driver:     newVolume.Driver,
+		DriverOpts: newVolume.DriverOpts,
+		Labels:     newVolume.Labels,
+	}
+
+	// A volume binding a host path would let any container mount that path as a plain volume.
+	if err := vc.containerPolicy.CheckVolume(createOptions); err != nil {
+		err = errors.Wrap(err, "there is an error while checking the container policy")
+		abortWithError(ctx, err, "Error creating volume!")
+		return
+	}
+
+	volume, err := vc.dockerClient.CreateVolume(ctx.Request.Context(), createOptions)
+
+	if err != nil {
+		err = errors.Wrap(err, "there is an error while creating volume")
+		abortWithError(ctx, err, "Error creating volume!")
+		return
+	}
+
+	writeJSON(ctx, http.StatusCreated, volume, "Error creating volume!")
+}
+
+// DeleteVolume godoc
+// @Summary Deletes a volume
+// @Tags    Volume
+// @Accept  json
+// @Produce json
+// @Param   name  path  string true  "Volume name"
+// @Param   force query bool   false "Remove the volume even if it is in use"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/volumes/{name} [delete]
+func (vc VolumeController) DeleteVolume(ctx *gin.Context) {
+	volumeName := ctx.Param("name")
+
+	if err := vc.dockerClient.DeleteVolume(ctx.Request.Context(), volumeName, ctx.Query("force") == "true"); err != nil {
+		err = errors.Wrapf(err, "there is an error while deleting volume. VolumeName:%s", volumeName)
+		abortWithError(ctx, err, "Error deleting volume!")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.SuccessResponse{Success: "Volume " + volumeName + " deleted"})
+}
+
+// PruneVolumes godoc
+// @Summary Deletes the local volumes that are not used by any container
+// @Tags    Volume
+// @Accept  json
+// @Produce json
+// @Param   label  query []string false "Only prune volumes with the label, as key or key=value" collectionFormat(multi)
+// @Param   fields query []string false "Fields to return, e.g. spaceReclaimed" collectionFormat(csv)
+// @Success 200 {object} docker.VolumePruneReport
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router  /docker/volumes/prune [post]
+func (vc VolumeController) PruneVolumes(ctx *gin.Context) {
+	if err := checkFields(ctx, docker.VolumePruneReport{}); err != nil {
+		abortWithError(ctx, errdefs.InvalidParameter(err), "Error pruning volumes!")
+		return
+	}
+
+	pruneReport, err := vc.dockerClient.PruneVolumes(ctx.Request.Context(), ctx.QueryArray("label"))
+
+	if err != nil {
+		err = errors.Wrap(err, "there is an error while pruning volumes")
+		abortWithError(ctx, err, "Error pruning volumes!")
+		return
+	}
+
+	writeJSON(ctx, http.StatusOK, pruneReport, "Error pruning volumes!")
+}
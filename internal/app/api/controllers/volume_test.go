@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"godopi/internal/app/api/models"
+	"godopi/internal/pkg/docker"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/gin-gonic/gin"
+	"gotest.tools/v3/assert"
+)
+
+func TestGetAllVolumesPassesFilters(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	var receivedOptions docker.ListVolumesOptions
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockListVolumes = func(c context.Context, options docker.ListVolumesOptions) ([]docker.Volume, error) {
+		receivedOptions = options
+		return []docker.Volume{{Name: "pgdata", Driver: "local"}}, nil
+	}
+
+	volumeController := VolumeController{dockerClient: &mockDockerClient}
+
+	e.GET("/", volumeController.GetAllVolumes)
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/?driver=local&label=app=db&dangling=false", nil)
+	e.ServeHTTP(w, c.Request)
+
+	var volumes []docker.Volume
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &volumes))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "pgdata", volumes[0].Name)
+	assert.Equal(t, "local", receivedOptions.Driver)
+	assert.DeepEqual(t, []string{"app=db"}, receivedOptions.Labels)
+	assert.Assert(t, receivedOptions.Dangling != nil && !*receivedOptions.Dangling)
+}
+
+func TestGetAllVolumesErrorInvalidDangling(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	volumeController := VolumeController{dockerClient: &mockDockerClient{}}
+
+	e.GET("/", volumeController.GetAllVolumes)
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/?dangling=maybe", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCreateVolumeSuccessDockerClient(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	var receivedOptions docker.CreateVolumeOptions
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockCreateVolume = func(c context.Context, options docker.CreateVolumeOptions) (docker.Volume, error) {
+		receivedOptions = options
+		return docker.Volume{Name: options.Name, Driver: options.Driver, Labels: options.Labels}, nil
+	}
+
+	volumeController := VolumeController{dockerClient: &mockDockerClient}
+
+	e.POST("/", volumeController.CreateVolume)
+
+	newVolume := models.Volume{Name: "pgdata", Driver: "local", DriverOpts: map[string]string{"type": "tmpfs", "device": "tmpfs"}, Labels: map[string]string{"app": "db"}}
+	data, _ := json.Marshal(newVolume)
+
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodPost, "/", bytes.NewBuffer(data))
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "pgdata", receivedOptions.Name)
+	assert.DeepEqual(t, newVolume.DriverOpts, receivedOptions.DriverOpts)
+}
+
+func TestDeleteVolumeErrorInUse(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	receivedForce := true
+
+	mockDockerClient := mockDockerClient{}
+	mockDockerClient.MockDeleteVolume = func(c context.Context, volumeName string, force bool) error {
+		receivedForce = force
+		return errdefs.Conflict(errors.New("remove pgdata: volume is in use"))
+	}
+
+	volumeController := VolumeController{dockerClient: &mockDockerClient}
+
+	e.DELETE("/:name", volumeController.DeleteVolume)
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodDelete, "/pgdata", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, false, receivedForce)
+}
@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// newWebSocketUpgrader only accepts same-origin requests unless other origins are listed in the comma separated allowedOrigins.
+func newWebSocketUpgrader(allowedOrigins string) websocket.Upgrader {
+	upgrader := websocket.Upgrader{ReadBufferSize: 4096, WriteBufferSize: 4096}
+
+	if strings.TrimSpace(allowedOrigins) == "" {
+		return upgrader
+	}
+
+	origins := map[string]struct{}{}
+
+	for _, origin := range strings.Split(allowedOrigins, ",") {
+		origins[strings.TrimSpace(origin)] = struct{}{}
+	}
+
+	upgrader.CheckOrigin = func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+
+		if origin == "" || strings.EqualFold(origin, "http://"+r.Host) || strings.EqualFold(origin, "https://"+r.Host) {
+			return true
+		}
+
+		_, ok := origins[origin]
+
+		return ok
+	}
+
+	return upgrader
+}
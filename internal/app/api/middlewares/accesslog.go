@@ -0,0 +1,46 @@
+package middlewares
+
+import (
+	"net/http"
+	"time"
+
+	. "godopi/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AccessLog logs every request once it is answered, with the request logger set up by RequestId.
+// It must come after RequestId and before gin.Recovery, so that the requests that panic are logged with their 500 status.
+// The query string is left out as it may carry credentials.
+func AccessLog() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		fields := []zap.Field{
+			zap.String("Method", ctx.Request.Method),
+			zap.String("Path", ctx.Request.URL.Path),
+			zap.Int("Status", status),
+			zap.Duration("Latency", time.Since(start)),
+			zap.String("ClientIp", ctx.ClientIP()),
+			zap.Int("ResponseBytes", ctx.Writer.Size()),
+		}
+
+		if principal, ok := GetPrincipal(ctx); ok {
+			fields = append(fields, zap.String("AuthMethod", principal.Method))
+		}
+
+		// The request context is read after the request, as Authenticate adds the principal to its logger.
+		requestLogger := LoggerFromContext(ctx.Request.Context())
+
+		if status >= http.StatusInternalServerError {
+			requestLogger.Error("Request", fields...)
+			return
+		}
+
+		requestLogger.Info("Request", fields...)
+	}
+}
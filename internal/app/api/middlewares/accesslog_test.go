@@ -0,0 +1,76 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "godopi/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gotest.tools/v3/assert"
+)
+
+// serveLogged sends a request through RequestId, AccessLog, gin.Recovery and Authenticate, with logs going to the returned observer.
+func serveLogged(t *testing.T, target string, handler gin.HandlerFunc) *observer.ObservedLogs {
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	authenticate, err := Authenticate(AuthOptions{ApiKeys: testApiKeyEntry("ops", "first-key")})
+	assert.NilError(t, err)
+
+	w := httptest.NewRecorder()
+	_, e := gin.CreateTestContext(w)
+
+	e.Use(func(ctx *gin.Context) {
+		ctx.Request = ctx.Request.WithContext(WithLogger(ctx.Request.Context(), zap.New(core)))
+	}, RequestId(), AccessLog(), gin.Recovery(), authenticate)
+	e.GET("/containers/:id", handler)
+
+	request, _ := http.NewRequest(http.MethodGet, target, nil)
+	request.Header.Set(REQUEST_ID_HEADER, "req-42")
+	request.Header.Set("X-API-Key", "first-key")
+	request.RemoteAddr = "192.0.2.10:40000"
+	e.ServeHTTP(w, request)
+
+	return logs
+}
+
+func TestAccessLogWithRequestLogger(t *testing.T) {
+	logs := serveLogged(t, "/containers/web?token=secret", func(ctx *gin.Context) {
+		LoggerFromContext(ctx.Request.Context()).Info("Handling request")
+		ctx.Status(http.StatusCreated)
+	})
+
+	entries := logs.AllUntimed()
+	assert.Equal(t, 2, len(entries))
+
+	handlerFields := entries[0].ContextMap()
+	assert.Equal(t, "Handling request", entries[0].Message)
+	assert.Equal(t, "req-42", handlerFields["RequestId"])
+	assert.Equal(t, "ops", handlerFields["Principal"])
+
+	accessFields := entries[1].ContextMap()
+	assert.Equal(t, zapcore.InfoLevel, entries[1].Level)
+	assert.Equal(t, "req-42", accessFields["RequestId"])
+	assert.Equal(t, "ops", accessFields["Principal"])
+	assert.Equal(t, AuthMethodApiKey, accessFields["AuthMethod"])
+	assert.Equal(t, http.MethodGet, accessFields["Method"])
+	assert.Equal(t, "/containers/web", accessFields["Path"])
+	assert.Equal(t, int64(http.StatusCreated), accessFields["Status"])
+	assert.Equal(t, "192.0.2.10", accessFields["ClientIp"])
+	assert.Assert(t, accessFields["Latency"] != nil)
+}
+
+func TestAccessLogErrorLevelOnPanic(t *testing.T) {
+	logs := serveLogged(t, "/containers/web", func(ctx *gin.Context) {
+		panic("broken handler")
+	})
+
+	accessLogs := logs.FilterMessage("Request").AllUntimed()
+	assert.Equal(t, 1, len(accessLogs))
+	assert.Equal(t, zapcore.ErrorLevel, accessLogs[0].Level)
+	assert.Equal(t, int64(http.StatusInternalServerError), accessLogs[0].ContextMap()["Status"])
+}
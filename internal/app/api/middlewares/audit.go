@@ -0,0 +1,298 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"godopi/internal/app/api/models"
+	"godopi/internal/pkg/audit"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxAuditedBodySize bounds the part of a request body that is recorded. The handler still receives the whole body.
+const maxAuditedBodySize = 64 * 1024
+
+// maxAuditedErrorSize bounds the part of an error response that is read for its code and message.
+const maxAuditedErrorSize = 4 * 1024
+
+const redacted = "[REDACTED]"
+
+// auditRecordTimeout bounds the time writing a record to the sinks may take once the request is answered.
+const auditRecordTimeout = 5 * time.Second
+
+// auditedActions are the actions that change the docker host, or run processes in it, and are therefore recorded.
+var auditedActions = map[Action]bool{
+	ActionCreate:    true,
+	ActionDelete:    true,
+	ActionOperate:   true,
+	ActionExec:      true,
+	ActionImagePull: true,
+	ActionPrune:     true,
+}
+
+// sensitiveParameter matches the names of parameters whose values are never recorded.
+var sensitiveParameter = regexp.MustCompile(`(?i)(pass|secret|token|credential|private|api[-_]?key|auth)`)
+
+// environmentParameter matches the parameters holding environment variables. Their names are recorded, their values are not.
+var environmentParameter = regexp.MustCompile(`(?i)^env(ironment)?$`)
+
+// digestedParameters are recorded as their SHA-256 digest. A compose file embeds environment values of its own.
+var digestedParameters = map[string]bool{"compose": true}
+
+// targetParameters name the resource of the routes that have no id in their path, in order of preference.
+var targetParameters = []string{"containerName", "imageName", "image", "name"}
+
+// Audit records the operations that change the docker host, whether they succeed, fail or are denied, to the audit log.
+// It has to run before Authenticate and Authorize so that rejected requests are recorded too.
+func Audit(auditLog audit.AuditLog) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		route, ok := routeActions[ctx.Request.Method+" "+ctx.FullPath()]
+
+		if !ok || !auditedActions[route.action] {
+			ctx.Next()
+			return
+		}
+
+		startTime := time.Now()
+		body := captureBody(ctx.Request)
+		recorder := &errorRecorder{ResponseWriter: ctx.Writer}
+		ctx.Writer = recorder
+
+		ctx.Next()
+
+		parameters := auditedParameters(ctx, body)
+		record := audit.Record{
+			Time:       startTime.UTC(),
+			RequestId:  GetRequestId(ctx),
+			SourceIp:   ctx.ClientIP(),
+			Method:     ctx.Request.Method,
+			Endpoint:   ctx.FullPath(),
+			Path:       ctx.Request.URL.Path,
+			Action:     string(route.action),
+			Target:     auditedTarget(ctx, parameters),
+			Parameters: parameters,
+			Status:     recorder.Status(),
+			Outcome:    auditOutcome(recorder.Status()),
+			DurationMs: time.Since(startTime).Milliseconds(),
+		}
+
+		if principal, ok := GetPrincipal(ctx); ok {
+			record.Subject = principal.Subject
+			record.AuthMethod = principal.Method
+		}
+
+		var errorResponse models.ErrorResponse
+
+		if json.Unmarshal(recorder.body.Bytes(), &errorResponse) == nil {
+			record.ErrorCode = errorResponse.Code
+			record.Error = errorResponse.Message
+		}
+
+		// The request context is cancelled when the client goes away, which must not cost the record.
+		recordCtx, cancel := context.WithTimeout(detachedContext{Context: ctx.Request.Context()}, auditRecordTimeout)
+		defer cancel()
+
+		auditLog.Record(recordCtx, record)
+	}
+}
+
+// detachedContext keeps the values of its parent, such as the request logger and the trace, but not its cancellation.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+// errorRecorder keeps the start of error responses for the audit record. Successful responses are not copied.
+type errorRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (er *errorRecorder) Write(data []byte) (int, error) {
+	er.record(data)
+	return er.ResponseWriter.Write(data)
+}
+
+func (er *errorRecorder) WriteString(data string) (int, error) {
+	er.record([]byte(data))
+	return er.ResponseWriter.WriteString(data)
+}
+
+func (er *errorRecorder) record(data []byte) {
+	if er.Status() < http.StatusBadRequest || er.body.Len() >= maxAuditedErrorSize {
+		return
+	}
+
+	if remaining := maxAuditedErrorSize - er.body.Len(); len(data) > remaining {
+		data = data[:remaining]
+	}
+
+	er.body.Write(data)
+}
+
+// captureBody reads the start of the request body and puts it back in front of the rest, so the handler reads it unchanged.
+func captureBody(request *http.Request) []byte {
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(request.Body, maxAuditedBodySize))
+	request.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), request.Body), Closer: request.Body}
+
+	return body
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// auditedParameters merges the query and the JSON body of the request, with the secrets redacted.
+// Bodies that are not JSON objects, or are too large to be recorded whole, are left out.
+func auditedParameters(ctx *gin.Context, body []byte) map[string]interface{} {
+	parameters := map[string]interface{}{}
+
+	for name, values := range ctx.Request.URL.Query() {
+		if len(values) == 1 {
+			parameters[name] = values[0]
+		} else {
+			parameters[name] = values
+		}
+	}
+
+	var bodyParameters map[string]interface{}
+
+	if len(body) < maxAuditedBodySize && json.Unmarshal(body, &bodyParameters) == nil {
+		for name, value := range bodyParameters {
+			parameters[name] = value
+		}
+	}
+
+	if len(parameters) == 0 {
+		return nil
+	}
+
+	return redact(parameters).(map[string]interface{})
+}
+
+// redact replaces the values of sensitive parameters, at any depth, with a placeholder.
+func redact(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(typed))
+
+		for name, nested := range typed {
+			switch {
+			case sensitiveParameter.MatchString(name):
+				result[name] = redacted
+			case environmentParameter.MatchString(name):
+				result[name] = redactEnvironment(nested)
+			case digestedParameters[name]:
+				result[name] = digest(nested)
+			default:
+				result[name] = redact(nested)
+			}
+		}
+
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(typed))
+
+		for i, nested := range typed {
+			result[i] = redact(nested)
+		}
+
+		return result
+	default:
+		return value
+	}
+}
+
+// redactEnvironment keeps the variable names of a map or of a list of NAME=value entries.
+func redactEnvironment(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(typed))
+
+		for name := range typed {
+			result[name] = redacted
+		}
+
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(typed))
+
+		for i, entry := range typed {
+			name, ok := entry.(string)
+
+			if !ok {
+				result[i] = redacted
+				continue
+			}
+
+			result[i] = strings.SplitN(name, "=", 2)[0] + "=" + redacted
+		}
+
+		return result
+	default:
+		return redacted
+	}
+}
+
+func digest(value interface{}) interface{} {
+	text, ok := value.(string)
+
+	if !ok {
+		return redacted
+	}
+
+	sum := sha256.Sum256([]byte(text))
+
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func auditedTarget(ctx *gin.Context, parameters map[string]interface{}) string {
+	for _, param := range []string{"id", "name"} {
+		if value := ctx.Param(param); value != "" {
+			return value
+		}
+	}
+
+	for _, name := range targetParameters {
+		if value, ok := parameters[name].(string); ok && value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+func auditOutcome(status int) string {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return audit.OutcomeDenied
+	case status >= http.StatusBadRequest:
+		return audit.OutcomeFailure
+	default:
+		return audit.OutcomeSuccess
+	}
+}
@@ -0,0 +1,153 @@
+package middlewares
+
+import (
+	"context"
+	"godopi/internal/app/api/models"
+	"godopi/internal/pkg/audit"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gotest.tools/v3/assert"
+)
+
+// serveAudited sends a request through RequestId, Audit and Authenticate to a handler that answers with status,
+// and returns the records written to a file sink along with the body seen by the handler.
+func serveAudited(t *testing.T, method string, route string, target string, body string, headers map[string]string, status int) ([]audit.Record, string) {
+	fileSink, err := audit.NewFileSink(filepath.Join(t.TempDir(), "audit.jsonl"))
+	assert.NilError(t, err)
+
+	authenticate, err := Authenticate(AuthOptions{ApiKeys: testApiKeyEntry("ops", "first-key")})
+	assert.NilError(t, err)
+
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	var handlerBody []byte
+
+	e.Use(RequestId())
+	e.Handle(method, route, Audit(audit.NewAuditLog(fileSink)), authenticate, func(ctx *gin.Context) {
+		handlerBody, _ = io.ReadAll(ctx.Request.Body)
+
+		if status >= http.StatusBadRequest {
+			ctx.AbortWithStatusJSON(status, models.ErrorResponse{Code: models.ErrorCodeNotFound, Message: "Error deleting container!"})
+			return
+		}
+
+		ctx.Status(status)
+	})
+
+	c.Request, _ = http.NewRequestWithContext(c, method, target, strings.NewReader(body))
+	c.Request.Header.Set(REQUEST_ID_HEADER, "req-42")
+
+	for name, value := range headers {
+		c.Request.Header.Set(name, value)
+	}
+
+	e.ServeHTTP(w, c.Request)
+
+	records, err := fileSink.Query(context.Background(), audit.Filter{})
+	assert.NilError(t, err)
+
+	return records, string(handlerBody)
+}
+
+func TestAuditRecordsOperationWithRedactedParameters(t *testing.T) {
+	body := `{"imageName":"nginx","containerName":"web","env":{"DB_PASSWORD":"hunter2"},"labels":{"team":"shop"},"registryAuth":"c2VjcmV0"}`
+
+	records, handlerBody := serveAudited(t, http.MethodPost, "/api/v1/docker/containers", "/api/v1/docker/containers?pull=true", body,
+		map[string]string{API_KEY_HEADER: "first-key"}, http.StatusCreated)
+
+	assert.Equal(t, body, handlerBody)
+	assert.Equal(t, 1, len(records))
+
+	record := records[0]
+
+	assert.Equal(t, "req-42", record.RequestId)
+	assert.Equal(t, "ops", record.Subject)
+	assert.Equal(t, AuthMethodApiKey, record.AuthMethod)
+	assert.Equal(t, "/api/v1/docker/containers", record.Endpoint)
+	assert.Equal(t, string(ActionCreate), record.Action)
+	assert.Equal(t, "web", record.Target)
+	assert.Equal(t, http.StatusCreated, record.Status)
+	assert.Equal(t, audit.OutcomeSuccess, record.Outcome)
+	assert.DeepEqual(t, map[string]interface{}{
+		"pull":          "true",
+		"imageName":     "nginx",
+		"containerName": "web",
+		"env":           map[string]interface{}{"DB_PASSWORD": redacted},
+		"labels":        map[string]interface{}{"team": "shop"},
+		"registryAuth":  redacted,
+	}, record.Parameters)
+}
+
+func TestAuditRecordsDeniedAndFailedOperations(t *testing.T) {
+	records, _ := serveAudited(t, http.MethodDelete, "/api/v1/docker/containers/:id", "/api/v1/docker/containers/web", "", nil, http.StatusOK)
+
+	assert.Equal(t, 1, len(records))
+	assert.Equal(t, "", records[0].Subject)
+	assert.Equal(t, "web", records[0].Target)
+	assert.Equal(t, http.StatusUnauthorized, records[0].Status)
+	assert.Equal(t, audit.OutcomeDenied, records[0].Outcome)
+	assert.Equal(t, models.ErrorCodeUnauthorized, records[0].ErrorCode)
+
+	records, _ = serveAudited(t, http.MethodDelete, "/api/v1/docker/containers/:id", "/api/v1/docker/containers/web", "",
+		map[string]string{API_KEY_HEADER: "first-key"}, http.StatusNotFound)
+
+	assert.Equal(t, 1, len(records))
+	assert.Equal(t, audit.OutcomeFailure, records[0].Outcome)
+	assert.Equal(t, models.ErrorCodeNotFound, records[0].ErrorCode)
+	assert.Equal(t, "Error deleting container!", records[0].Error)
+}
+
+func TestAuditDigestsComposeFile(t *testing.T) {
+	body := `{"name":"shop","compose":"services:\n  db:\n    image: postgres\n    environment:\n      - POSTGRES_PASSWORD=hunter2\n"}`
+
+	records, _ := serveAudited(t, http.MethodPost, "/api/v1/stacks", "/api/v1/stacks", body, map[string]string{API_KEY_HEADER: "first-key"}, http.StatusCreated)
+
+	assert.Equal(t, 1, len(records))
+	assert.Equal(t, "shop", records[0].Target)
+	assert.Assert(t, strings.HasPrefix(records[0].Parameters["compose"].(string), "sha256:"))
+}
+
+func TestAuditSkipsReads(t *testing.T) {
+	records, _ := serveAudited(t, http.MethodGet, "/api/v1/docker/containers/:id", "/api/v1/docker/containers/web", "",
+		map[string]string{API_KEY_HEADER: "first-key"}, http.StatusOK)
+
+	assert.Equal(t, 0, len(records))
+}
+
+// contextSink keeps the error of the context each record is written with.
+type contextSink struct {
+	errs []error
+}
+
+func (cs *contextSink) Write(ctx context.Context, record audit.Record) error {
+	cs.errs = append(cs.errs, ctx.Err())
+	return nil
+}
+
+func TestAuditRecordsAfterClientIsGone(t *testing.T) {
+	sink := &contextSink{}
+
+	w := httptest.NewRecorder()
+	_, e := gin.CreateTestContext(w)
+
+	requestCtx, cancel := context.WithCancel(context.Background())
+
+	e.DELETE("/api/v1/docker/containers/:id", Audit(audit.NewAuditLog(sink)), func(ctx *gin.Context) {
+		// The client disconnects while the container is being deleted.
+		cancel()
+		ctx.Status(http.StatusOK)
+	})
+
+	request, _ := http.NewRequestWithContext(requestCtx, http.MethodDelete, "/api/v1/docker/containers/web", nil)
+	e.ServeHTTP(w, request)
+
+	assert.Equal(t, 1, len(sink.errs))
+	assert.NilError(t, sink.errs[0])
+}
@@ -0,0 +1,266 @@
+package middlewares
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/hex"
+	"godopi/internal/app/api/models"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	. "godopi/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const API_KEY_HEADER string = "X-API-Key"
+
+const principalKey = "Principal"
+
+const (
+	clientCertSubjectCommonName        = "cn"
+	clientCertSubjectDistinguishedName = "dn"
+)
+
+const (
+	AuthMethodApiKey     = "api-key"
+	AuthMethodJwt        = "jwt"
+	AuthMethodClientCert = "client-cert"
+)
+
+// AuthOptions are the raw auth settings from the config.
+// ApiKeys is a comma separated list of name:hash entries where hash is the hex encoded SHA-256 of the key.
+// ClientCertSubject maps verified TLS client certificates to a subject: cn takes the common name, dn the whole
+// distinguished name, and an empty value does not accept client certificates.
+type AuthOptions struct {
+	ApiKeys           string
+	JwtHmacSecret     string
+	JwtPublicKeyFile  string
+	JwtIssuer         string
+	JwtAudience       string
+	ClientCertSubject string
+}
+
+// Principal is the authenticated caller of a request. Subject is the API key name or the sub claim of the token.
+type Principal struct {
+	Subject string
+	Method  string
+	Claims  jwt.MapClaims
+}
+
+type authenticator struct {
+	apiKeys           map[string]string
+	hmacSecret        []byte
+	rsaPublicKey      *rsa.PublicKey
+	issuer            string
+	audience          string
+	clientCertSubject string
+}
+
+// Authenticate rejects the requests that carry neither a known API key in the X-API-Key header,
+// nor a valid JWT bearer token in the Authorization header, nor a TLS client certificate verified by the server,
+// and stores the principal of the others. Explicit credentials take precedence over the client certificate.
+// Tokens are only accepted with the signature family of a configured key, HS* with the secret and RS* with the public key.
+func Authenticate(options AuthOptions) (gin.HandlerFunc, error) {
+	auth, err := newAuthenticator(options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(auth.apiKeys) == 0 && auth.hmacSecret == nil && auth.rsaPublicKey == nil && auth.clientCertSubject == "" {
+		Logger().Warn("No API key, JWT key or TLS client CA is configured, every authenticated request will be rejected")
+	}
+
+	return func(ctx *gin.Context) {
+		principal, err := auth.authenticate(ctx.Request)
+
+		if err != nil {
+			LoggerFromContext(ctx.Request.Context()).Warn("Request is not authenticated", zap.String("Path", ctx.FullPath()), zap.String("ClientIp", ctx.ClientIP()), zap.Error(err))
+
+			ctx.Header("WWW-Authenticate", `Bearer realm="godopi"`)
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+				Code:      models.ErrorCodeUnauthorized,
+				Message:   "Authentication required!",
+				RequestId: GetRequestId(ctx),
+			})
+			return
+		}
+
+		ctx.Set(principalKey, principal)
+
+		requestLogger := LoggerFromContext(ctx.Request.Context()).With(zap.String("Principal", principal.Subject))
+		ctx.Request = ctx.Request.WithContext(WithLogger(ctx.Request.Context(), requestLogger))
+
+		ctx.Next()
+	}, nil
+}
+
+// GetPrincipal returns the principal stored by Authenticate, and false when the request was not authenticated.
+func GetPrincipal(ctx *gin.Context) (Principal, bool) {
+	principal, ok := ctx.Get(principalKey)
+
+	if !ok {
+		return Principal{}, false
+	}
+
+	return principal.(Principal), true
+}
+
+func newAuthenticator(options AuthOptions) (authenticator, error) {
+	auth := authenticator{apiKeys: map[string]string{}, issuer: options.JwtIssuer, audience: options.JwtAudience, clientCertSubject: options.ClientCertSubject}
+
+	switch options.ClientCertSubject {
+	case "", clientCertSubjectCommonName, clientCertSubjectDistinguishedName:
+	default:
+		return authenticator{}, errors.Errorf("client certificate subject must be cn or dn. ClientCertSubject:%s", options.ClientCertSubject)
+	}
+
+	for _, entry := range strings.Split(options.ApiKeys, ",") {
+		entry = strings.TrimSpace(entry)
+
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 2)
+
+		if len(parts) != 2 || parts[0] == "" {
+			return authenticator{}, errors.Errorf("api key must be given as name:sha256. Entry:%s", entry)
+		}
+
+		hash, err := hex.DecodeString(parts[1])
+
+		if err != nil || len(hash) != sha256.Size {
+			return authenticator{}, errors.Errorf("api key hash must be a hex encoded SHA-256. Name:%s", parts[0])
+		}
+
+		auth.apiKeys[string(hash)] = parts[0]
+	}
+
+	if options.JwtHmacSecret != "" {
+		auth.hmacSecret = []byte(options.JwtHmacSecret)
+	}
+
+	if options.JwtPublicKeyFile != "" {
+		pem, err := os.ReadFile(options.JwtPublicKeyFile)
+
+		if err != nil {
+			return authenticator{}, errors.Wrapf(err, "there is an error while reading the JWT public key. File:%s", options.JwtPublicKeyFile)
+		}
+
+		if auth.rsaPublicKey, err = jwt.ParseRSAPublicKeyFromPEM(pem); err != nil {
+			return authenticator{}, errors.Wrapf(err, "there is an error while parsing the JWT public key. File:%s", options.JwtPublicKeyFile)
+		}
+	}
+
+	return auth, nil
+}
+
+func (a authenticator) authenticate(request *http.Request) (Principal, error) {
+	if apiKey := request.Header.Get(API_KEY_HEADER); apiKey != "" {
+		return a.authenticateApiKey(apiKey)
+	}
+
+	authorization := request.Header.Get("Authorization")
+
+	if len(authorization) > len("Bearer ") && strings.EqualFold(authorization[:len("Bearer ")], "Bearer ") {
+		return a.authenticateJwt(authorization[len("Bearer "):])
+	}
+
+	if a.clientCertSubject != "" && request.TLS != nil && len(request.TLS.VerifiedChains) > 0 {
+		return a.authenticateClientCert(request.TLS.VerifiedChains[0][0])
+	}
+
+	return Principal{}, errors.New("request has no credentials")
+}
+
+// authenticateClientCert takes the subject from a certificate the TLS handshake has already verified against the client CA.
+func (a authenticator) authenticateClientCert(certificate *x509.Certificate) (Principal, error) {
+	subject := certificate.Subject.CommonName
+
+	if a.clientCertSubject == clientCertSubjectDistinguishedName {
+		subject = certificate.Subject.String()
+	}
+
+	if subject == "" {
+		return Principal{}, errors.New("client certificate has no subject")
+	}
+
+	return Principal{Subject: subject, Method: AuthMethodClientCert}, nil
+}
+
+// authenticateApiKey compares the hash of the key against every configured hash in constant time.
+func (a authenticator) authenticateApiKey(apiKey string) (Principal, error) {
+	hash := sha256.Sum256([]byte(apiKey))
+	name := ""
+
+	for knownHash, knownName := range a.apiKeys {
+		if subtle.ConstantTimeCompare(hash[:], []byte(knownHash)) == 1 {
+			name = knownName
+		}
+	}
+
+	if name == "" {
+		return Principal{}, errors.New("api key is not known")
+	}
+
+	return Principal{Subject: name, Method: AuthMethodApiKey}, nil
+}
+
+func (a authenticator) authenticateJwt(tokenString string) (Principal, error) {
+	var validMethods []string
+
+	if a.hmacSecret != nil {
+		validMethods = append(validMethods, "HS256", "HS384", "HS512")
+	}
+
+	if a.rsaPublicKey != nil {
+		validMethods = append(validMethods, "RS256", "RS384", "RS512")
+	}
+
+	if len(validMethods) == 0 {
+		return Principal{}, errors.New("bearer tokens are not accepted as no JWT key is configured")
+	}
+
+	claims := jwt.MapClaims{}
+
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+			return a.hmacSecret, nil
+		}
+
+		return a.rsaPublicKey, nil
+	}, jwt.WithValidMethods(validMethods))
+
+	if err != nil {
+		return Principal{}, errors.Wrap(err, "token is not valid")
+	}
+
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return Principal{}, errors.New("token has no expiry")
+	}
+
+	if a.issuer != "" && !claims.VerifyIssuer(a.issuer, true) {
+		return Principal{}, errors.Errorf("token issuer is not accepted. Issuer:%v", claims["iss"])
+	}
+
+	if a.audience != "" && !claims.VerifyAudience(a.audience, true) {
+		return Principal{}, errors.Errorf("token audience is not accepted. Audience:%v", claims["aud"])
+	}
+
+	subject, _ := claims["sub"].(string)
+
+	if subject == "" {
+		return Principal{}, errors.New("token has no subject")
+	}
+
+	return Principal{Subject: subject, Method: AuthMethodJwt, Claims: claims}, nil
+}
@@ -0,0 +1,222 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"gotest.tools/v3/assert"
+)
+
+const testHmacSecret = "5f0c3a2d8e7b4c1f9a6d2e8b7c4f1a3d"
+
+func testApiKeyEntry(name string, key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return name + ":" + hex.EncodeToString(hash[:])
+}
+
+func testToken(t *testing.T, method jwt.SigningMethod, key interface{}, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	assert.NilError(t, err)
+	return token
+}
+
+func testClaims() jwt.MapClaims {
+	return jwt.MapClaims{"sub": "ci-deployer", "iss": "https://auth.example.com", "aud": "godopi", "exp": time.Now().Add(time.Hour).Unix()}
+}
+
+// serveAuthenticated sends a request with the given headers through Authenticate and returns the response and the principal seen by the handler.
+func serveAuthenticated(t *testing.T, options AuthOptions, headers map[string]string) (*httptest.ResponseRecorder, Principal) {
+	authenticate, err := Authenticate(options)
+	assert.NilError(t, err)
+
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	var principal Principal
+
+	e.GET("/", authenticate, func(ctx *gin.Context) {
+		principal, _ = GetPrincipal(ctx)
+		ctx.Status(http.StatusOK)
+	})
+
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/", nil)
+
+	for name, value := range headers {
+		c.Request.Header.Set(name, value)
+	}
+
+	e.ServeHTTP(w, c.Request)
+
+	return w, principal
+}
+
+func TestAuthenticateApiKey(t *testing.T) {
+	options := AuthOptions{ApiKeys: testApiKeyEntry("ops", "first-key") + ", " + testApiKeyEntry("ci", "second-key")}
+
+	w, principal := serveAuthenticated(t, options, map[string]string{API_KEY_HEADER: "second-key"})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.DeepEqual(t, Principal{Subject: "ci", Method: AuthMethodApiKey}, principal)
+
+	w, _ = serveAuthenticated(t, options, map[string]string{API_KEY_HEADER: "third-key"})
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer realm="godopi"`, w.Header().Get("WWW-Authenticate"))
+}
+
+func TestAuthenticateErrorNoCredentials(t *testing.T) {
+	w, _ := serveAuthenticated(t, AuthOptions{ApiKeys: testApiKeyEntry("ops", "first-key"), JwtHmacSecret: testHmacSecret}, nil)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthenticateHmacJwt(t *testing.T) {
+	options := AuthOptions{JwtHmacSecret: testHmacSecret, JwtIssuer: "https://auth.example.com", JwtAudience: "godopi"}
+
+	token := testToken(t, jwt.SigningMethodHS256, []byte(testHmacSecret), testClaims())
+	w, principal := serveAuthenticated(t, options, map[string]string{"Authorization": "Bearer " + token})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ci-deployer", principal.Subject)
+	assert.Equal(t, AuthMethodJwt, principal.Method)
+}
+
+func TestAuthenticateErrorInvalidJwt(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NilError(t, err)
+
+	options := AuthOptions{JwtHmacSecret: testHmacSecret, JwtIssuer: "https://auth.example.com", JwtAudience: "godopi"}
+
+	withClaim := func(name string, value interface{}) jwt.MapClaims {
+		claims := testClaims()
+
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+
+		return claims
+	}
+
+	for name, token := range map[string]string{
+		"wrong secret":        testToken(t, jwt.SigningMethodHS256, []byte("another-secret"), testClaims()),
+		"rsa without rsa key": testToken(t, jwt.SigningMethodRS256, rsaKey, testClaims()),
+		"expired":             testToken(t, jwt.SigningMethodHS256, []byte(testHmacSecret), withClaim("exp", time.Now().Add(-time.Minute).Unix())),
+		"no expiry":           testToken(t, jwt.SigningMethodHS256, []byte(testHmacSecret), withClaim("exp", nil)),
+		"other issuer":        testToken(t, jwt.SigningMethodHS256, []byte(testHmacSecret), withClaim("iss", "https://evil.example.com")),
+		"other audience":      testToken(t, jwt.SigningMethodHS256, []byte(testHmacSecret), withClaim("aud", "registry")),
+		"no subject":          testToken(t, jwt.SigningMethodHS256, []byte(testHmacSecret), withClaim("sub", nil)),
+		"unsigned":            testToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, testClaims()),
+		"not a token":         "not-a-token",
+	} {
+		t.Run(name, func(t *testing.T) {
+			w, _ := serveAuthenticated(t, options, map[string]string{"Authorization": "Bearer " + token})
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		})
+	}
+}
+
+func TestAuthenticateRsaJwt(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NilError(t, err)
+
+	publicKey, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	assert.NilError(t, err)
+
+	publicKeyFile := filepath.Join(t.TempDir(), "jwt.pem")
+	assert.NilError(t, os.WriteFile(publicKeyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}), 0600))
+
+	options := AuthOptions{JwtPublicKeyFile: publicKeyFile}
+
+	token := testToken(t, jwt.SigningMethodRS256, rsaKey, testClaims())
+	w, principal := serveAuthenticated(t, options, map[string]string{"Authorization": "bearer " + token})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ci-deployer", principal.Subject)
+
+	// The public key must not be usable as an HMAC secret.
+	pemKey, _ := os.ReadFile(publicKeyFile)
+	token = testToken(t, jwt.SigningMethodHS256, pemKey, testClaims())
+	w, _ = serveAuthenticated(t, options, map[string]string{"Authorization": "Bearer " + token})
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthenticateErrorInvalidOptions(t *testing.T) {
+	for name, options := range map[string]AuthOptions{
+		"api key without name": {ApiKeys: "0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0"},
+		"api key hash not hex": {ApiKeys: "ops:first-key"},
+		"missing public key":   {JwtPublicKeyFile: filepath.Join(t.TempDir(), "missing.pem")},
+		"unknown cert subject": {ClientCertSubject: "email"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Authenticate(options)
+
+			assert.Assert(t, err != nil)
+		})
+	}
+}
+
+func TestAuthenticateClientCert(t *testing.T) {
+	certificate := &x509.Certificate{Subject: pkix.Name{CommonName: "build-agent", Organization: []string{"Acme"}}}
+
+	serve := func(options AuthOptions, headers map[string]string) (*httptest.ResponseRecorder, Principal) {
+		authenticate, err := Authenticate(options)
+		assert.NilError(t, err)
+
+		w := httptest.NewRecorder()
+		c, e := gin.CreateTestContext(w)
+
+		var principal Principal
+
+		e.GET("/", authenticate, func(ctx *gin.Context) {
+			principal, _ = GetPrincipal(ctx)
+			ctx.Status(http.StatusOK)
+		})
+
+		c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/", nil)
+		c.Request.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{certificate}}}
+
+		for name, value := range headers {
+			c.Request.Header.Set(name, value)
+		}
+
+		e.ServeHTTP(w, c.Request)
+
+		return w, principal
+	}
+
+	w, principal := serve(AuthOptions{ClientCertSubject: "cn"}, nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.DeepEqual(t, Principal{Subject: "build-agent", Method: AuthMethodClientCert}, principal)
+
+	_, principal = serve(AuthOptions{ClientCertSubject: "dn"}, nil)
+
+	assert.Equal(t, "CN=build-agent,O=Acme", principal.Subject)
+
+	// An API key takes precedence over the certificate.
+	_, principal = serve(AuthOptions{ApiKeys: testApiKeyEntry("ops", "first-key"), ClientCertSubject: "cn"}, map[string]string{API_KEY_HEADER: "first-key"})
+
+	assert.Equal(t, "ops", principal.Subject)
+
+	w, _ = serve(AuthOptions{}, nil)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,52 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/v3/assert"
+)
+
+func serveMetrics(e *gin.Engine, method string, target string) {
+	w := httptest.NewRecorder()
+	request, _ := http.NewRequest(method, target, nil)
+	e.ServeHTTP(w, request)
+}
+
+func TestMetricsCountsRequestsPerRoute(t *testing.T) {
+	_, e := gin.CreateTestContext(httptest.NewRecorder())
+
+	e.Use(Metrics(), gin.Recovery())
+	e.GET("/containers/:id", func(ctx *gin.Context) {
+		if ctx.Param("id") == "missing" {
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+
+		ctx.Status(http.StatusOK)
+	})
+	e.GET("/panic", func(ctx *gin.Context) {
+		panic("broken handler")
+	})
+
+	found := httpRequests.WithLabelValues(http.MethodGet, "/containers/:id", "200")
+	missing := httpRequests.WithLabelValues(http.MethodGet, "/containers/:id", "404")
+	panicked := httpRequests.WithLabelValues(http.MethodGet, "/panic", "500")
+	unmatched := httpRequests.WithLabelValues(http.MethodGet, unmatchedRoute, "404")
+
+	before := []float64{testutil.ToFloat64(found), testutil.ToFloat64(missing), testutil.ToFloat64(panicked), testutil.ToFloat64(unmatched)}
+
+	serveMetrics(e, http.MethodGet, "/containers/web")
+	serveMetrics(e, http.MethodGet, "/containers/db")
+	serveMetrics(e, http.MethodGet, "/containers/missing")
+	serveMetrics(e, http.MethodGet, "/panic")
+	serveMetrics(e, http.MethodGet, "/no/such/route")
+
+	assert.Equal(t, before[0]+2, testutil.ToFloat64(found))
+	assert.Equal(t, before[1]+1, testutil.ToFloat64(missing))
+	assert.Equal(t, before[2]+1, testutil.ToFloat64(panicked))
+	assert.Equal(t, before[3]+1, testutil.ToFloat64(unmatched))
+}
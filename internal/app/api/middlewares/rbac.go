@@ -0,0 +1,384 @@
+package middlewares
+
+import (
+	"godopi/internal/app/api/models"
+	"godopi/internal/pkg/docker"
+	"net/http"
+	"os"
+	"strings"
+
+	. "godopi/internal/pkg/logger"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+)
+
+type Action string
+
+const (
+	ActionList      Action = "list"
+	ActionInspect   Action = "inspect"
+	ActionCreate    Action = "create"
+	ActionDelete    Action = "delete"
+	ActionOperate   Action = "operate"
+	ActionExec      Action = "exec"
+	ActionImagePull Action = "image-pull"
+	ActionPrune     Action = "prune"
+	ActionAudit     Action = "audit"
+)
+
+// actionWildcard grants every action to a role.
+const actionWildcard Action = "*"
+
+// subjectWildcard binds a role to every principal that has no binding of its own.
+const subjectWildcard = "*"
+
+const scopeKey = "Scope"
+
+var actions = []Action{ActionList, ActionInspect, ActionCreate, ActionDelete, ActionOperate, ActionExec, ActionImagePull, ActionPrune, ActionAudit}
+
+// defaultRoles are the roles every policy knows. A policy file may redefine them or add its own.
+var defaultRoles = map[string][]Action{
+	"viewer":   {ActionList, ActionInspect},
+	"operator": {ActionList, ActionInspect, ActionCreate, ActionDelete, ActionOperate, ActionExec, ActionImagePull},
+	"admin":    {actionWildcard},
+}
+
+// resourceKind tells how the scope of a principal is checked for a route.
+type resourceKind int
+
+const (
+	// resourceOther routes act on images, volumes, networks or jobs.
+	// Scoped principals may read them but not change them, and JobController only shows them the jobs they started.
+	resourceOther resourceKind = iota
+	// resourceContainer routes act on the container named by the id parameter.
+	resourceContainer
+	// resourceExec routes act on the container of the exec instance named by the id parameter.
+	resourceExec
+	// resourceContainers routes list or create containers. Their controllers apply the scope stored by Authorize.
+	resourceContainers
+	// resourceHost routes report on or act on many containers of the host at once, like the stats, the events and the stacks.
+	// Scoped principals may not use them.
+	resourceHost
+)
+
+type routeAction struct {
+	action   Action
+	resource resourceKind
+}
+
+// routeActions maps every protected route to the action it performs. Routes missing from the map are denied.
+var routeActions = map[string]routeAction{
+	"GET /api/v1/docker/containers":               {ActionList, resourceContainers},
+	"GET /api/v1/docker/containers/:id":           {ActionInspect, resourceContainer},
+	"POST /api/v1/docker/containers":              {ActionCreate, resourceContainers},
+	"DELETE /api/v1/docker/containers/:id":        {ActionDelete, resourceContainer},
+	"POST /api/v1/docker/containers/:id/start":    {ActionOperate, resourceContainer},
+	"POST /api/v1/docker/containers/:id/stop":     {ActionOperate, resourceContainer},
+	"POST /api/v1/docker/containers/:id/restart":  {ActionOperate, resourceContainer},
+	"POST /api/v1/docker/containers/:id/pause":    {ActionOperate, resourceContainer},
+	"POST /api/v1/docker/containers/:id/unpause":  {ActionOperate, resourceContainer},
+	"POST /api/v1/docker/containers/:id/kill":     {ActionOperate, resourceContainer},
+	"GET /api/v1/docker/containers/:id/logs":      {ActionInspect, resourceContainer},
+	"GET /api/v1/docker/containers/:id/stats":     {ActionInspect, resourceContainer},
+	"GET /api/v1/docker/stats":                    {ActionInspect, resourceHost},
+	"GET /api/v1/docker/events":                   {ActionInspect, resourceHost},
+	"POST /api/v1/docker/containers/:id/exec":     {ActionExec, resourceContainer},
+	"GET /api/v1/docker/exec/:id":                 {ActionExec, resourceExec},
+	"GET /api/v1/docker/exec/:id/attach":          {ActionExec, resourceExec},
+	"POST /api/v1/docker/exec/:id/resize":         {ActionExec, resourceExec},
+	"GET /api/v1/docker/images":                   {ActionList, resourceOther},
+	"GET /api/v1/docker/images/:id":               {ActionInspect, resourceOther},
+	"GET /api/v1/docker/images/:id/history":       {ActionInspect, resourceOther},
+	"POST /api/v1/docker/images/pull":             {ActionImagePull, resourceOther},
+	"POST /api/v1/docker/images/prune":            {ActionPrune, resourceOther},
+	"POST /api/v1/docker/images/:id/tag":          {ActionCreate, resourceOther},
+	"DELETE /api/v1/docker/images/:id":            {ActionDelete, resourceOther},
+	"GET /api/v1/docker/volumes":                  {ActionList, resourceOther},
+	"GET /api/v1/docker/volumes/:name":            {ActionInspect, resourceOther},
+	"POST /api/v1/docker/volumes":                 {ActionCreate, resourceOther},
+	"POST /api/v1/docker/volumes/prune":           {ActionPrune, resourceOther},
+	"DELETE /api/v1/docker/volumes/:name":         {ActionDelete, resourceOther},
+	"GET /api/v1/docker/networks":                 {ActionList, resourceOther},
+	"GET /api/v1/docker/networks/:id":             {ActionInspect, resourceOther},
+	"POST /api/v1/docker/networks":                {ActionCreate, resourceOther},
+	"POST /api/v1/docker/networks/prune":          {ActionPrune, resourceOther},
+	"POST /api/v1/docker/networks/:id/connect":    {ActionOperate, resourceOther},
+	"POST /api/v1/docker/networks/:id/disconnect": {ActionOperate, resourceOther},
+	"DELETE /api/v1/docker/networks/:id":          {ActionDelete, resourceOther},
+	"GET /api/v1/stacks":                          {ActionList, resourceHost},
+	"GET /api/v1/stacks/:name":                    {ActionInspect, resourceHost},
+	"POST /api/v1/stacks":                         {ActionCreate, resourceHost},
+	"PUT /api/v1/stacks/:name":                    {ActionCreate, resourceHost},
+	"DELETE /api/v1/stacks/:name":                 {ActionDelete, resourceHost},
+	"GET /api/v1/jobs/:id":                        {ActionInspect, resourceOther},
+	"GET /api/v1/jobs/:id/stream":                 {ActionInspect, resourceOther},
+	"GET /api/v1/audit":                           {ActionAudit, resourceOther},
+}
+
+// Policy decides which actions a principal may perform. It is loaded from a YAML file like
+//
+//	roles:
+//	  deployer: [list, inspect, create, operate]
+//	bindings:
+//	  - subject: ops
+//	    role: admin
+//	  - subject: shop-ci
+//	    role: deployer
+//	    scope:
+//	      labels: [team=shop]
+//	      namePrefix: shop-
+type Policy struct {
+	Roles    map[string][]Action `yaml:"roles"`
+	Bindings []PolicyBinding     `yaml:"bindings"`
+}
+
+// PolicyBinding grants a role to the principal with the subject. A scope confines the principal to the containers it matches.
+type PolicyBinding struct {
+	Subject string `yaml:"subject"`
+	Role    string `yaml:"role"`
+	Scope   *Scope `yaml:"scope"`
+}
+
+// Scope matches the containers that have all of Labels, given as key or key=value, and whose name starts with NamePrefix.
+type Scope struct {
+	Labels     []string `yaml:"labels"`
+	NamePrefix string   `yaml:"namePrefix"`
+}
+
+// Allows reports whether a container with the name and labels is within the scope.
+func (s Scope) Allows(containerName string, labels map[string]string) bool {
+	if !strings.HasPrefix(strings.TrimPrefix(containerName, "/"), s.NamePrefix) {
+		return false
+	}
+
+	for _, label := range s.Labels {
+		parts := strings.SplitN(label, "=", 2)
+		value, ok := labels[parts[0]]
+
+		if !ok || (len(parts) == 2 && value != parts[1]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// LoadPolicy reads a policy file and checks that its bindings only use known roles and its roles only known actions.
+func LoadPolicy(policyFile string) (Policy, error) {
+	data, err := os.ReadFile(policyFile)
+
+	if err != nil {
+		return Policy{}, errors.Wrapf(err, "there is an error while reading the policy file. File:%s", policyFile)
+	}
+
+	var policy Policy
+
+	if err = yaml.UnmarshalStrict(data, &policy); err != nil {
+		return Policy{}, errors.Wrapf(err, "there is an error while parsing the policy file. File:%s", policyFile)
+	}
+
+	roles := map[string][]Action{}
+
+	for role, roleActions := range defaultRoles {
+		roles[role] = roleActions
+	}
+
+	for role, roleActions := range policy.Roles {
+		for _, action := range roleActions {
+			if !knownAction(action) {
+				return Policy{}, errors.Errorf("role has an unknown action. Role:%s Action:%s", role, action)
+			}
+		}
+
+		roles[role] = roleActions
+	}
+
+	subjects := map[string]bool{}
+
+	for _, binding := range policy.Bindings {
+		if binding.Subject == "" {
+			return Policy{}, errors.Errorf("binding has no subject. Role:%s", binding.Role)
+		}
+
+		if subjects[binding.Subject] {
+			return Policy{}, errors.Errorf("subject is bound more than once. Subject:%s", binding.Subject)
+		}
+
+		if _, ok := roles[binding.Role]; !ok {
+			return Policy{}, errors.Errorf("binding has an unknown role. Subject:%s Role:%s", binding.Subject, binding.Role)
+		}
+
+		subjects[binding.Subject] = true
+	}
+
+	policy.Roles = roles
+
+	return policy, nil
+}
+
+// Authorize rejects the requests whose principal is not allowed to perform the action of the route with 403.
+// It has to run after Authenticate. For container routes the container is looked up to check the scope of the principal.
+func Authorize(policy Policy, dockerClient docker.DockerClient) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		route, ok := routeActions[ctx.Request.Method+" "+ctx.FullPath()]
+
+		if !ok {
+			LoggerFromContext(ctx.Request.Context()).Error("Route has no action to authorize", zap.String("Method", ctx.Request.Method), zap.String("Path", ctx.FullPath()))
+			abortForbidden(ctx, "unknown", "route is not covered by the policy")
+			return
+		}
+
+		principal, _ := GetPrincipal(ctx)
+		binding, ok := policy.binding(principal.Subject)
+
+		if !ok {
+			abortForbidden(ctx, route.action, "principal has no role. Subject:"+principal.Subject)
+			return
+		}
+
+		if !policy.allows(binding.Role, route.action) {
+			abortForbidden(ctx, route.action, "role does not allow the action. Subject:"+principal.Subject+" Role:"+binding.Role)
+			return
+		}
+
+		if binding.Scope != nil {
+			allowed, err := scopeAllows(ctx, dockerClient, *binding.Scope, route)
+
+			if err != nil {
+				LoggerFromContext(ctx.Request.Context()).Error("Error checking the scope of a request", zap.String("Subject", principal.Subject), zap.Error(err))
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{
+					Code:      models.ErrorCodeInternal,
+					Message:   "Error authorizing request!",
+					Details:   err.Error(),
+					RequestId: GetRequestId(ctx),
+				})
+				return
+			}
+
+			if !allowed {
+				abortForbidden(ctx, route.action, "resource is outside of the scope of the principal. Subject:"+principal.Subject)
+				return
+			}
+
+			ctx.Set(scopeKey, *binding.Scope)
+		}
+
+		ctx.Next()
+	}
+}
+
+// HasAction reports whether a route is in the action map. Authorize denies the routes that are not.
+func HasAction(method string, path string) bool {
+	_, ok := routeActions[method+" "+path]
+	return ok
+}
+
+// GetScope returns the scope stored by Authorize, and false when the principal is not confined to a scope.
+func GetScope(ctx *gin.Context) (Scope, bool) {
+	scope, ok := ctx.Get(scopeKey)
+
+	if !ok {
+		return Scope{}, false
+	}
+
+	return scope.(Scope), true
+}
+
+func (p Policy) binding(subject string) (PolicyBinding, bool) {
+	var wildcard *PolicyBinding
+
+	for i, binding := range p.Bindings {
+		if binding.Subject == subject && subject != "" {
+			return binding, true
+		}
+
+		if binding.Subject == subjectWildcard {
+			wildcard = &p.Bindings[i]
+		}
+	}
+
+	if wildcard != nil {
+		return *wildcard, true
+	}
+
+	return PolicyBinding{}, false
+}
+
+func (p Policy) allows(role string, action Action) bool {
+	for _, roleAction := range p.Roles[role] {
+		if roleAction == action || roleAction == actionWildcard {
+			return true
+		}
+	}
+
+	return false
+}
+
+// scopeAllows checks a scoped principal against the resource of the route. Missing containers are left to the handler to report.
+func scopeAllows(ctx *gin.Context, dockerClient docker.DockerClient, scope Scope, route routeAction) (bool, error) {
+	switch route.resource {
+	case resourceContainers:
+		return true, nil
+	case resourceOther:
+		return route.action == ActionList || route.action == ActionInspect, nil
+	case resourceHost:
+		return false, nil
+	}
+
+	containerId := ctx.Param("id")
+
+	if route.resource == resourceExec {
+		execState, err := dockerClient.InspectExec(ctx.Request.Context(), containerId)
+
+		if errdefs.IsNotFound(err) {
+			return true, nil
+		}
+
+		if err != nil {
+			return false, errors.Wrapf(err, "there is an error while getting exec info. ExecId:%s", containerId)
+		}
+
+		containerId = execState.ContainerId
+	}
+
+	container, err := dockerClient.InspectContainer(ctx.Request.Context(), containerId)
+
+	if errdefs.IsNotFound(err) {
+		return true, nil
+	}
+
+	if err != nil {
+		return false, errors.Wrapf(err, "there is an error while getting detailed container info. ContainerId:%s", containerId)
+	}
+
+	return scope.Allows(container.Name, container.Config.Labels), nil
+}
+
+func knownAction(action Action) bool {
+	if action == actionWildcard {
+		return true
+	}
+
+	for _, knownAction := range actions {
+		if action == knownAction {
+			return true
+		}
+	}
+
+	return false
+}
+
+func abortForbidden(ctx *gin.Context, action Action, details string) {
+	LoggerFromContext(ctx.Request.Context()).Warn("Request is not authorized", zap.String("Action", string(action)), zap.String("Path", ctx.FullPath()), zap.String("Details", details))
+
+	ctx.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+		Code:      models.ErrorCodeForbidden,
+		Message:   "Action " + string(action) + " is not allowed!",
+		Details:   details,
+		RequestId: GetRequestId(ctx),
+	})
+}
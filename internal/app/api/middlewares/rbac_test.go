@@ -0,0 +1,244 @@
+package middlewares
+
+import (
+	"context"
+	"encoding/json"
+	"godopi/internal/app/api/models"
+	"godopi/internal/pkg/docker"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"gotest.tools/v3/assert"
+)
+
+const testPolicy = `
+roles:
+  deployer: [list, inspect, create, operate]
+bindings:
+  - subject: ops
+    role: admin
+  - subject: dashboard
+    role: viewer
+  - subject: shop-ci
+    role: deployer
+    scope:
+      labels: [team=shop]
+      namePrefix: shop-
+  - subject: shop-dashboard
+    role: viewer
+    scope:
+      labels: [team=shop]
+`
+
+// scopeDockerClient only answers the lookups Authorize makes to check scopes.
+type scopeDockerClient struct {
+	docker.DockerClient
+	containers map[string]docker.ContainerDetail
+	execs      map[string]docker.ExecState
+}
+
+func (sdc scopeDockerClient) InspectContainer(ctx context.Context, containerId string) (docker.ContainerDetail, error) {
+	container, ok := sdc.containers[containerId]
+
+	if !ok {
+		return docker.ContainerDetail{}, errdefs.NotFound(errors.Errorf("no such container: %s", containerId))
+	}
+
+	return container, nil
+}
+
+func (sdc scopeDockerClient) InspectExec(ctx context.Context, execId string) (docker.ExecState, error) {
+	execState, ok := sdc.execs[execId]
+
+	if !ok {
+		return docker.ExecState{}, errdefs.NotFound(errors.Errorf("no such exec: %s", execId))
+	}
+
+	return execState, nil
+}
+
+func testLoadPolicy(t *testing.T, content string) (Policy, error) {
+	policyFile := filepath.Join(t.TempDir(), "policy.yaml")
+	assert.NilError(t, os.WriteFile(policyFile, []byte(content), 0600))
+
+	return LoadPolicy(policyFile)
+}
+
+// serveAuthorized sends a request as the subject through Authorize to a handler registered on the route,
+// and returns the response and the scope seen by the handler.
+func serveAuthorized(t *testing.T, subject string, method string, route string, target string) (*httptest.ResponseRecorder, *Scope) {
+	policy, err := testLoadPolicy(t, testPolicy)
+	assert.NilError(t, err)
+
+	dockerClient := scopeDockerClient{
+		containers: map[string]docker.ContainerDetail{
+			"a1b2c3": {Name: "shop-api", Config: docker.ContainerConfig{Labels: map[string]string{"team": "shop"}}},
+			"d4e5f6": {Name: "billing-api", Config: docker.ContainerConfig{Labels: map[string]string{"team": "billing"}}},
+			"0a9b8c": {Name: "shop-legacy", Config: docker.ContainerConfig{Labels: map[string]string{}}},
+		},
+		execs: map[string]docker.ExecState{"e1": {ContainerId: "d4e5f6"}},
+	}
+
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	var scope *Scope
+
+	e.Handle(method, route, func(ctx *gin.Context) {
+		ctx.Set(principalKey, Principal{Subject: subject, Method: AuthMethodApiKey})
+	}, Authorize(policy, dockerClient), func(ctx *gin.Context) {
+		if s, ok := GetScope(ctx); ok {
+			scope = &s
+		}
+
+		ctx.Status(http.StatusOK)
+	})
+
+	c.Request, _ = http.NewRequestWithContext(c, method, target, nil)
+	e.ServeHTTP(w, c.Request)
+
+	return w, scope
+}
+
+func TestAuthorizeRoles(t *testing.T) {
+	for name, test := range map[string]struct {
+		subject string
+		method  string
+		route   string
+		status  int
+	}{
+		"viewer lists":          {"dashboard", http.MethodGet, "/api/v1/docker/containers", http.StatusOK},
+		"viewer creates":        {"dashboard", http.MethodPost, "/api/v1/docker/containers", http.StatusForbidden},
+		"viewer pulls":          {"dashboard", http.MethodPost, "/api/v1/docker/images/pull", http.StatusForbidden},
+		"admin prunes":          {"ops", http.MethodPost, "/api/v1/docker/images/prune", http.StatusOK},
+		"custom role operates":  {"shop-ci", http.MethodPost, "/api/v1/docker/containers", http.StatusOK},
+		"custom role deletes":   {"shop-ci", http.MethodDelete, "/api/v1/stacks/:name", http.StatusForbidden},
+		"unbound subject lists": {"intruder", http.MethodGet, "/api/v1/docker/containers", http.StatusForbidden},
+		"route without action":  {"ops", http.MethodGet, "/api/v1/docker/unknown", http.StatusForbidden},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w, _ := serveAuthorized(t, test.subject, test.method, test.route, test.route)
+
+			assert.Equal(t, test.status, w.Code)
+		})
+	}
+}
+
+func TestAuthorizeErrorBodyNamesAction(t *testing.T) {
+	w, _ := serveAuthorized(t, "dashboard", http.MethodDelete, "/api/v1/docker/containers/:id", "/api/v1/docker/containers/a1b2c3")
+
+	var errorResponse models.ErrorResponse
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &errorResponse))
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, models.ErrorCodeForbidden, errorResponse.Code)
+	assert.Equal(t, "Action delete is not allowed!", errorResponse.Message)
+}
+
+func TestAuthorizeScope(t *testing.T) {
+	for name, test := range map[string]struct {
+		method string
+		route  string
+		target string
+		status int
+	}{
+		"container in scope":        {http.MethodPost, "/api/v1/docker/containers/:id/stop", "/api/v1/docker/containers/a1b2c3/stop", http.StatusOK},
+		"container of another team": {http.MethodPost, "/api/v1/docker/containers/:id/stop", "/api/v1/docker/containers/d4e5f6/stop", http.StatusForbidden},
+		"container without label":   {http.MethodGet, "/api/v1/docker/containers/:id", "/api/v1/docker/containers/0a9b8c", http.StatusForbidden},
+		"missing container":         {http.MethodGet, "/api/v1/docker/containers/:id", "/api/v1/docker/containers/ffffff", http.StatusOK},
+		"network read":              {http.MethodGet, "/api/v1/docker/networks", "/api/v1/docker/networks", http.StatusOK},
+		"network change":            {http.MethodPost, "/api/v1/docker/networks", "/api/v1/docker/networks", http.StatusForbidden},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w, _ := serveAuthorized(t, "shop-ci", test.method, test.route, test.target)
+
+			assert.Equal(t, test.status, w.Code)
+		})
+	}
+}
+
+func TestAuthorizeScopedViewerHostRoutes(t *testing.T) {
+	for _, route := range []string{"/api/v1/docker/stats", "/api/v1/docker/events", "/api/v1/stacks", "/api/v1/stacks/:name"} {
+		w, _ := serveAuthorized(t, "shop-dashboard", http.MethodGet, route, route)
+		assert.Equal(t, http.StatusForbidden, w.Code, route)
+
+		w, _ = serveAuthorized(t, "dashboard", http.MethodGet, route, route)
+		assert.Equal(t, http.StatusOK, w.Code, route)
+	}
+
+	w, _ := serveAuthorized(t, "shop-dashboard", http.MethodGet, "/api/v1/docker/containers/:id", "/api/v1/docker/containers/a1b2c3")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAuthorizeScopeOfExec(t *testing.T) {
+	policy, err := testLoadPolicy(t, testPolicy)
+	assert.NilError(t, err)
+
+	policy.Roles["deployer"] = append(policy.Roles["deployer"], ActionExec)
+
+	dockerClient := scopeDockerClient{
+		containers: map[string]docker.ContainerDetail{"d4e5f6": {Name: "billing-api"}},
+		execs:      map[string]docker.ExecState{"e1": {ContainerId: "d4e5f6"}},
+	}
+
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+
+	e.GET("/api/v1/docker/exec/:id", func(ctx *gin.Context) {
+		ctx.Set(principalKey, Principal{Subject: "shop-ci"})
+	}, Authorize(policy, dockerClient), func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	c.Request, _ = http.NewRequestWithContext(c, http.MethodGet, "/api/v1/docker/exec/e1", nil)
+	e.ServeHTTP(w, c.Request)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestAuthorizeStoresScopeForContainerList(t *testing.T) {
+	w, scope := serveAuthorized(t, "shop-ci", http.MethodGet, "/api/v1/docker/containers", "/api/v1/docker/containers")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.DeepEqual(t, &Scope{Labels: []string{"team=shop"}, NamePrefix: "shop-"}, scope)
+
+	_, scope = serveAuthorized(t, "ops", http.MethodGet, "/api/v1/docker/containers", "/api/v1/docker/containers")
+
+	assert.Assert(t, scope == nil)
+}
+
+func TestLoadPolicyErrorInvalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown role":      "bindings:\n  - subject: ops\n    role: root\n",
+		"unknown action":    "roles:\n  deployer: [deploy]\n",
+		"duplicate subject": "bindings:\n  - subject: ops\n    role: admin\n  - subject: ops\n    role: viewer\n",
+		"missing subject":   "bindings:\n  - role: admin\n",
+		"unknown field":     "bindings:\n  - subject: ops\n    roles: admin\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := testLoadPolicy(t, content)
+
+			assert.Assert(t, err != nil)
+		})
+	}
+}
+
+func TestLoadPolicyWildcardSubject(t *testing.T) {
+	policy, err := testLoadPolicy(t, "bindings:\n  - subject: ops\n    role: admin\n  - subject: \"*\"\n    role: viewer\n")
+	assert.NilError(t, err)
+
+	binding, ok := policy.binding("dashboard")
+
+	assert.Assert(t, ok)
+	assert.Equal(t, "viewer", binding.Role)
+
+	binding, _ = policy.binding("ops")
+
+	assert.Equal(t, "admin", binding.Role)
+}
@@ -0,0 +1,60 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	. "godopi/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const REQUEST_ID_HEADER string = "X-Request-ID"
+
+const requestIdKey = "RequestId"
+
+// validRequestId limits the request ids accepted from clients, so that they can be logged and echoed back safely.
+var validRequestId = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestId propagates the X-Request-ID header of the request, or assigns a new id when it is missing or malformed,
+// and echoes it back in the response. The request context carries a logger with the id, and the trace id when the request
+// is traced, which LoggerFromContext returns to the controllers and to the docker and cache clients.
+func RequestId() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestId := ctx.GetHeader(REQUEST_ID_HEADER)
+
+		if !validRequestId.MatchString(requestId) {
+			requestId = newRequestId()
+		}
+
+		ctx.Set(requestIdKey, requestId)
+		ctx.Header(REQUEST_ID_HEADER, requestId)
+
+		requestLogger := LoggerFromContext(ctx.Request.Context()).With(zap.String("RequestId", requestId))
+
+		if spanContext := trace.SpanContextFromContext(ctx.Request.Context()); spanContext.IsValid() {
+			requestLogger = requestLogger.With(zap.String("TraceId", spanContext.TraceID().String()))
+		}
+
+		ctx.Request = ctx.Request.WithContext(WithLogger(ctx.Request.Context(), requestLogger))
+		ctx.Next()
+	}
+}
+
+// GetRequestId returns the id assigned by RequestId, or an empty string when the middleware is not in use.
+func GetRequestId(ctx *gin.Context) string {
+	return ctx.GetString(requestIdKey)
+}
+
+func newRequestId() string {
+	bytes := make([]byte, 16)
+
+	if _, err := rand.Read(bytes); err != nil {
+		Logger().Fatal("Encountered an error while generating a request id! Error:", zap.Error(err))
+	}
+
+	return hex.EncodeToString(bytes)
+}
@@ -0,0 +1,28 @@
+package middlewares
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EndOnShutdown cancels the request context once shutdownCtx is done. It is meant for the streaming routes,
+// which would otherwise hold a graceful shutdown up until its timeout, while the other requests are left to finish.
+func EndOnShutdown(shutdownCtx context.Context) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestCtx, cancel := context.WithCancel(ctx.Request.Context())
+		defer cancel()
+
+		go func() {
+			select {
+			case <-shutdownCtx.Done():
+				cancel()
+			case <-requestCtx.Done():
+			}
+		}()
+
+		ctx.Request = ctx.Request.WithContext(requestCtx)
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,40 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gotest.tools/v3/assert"
+)
+
+func TestEndOnShutdownEndsStream(t *testing.T) {
+	shutdownCtx, shutdown := context.WithCancel(context.Background())
+
+	w := httptest.NewRecorder()
+	_, e := gin.CreateTestContext(w)
+
+	streaming := make(chan struct{})
+
+	e.GET("/events", EndOnShutdown(shutdownCtx), func(ctx *gin.Context) {
+		close(streaming)
+		<-ctx.Request.Context().Done()
+		ctx.Status(http.StatusOK)
+	})
+
+	served := make(chan struct{})
+
+	go func() {
+		defer close(served)
+		request, _ := http.NewRequest(http.MethodGet, "/events", nil)
+		e.ServeHTTP(w, request)
+	}()
+
+	<-streaming
+	shutdown()
+	<-served
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracingServerName = "godopi"
+
+var tracer = otel.Tracer("godopi/internal/app/api/middlewares")
+
+// Tracing starts a server span for every request, continuing the trace of the W3C traceparent header when there is one.
+// The request context carries the span, so that the docker and cache calls made with it become its children.
+// It must come before gin.Recovery for the requests that panic to end their span with the 500 status.
+func Tracing() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		route := ctx.FullPath()
+
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		parent := otel.GetTextMapPropagator().Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+		spanCtx, span := tracer.Start(parent, ctx.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest(tracingServerName, route, ctx.Request)...),
+		)
+		defer span.End()
+
+		ctx.Request = ctx.Request.WithContext(spanCtx)
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+
+		span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(status)...)
+		span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(status, trace.SpanKindServer))
+
+		if requestId := GetRequestId(ctx); requestId != "" {
+			span.SetAttributes(attribute.String("http.request_id", requestId))
+		}
+
+		if principal, ok := GetPrincipal(ctx); ok {
+			span.SetAttributes(semconv.EnduserIDKey.String(principal.Subject))
+		}
+	}
+}
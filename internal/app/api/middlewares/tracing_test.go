@@ -0,0 +1,75 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"gotest.tools/v3/assert"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestTracingContinuesIncomingTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	_, e := gin.CreateTestContext(httptest.NewRecorder())
+
+	var handlerSpan trace.SpanContext
+
+	e.Use(Tracing(), gin.Recovery(), RequestId())
+	e.GET("/containers/:id", func(ctx *gin.Context) {
+		handlerSpan = trace.SpanContextFromContext(ctx.Request.Context())
+
+		if ctx.Param("id") == "broken" {
+			panic("broken handler")
+		}
+
+		ctx.Status(http.StatusNotFound)
+	})
+
+	for _, target := range []string{"/containers/web", "/containers/broken"} {
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, target, nil)
+		request.Header.Set("traceparent", testTraceParent)
+		request.Header.Set(REQUEST_ID_HEADER, "req-42")
+		e.ServeHTTP(w, request)
+	}
+
+	spans := recorder.Ended()
+	assert.Equal(t, 2, len(spans))
+
+	for _, span := range spans {
+		assert.Equal(t, "GET /containers/:id", span.Name())
+		assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+		assert.Assert(t, span.Parent().IsRemote())
+	}
+
+	// The handler sees the span of the request, so that the calls it makes become its children.
+	assert.Equal(t, spans[1].SpanContext().SpanID(), handlerSpan.SpanID())
+
+	// Client errors are not errors of the server, a panic is.
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+
+	attributes := map[string]string{}
+
+	for _, attribute := range spans[0].Attributes() {
+		attributes[string(attribute.Key)] = attribute.Value.Emit()
+	}
+
+	assert.Equal(t, "404", attributes["http.status_code"])
+	assert.Equal(t, "/containers/:id", attributes["http.route"])
+	assert.Equal(t, "req-42", attributes["http.request_id"])
+}
@@ -1,6 +1,218 @@
 package models
 
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+	"github.com/pkg/errors"
+)
+
 type Container struct {
-	ImageName     string `json:"imageName" binding:"required"`
-	ContainerName string `json:"containerName"`
+	ImageName     string              `json:"imageName" binding:"required"`
+	ContainerName string              `json:"containerName"`
+	Env           map[string]string   `json:"env"`
+	Cmd           []string            `json:"cmd"`
+	Entrypoint    []string            `json:"entrypoint"`
+	Ports         []PortBinding       `json:"ports" binding:"dive"`
+	Mounts        []Mount             `json:"mounts" binding:"dive"`
+	Labels        map[string]string   `json:"labels"`
+	RestartPolicy *RestartPolicy      `json:"restartPolicy"`
+	WorkingDir    string              `json:"workingDir"`
+	User          string              `json:"user"`
+	Resources     *Resources          `json:"resources"`
+	Networks      []NetworkAttachment `json:"networks" binding:"dive"`
+	PullPolicy    string              `json:"pullPolicy" binding:"omitempty,oneof=always if-not-present never"`
+	AutoStart     *bool               `json:"autoStart"`
+	Privileged    bool                `json:"privileged"`
+}
+
+type PortBinding struct {
+	ContainerPort int    `json:"containerPort" binding:"required,min=1,max=65535"`
+	Protocol      string `json:"protocol" binding:"omitempty,oneof=tcp udp sctp"`
+	HostIp        string `json:"hostIp" binding:"omitempty,ip"`
+	HostPort      int    `json:"hostPort" binding:"omitempty,min=1,max=65535"`
+}
+
+type Mount struct {
+	Type     string `json:"type" binding:"required,oneof=bind volume tmpfs"`
+	Source   string `json:"source"`
+	Target   string `json:"target" binding:"required"`
+	ReadOnly bool   `json:"readOnly"`
+}
+
+type RestartPolicy struct {
+	Name              string `json:"name" binding:"required,oneof=no always on-failure unless-stopped"`
+	MaximumRetryCount int    `json:"maximumRetryCount" binding:"min=0"`
+}
+
+type Resources struct {
+	Cpus        float64 `json:"cpus" binding:"min=0"`
+	CpuShares   int64   `json:"cpuShares" binding:"min=0"`
+	MemoryBytes int64   `json:"memoryBytes" binding:"min=0"`
+	MemorySwap  int64   `json:"memorySwap" binding:"min=-1"`
+}
+
+type NetworkAttachment struct {
+	Name        string   `json:"name" binding:"required"`
+	Aliases     []string `json:"aliases"`
+	IPv4Address string   `json:"ipv4Address" binding:"omitempty,ipv4"`
+}
+
+// ShouldAutoStart reports whether the container should be started right after creation, which is the default.
+func (c Container) ShouldAutoStart() bool {
+	return c.AutoStart == nil || *c.AutoStart
+}
+
+// DockerConfigs validates the parts of the container spec that binding tags cannot express
+// and translates it into the configs expected by the docker engine.
+func (c Container) DockerConfigs() (*container.Config, *container.HostConfig, *network.NetworkingConfig, error) {
+	env, err := dockerEnv(c.Env)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	exposedPorts, portBindings, err := c.dockerPorts()
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	mounts, err := c.dockerMounts()
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	config := &container.Config{
+		Image:        c.ImageName,
+		Env:          env,
+		Cmd:          c.Cmd,
+		Entrypoint:   c.Entrypoint,
+		ExposedPorts: exposedPorts,
+		Labels:       c.Labels,
+		WorkingDir:   c.WorkingDir,
+		User:         c.User,
+	}
+
+	hostConfig := &container.HostConfig{
+		PortBindings: portBindings,
+		Mounts:       mounts,
+		Privileged:   c.Privileged,
+	}
+
+	if c.RestartPolicy != nil {
+		if c.RestartPolicy.MaximumRetryCount > 0 && c.RestartPolicy.Name != "on-failure" {
+			return nil, nil, nil, errors.Errorf("maximum retry count is only allowed with the on-failure restart policy. RestartPolicy:%s", c.RestartPolicy.Name)
+		}
+
+		hostConfig.RestartPolicy = container.RestartPolicy{Name: c.RestartPolicy.Name, MaximumRetryCount: c.RestartPolicy.MaximumRetryCount}
+	}
+
+	if c.Resources != nil {
+		hostConfig.Resources = container.Resources{
+			NanoCPUs:   int64(c.Resources.Cpus * 1e9),
+			CPUShares:  c.Resources.CpuShares,
+			Memory:     c.Resources.MemoryBytes,
+			MemorySwap: c.Resources.MemorySwap,
+		}
+	}
+
+	networkingConfig := &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{}}
+
+	for i, attachment := range c.Networks {
+		if _, ok := networkingConfig.EndpointsConfig[attachment.Name]; ok {
+			return nil, nil, nil, errors.Errorf("network is attached more than once. Network:%s", attachment.Name)
+		}
+
+		endpointSettings := &network.EndpointSettings{Aliases: attachment.Aliases}
+
+		if attachment.IPv4Address != "" {
+			endpointSettings.IPAMConfig = &network.EndpointIPAMConfig{IPv4Address: attachment.IPv4Address}
+		}
+
+		networkingConfig.EndpointsConfig[attachment.Name] = endpointSettings
+
+		// The first network becomes the primary one, the rest are connected after creation.
+		if i == 0 {
+			hostConfig.NetworkMode = container.NetworkMode(attachment.Name)
+		}
+	}
+
+	return config, hostConfig, networkingConfig, nil
+}
+
+func dockerEnv(variables map[string]string) ([]string, error) {
+	env := make([]string, 0, len(variables))
+
+	for key, value := range variables {
+		if key == "" || strings.Contains(key, "=") {
+			return nil, errors.Errorf("environment variable name must be non-empty and must not contain '='. Name:%s", key)
+		}
+
+		env = append(env, key+"="+value)
+	}
+
+	return env, nil
+}
+
+func (c Container) dockerPorts() (nat.PortSet, nat.PortMap, error) {
+	exposedPorts := nat.PortSet{}
+	portBindings := nat.PortMap{}
+
+	for _, binding := range c.Ports {
+		protocol := binding.Protocol
+
+		if protocol == "" {
+			protocol = "tcp"
+		}
+
+		port, err := nat.NewPort(protocol, fmt.Sprint(binding.ContainerPort))
+
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "there is an error while parsing the container port. ContainerPort:%d", binding.ContainerPort)
+		}
+
+		exposedPorts[port] = struct{}{}
+
+		hostPort := ""
+
+		if binding.HostPort != 0 {
+			hostPort = fmt.Sprint(binding.HostPort)
+		}
+
+		portBindings[port] = append(portBindings[port], nat.PortBinding{HostIP: binding.HostIp, HostPort: hostPort})
+	}
+
+	return exposedPorts, portBindings, nil
+}
+
+func (c Container) dockerMounts() ([]mount.Mount, error) {
+	mounts := make([]mount.Mount, 0, len(c.Mounts))
+
+	for _, m := range c.Mounts {
+		if !path.IsAbs(m.Target) {
+			return nil, errors.Errorf("mount target must be an absolute path. Target:%s", m.Target)
+		}
+
+		switch mount.Type(m.Type) {
+		case mount.TypeBind:
+			if !path.IsAbs(m.Source) {
+				return nil, errors.Errorf("bind mount source must be an absolute host path. Source:%s", m.Source)
+			}
+		case mount.TypeTmpfs:
+			if m.Source != "" {
+				return nil, errors.Errorf("tmpfs mount must not have a source. Source:%s", m.Source)
+			}
+		}
+
+		mounts = append(mounts, mount.Mount{Type: mount.Type(m.Type), Source: m.Source, Target: m.Target, ReadOnly: m.ReadOnly})
+	}
+
+	return mounts, nil
 }
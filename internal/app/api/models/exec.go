@@ -0,0 +1,14 @@
+package models
+
+type Exec struct {
+	Cmd        []string          `json:"cmd" binding:"required,min=1"`
+	Env        map[string]string `json:"env"`
+	User       string            `json:"user"`
+	WorkingDir string            `json:"workingDir"`
+	Tty        bool              `json:"tty"`
+}
+
+// DockerEnv validates the environment variables and translates them into the KEY=VALUE form expected by the docker engine.
+func (e Exec) DockerEnv() ([]string, error) {
+	return dockerEnv(e.Env)
+}
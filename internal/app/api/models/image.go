@@ -0,0 +1,52 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type ImagePull struct {
+	Image  string `json:"image" binding:"required"`
+	Tag    string `json:"tag"`
+	Digest string `json:"digest"`
+}
+
+type ImageTag struct {
+	Repository string `json:"repository" binding:"required"`
+	Tag        string `json:"tag"`
+}
+
+// Reference builds the reference to pull, pinning it by digest when one is given and defaulting to the latest tag otherwise.
+func (ip ImagePull) Reference() (string, error) {
+	if ip.Tag != "" && ip.Digest != "" {
+		return "", errors.Errorf("only one of tag and digest can be given. Tag:%s Digest:%s", ip.Tag, ip.Digest)
+	}
+
+	if strings.Contains(ip.Image, "@") {
+		return "", errors.Errorf("image must not contain a digest, use the digest field instead. Image:%s", ip.Image)
+	}
+
+	if ip.Digest != "" {
+		if !strings.HasPrefix(ip.Digest, "sha256:") {
+			return "", errors.Errorf("digest must be in the sha256:<hex> form. Digest:%s", ip.Digest)
+		}
+
+		return ip.Image + "@" + ip.Digest, nil
+	}
+
+	if ip.Tag != "" {
+		return ip.Image + ":" + ip.Tag, nil
+	}
+
+	return ip.Image, nil
+}
+
+// Reference builds the target reference of the tag, defaulting to the latest tag.
+func (it ImageTag) Reference() string {
+	if it.Tag == "" {
+		return it.Repository + ":latest"
+	}
+
+	return it.Repository + ":" + it.Tag
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+type Network struct {
+	Name       string            `json:"name" binding:"required"`
+	Driver     string            `json:"driver"`
+	Internal   bool              `json:"internal"`
+	Attachable bool              `json:"attachable"`
+	Labels     map[string]string `json:"labels"`
+	Options    map[string]string `json:"options"`
+	Ipam       []IpamConfig      `json:"ipam" binding:"dive"`
+}
+
+type IpamConfig struct {
+	Subnet  string `json:"subnet" binding:"required,cidr"`
+	Gateway string `json:"gateway" binding:"omitempty,ip"`
+	IpRange string `json:"ipRange" binding:"omitempty,cidr"`
+}
+
+type NetworkConnection struct {
+	Container   string   `json:"container" binding:"required"`
+	Aliases     []string `json:"aliases"`
+	IPv4Address string   `json:"ipv4Address" binding:"omitempty,ipv4"`
+}
+
+type NetworkDisconnection struct {
+	Container string `json:"container" binding:"required"`
+	Force     bool   `json:"force"`
+}
+
+// Validate checks that the gateway and the IP range of every IPAM config lie within its subnet,
+// which binding tags cannot express.
+func (n Network) Validate() error {
+	for _, ipamConfig := range n.Ipam {
+		_, subnet, err := net.ParseCIDR(ipamConfig.Subnet)
+
+		if err != nil {
+			return errors.Wrapf(err, "subnet is not a valid CIDR. Subnet:%s", ipamConfig.Subnet)
+		}
+
+		if ipamConfig.Gateway != "" && !subnet.Contains(net.ParseIP(ipamConfig.Gateway)) {
+			return errors.Errorf("gateway is not within the subnet. Gateway:%s Subnet:%s", ipamConfig.Gateway, ipamConfig.Subnet)
+		}
+
+		if ipamConfig.IpRange != "" {
+			rangeIp, ipRange, err := net.ParseCIDR(ipamConfig.IpRange)
+
+			if err != nil {
+				return errors.Wrapf(err, "ip range is not a valid CIDR. IpRange:%s", ipamConfig.IpRange)
+			}
+
+			rangeOnes, _ := ipRange.Mask.Size()
+			subnetOnes, _ := subnet.Mask.Size()
+
+			if !subnet.Contains(rangeIp) || rangeOnes < subnetOnes {
+				return errors.Errorf("ip range is not within the subnet. IpRange:%s Subnet:%s", ipamConfig.IpRange, ipamConfig.Subnet)
+			}
+		}
+	}
+
+	return nil
+}
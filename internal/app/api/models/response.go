@@ -0,0 +1,36 @@
+package models
+
+import "godopi/internal/pkg/policy"
+
+// ErrorResponse is the body of every failed request. Code is one of the stable error codes below,
+// Message summarizes the failed operation and Details carries the underlying cause.
+// Violations lists the broken rules of the container policy when Code is POLICY_VIOLATION.
+type ErrorResponse struct {
+	Code       string             `json:"code"`
+	Message    string             `json:"message"`
+	Details    string             `json:"details,omitempty"`
+	RequestId  string             `json:"requestId,omitempty"`
+	Violations []policy.Violation `json:"violations,omitempty"`
+}
+
+const (
+	ErrorCodeInvalidParameter = "INVALID_PARAMETER"
+	ErrorCodeUnauthorized     = "UNAUTHORIZED"
+	ErrorCodeForbidden        = "FORBIDDEN"
+	ErrorCodeNotFound         = "NOT_FOUND"
+	ErrorCodeConflict         = "CONFLICT"
+	ErrorCodePolicyViolation  = "POLICY_VIOLATION"
+	ErrorCodeNotImplemented   = "NOT_IMPLEMENTED"
+	ErrorCodeUnavailable      = "UNAVAILABLE"
+	ErrorCodeInternal         = "INTERNAL"
+)
+
+// SuccessResponse is the body of the requests that change state without returning a resource.
+type SuccessResponse struct {
+	Success string `json:"Success"`
+}
+
+type ExecCreated struct {
+	Id     string `json:"Id"`
+	Attach string `json:"Attach"`
+}
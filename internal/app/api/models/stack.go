@@ -0,0 +1,10 @@
+package models
+
+type Stack struct {
+	Name    string `json:"name" binding:"required"`
+	Compose string `json:"compose" binding:"required"`
+}
+
+type StackUpdate struct {
+	Compose string `json:"compose" binding:"required"`
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+
+	. "godopi/internal/pkg/logger"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// listenFdsStart is the first file descriptor passed by systemd socket activation, after stdin, stdout and stderr.
+const listenFdsStart = 3
+
+// ListenerOptions are the raw listener settings from the config. An empty Address or UnixSocket disables that listener.
+// UnixSocketMode is the octal permission of the socket file and UnixSocketGroup the name or id of its group.
+type ListenerOptions struct {
+	Address         string
+	UnixSocket      string
+	UnixSocketMode  string
+	UnixSocketGroup string
+}
+
+// newListeners opens the listeners passed by systemd socket activation when there are any, and the configured ones otherwise.
+func newListeners(options ListenerOptions) ([]net.Listener, error) {
+	activated, err := activationListeners()
+
+	if err != nil || len(activated) > 0 {
+		return activated, err
+	}
+
+	var listeners []net.Listener
+
+	if options.Address != "" {
+		listener, err := net.Listen("tcp", options.Address)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "there is an error while listening on the address. Address:%s", options.Address)
+		}
+
+		listeners = append(listeners, listener)
+	}
+
+	if options.UnixSocket != "" {
+		listener, err := listenUnix(options)
+
+		if err != nil {
+			closeListeners(listeners)
+			return nil, err
+		}
+
+		listeners = append(listeners, listener)
+	}
+
+	if len(listeners) == 0 {
+		return nil, errors.New("no listener is configured, set a server address, a unix socket or use socket activation")
+	}
+
+	return listeners, nil
+}
+
+// activationListeners takes over the sockets systemd passed to this process, as described in sd_listen_fds(3).
+// The variables are unset, so that child processes do not take the sockets over again.
+func activationListeners() ([]net.Listener, error) {
+	pid, fds, names := os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS"), os.Getenv("LISTEN_FDNAMES")
+
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	if pid != strconv.Itoa(os.Getpid()) || fds == "" {
+		return nil, nil
+	}
+
+	count, err := strconv.Atoi(fds)
+
+	if err != nil || count < 1 {
+		return nil, errors.Errorf("LISTEN_FDS must be a positive number. LISTEN_FDS:%s", fds)
+	}
+
+	return fileListeners(listenFdsStart, count, strings.Split(names, ":"))
+}
+
+func fileListeners(firstFd int, count int, names []string) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, count)
+
+	for i := 0; i < count; i++ {
+		name := "LISTEN_FD_" + strconv.Itoa(firstFd+i)
+
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+
+		file := os.NewFile(uintptr(firstFd+i), name)
+		listener, err := net.FileListener(file)
+		// The listener holds a duplicate of the descriptor.
+		file.Close()
+
+		if err != nil {
+			closeListeners(listeners)
+			return nil, errors.Wrapf(err, "there is an error while taking over an activated socket, only stream sockets are supported. Socket:%s", name)
+		}
+
+		Logger().Info("Taking over an activated socket", zap.String("Socket", name), zap.String("Address", listener.Addr().String()))
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
+
+// listenUnix creates the socket file with its permissions and group. A socket left over by a previous run is replaced,
+// any other file at the path is left alone.
+func listenUnix(options ListenerOptions) (net.Listener, error) {
+	socketPath := options.UnixSocket
+
+	mode, err := strconv.ParseUint(options.UnixSocketMode, 8, 32)
+
+	if err != nil || mode > 0777 {
+		return nil, errors.Errorf("unix socket mode must be an octal permission. Mode:%s", options.UnixSocketMode)
+	}
+
+	gid := -1
+
+	if options.UnixSocketGroup != "" {
+		if gid, err = lookupGroupId(options.UnixSocketGroup); err != nil {
+			return nil, err
+		}
+	}
+
+	if info, err := os.Lstat(socketPath); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, errors.Errorf("unix socket path is taken by a file that is not a socket. Path:%s", socketPath)
+		}
+
+		if err = os.Remove(socketPath); err != nil {
+			return nil, errors.Wrapf(err, "there is an error while removing the previous unix socket. Path:%s", socketPath)
+		}
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "there is an error while listening on the unix socket. Path:%s", socketPath)
+	}
+
+	if err = os.Chmod(socketPath, os.FileMode(mode)); err != nil {
+		listener.Close()
+		return nil, errors.Wrapf(err, "there is an error while setting the mode of the unix socket. Path:%s", socketPath)
+	}
+
+	if gid != -1 {
+		if err = os.Chown(socketPath, -1, gid); err != nil {
+			listener.Close()
+			return nil, errors.Wrapf(err, "there is an error while setting the group of the unix socket. Path:%s Group:%s", socketPath, options.UnixSocketGroup)
+		}
+	}
+
+	return listener, nil
+}
+
+func lookupGroupId(group string) (int, error) {
+	if gid, err := strconv.Atoi(group); err == nil {
+		return gid, nil
+	}
+
+	found, err := user.LookupGroup(group)
+
+	if err != nil {
+		return 0, errors.Wrapf(err, "there is an error while looking up the unix socket group. Group:%s", group)
+	}
+
+	return strconv.Atoi(found.Gid)
+}
+
+func closeListeners(listeners []net.Listener) {
+	for _, listener := range listeners {
+		listener.Close()
+	}
+}
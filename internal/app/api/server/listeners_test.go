@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func serveTestListener(t *testing.T, listener net.Listener) {
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})}
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	t.Cleanup(func() {
+		_ = server.Close()
+	})
+}
+
+func getTestUnix(t *testing.T, socketPath string) string {
+	client := &http.Client{Transport: &http.Transport{DialContext: func(ctx context.Context, network string, address string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+	}}}
+
+	response, err := client.Get("http://godopi/")
+	assert.NilError(t, err)
+
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	assert.NilError(t, err)
+
+	return string(body)
+}
+
+func TestListenUnixSocket(t *testing.T) {
+	// Socket paths are limited to about 100 bytes, which the nested test directories may exceed.
+	directory, err := os.MkdirTemp("", "godopi")
+	assert.NilError(t, err)
+	t.Cleanup(func() { os.RemoveAll(directory) })
+
+	socketPath := filepath.Join(directory, "godopi.sock")
+
+	// A socket left over by a previous run is replaced.
+	stale, err := net.Listen("unix", socketPath)
+	assert.NilError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listeners, err := newListeners(ListenerOptions{UnixSocket: socketPath, UnixSocketMode: "0600", UnixSocketGroup: strconv.Itoa(os.Getgid())})
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(listeners))
+
+	serveTestListener(t, listeners[0])
+
+	info, err := os.Stat(socketPath)
+	assert.NilError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	assert.Equal(t, "ok", getTestUnix(t, socketPath))
+}
+
+func TestListenUnixSocketErrorInvalid(t *testing.T) {
+	directory := t.TempDir()
+	regularFile := filepath.Join(directory, "godopi.sock")
+	assert.NilError(t, os.WriteFile(regularFile, []byte("keep"), 0600))
+
+	for name, options := range map[string]ListenerOptions{
+		"path is a regular file": {UnixSocket: regularFile, UnixSocketMode: "0660"},
+		"mode is not octal":      {UnixSocket: filepath.Join(directory, "a.sock"), UnixSocketMode: "rw-rw----"},
+		"unknown group":          {UnixSocket: filepath.Join(directory, "b.sock"), UnixSocketMode: "0660", UnixSocketGroup: "no-such-group-godopi"},
+		"nothing to listen on":   {},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := newListeners(options)
+
+			assert.Assert(t, err != nil)
+		})
+	}
+
+	data, err := os.ReadFile(regularFile)
+	assert.NilError(t, err)
+	assert.Equal(t, "keep", string(data))
+}
+
+func TestActivationListeners(t *testing.T) {
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer tcpListener.Close()
+
+	file, err := tcpListener.(*net.TCPListener).File()
+	assert.NilError(t, err)
+
+	// fileListeners takes the descriptor over and closes it, as it would for systemd.
+	fd, err := syscall.Dup(int(file.Fd()))
+	assert.NilError(t, err)
+	file.Close()
+
+	listeners, err := fileListeners(fd, 1, []string{"godopi.socket"})
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(listeners))
+	assert.Equal(t, tcpListener.Addr().String(), listeners[0].Addr().String())
+
+	serveTestListener(t, listeners[0])
+
+	response, err := http.Get("http://" + listeners[0].Addr().String())
+	assert.NilError(t, err)
+	response.Body.Close()
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	// Variables meant for another process are ignored and unset.
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+
+	listeners, err = activationListeners()
+	assert.NilError(t, err)
+	assert.Equal(t, 0, len(listeners))
+
+	_, ok := os.LookupEnv("LISTEN_FDS")
+	assert.Assert(t, !ok)
+}
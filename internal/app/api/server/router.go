@@ -1,13 +1,26 @@
 package server
 
 import (
+	"context"
 	"godopi/docs"
 	"godopi/internal/app/api/controllers"
+	"godopi/internal/app/api/middlewares"
+	. "godopi/internal/app/configs"
+	"godopi/internal/pkg/audit"
+	"godopi/internal/pkg/docker"
+	"godopi/internal/pkg/jobs"
 	. "godopi/internal/pkg/logger"
+	"godopi/internal/pkg/policy"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.uber.org/zap"
 )
 
 // @title       Godopi API
@@ -15,31 +28,256 @@ import (
 // @description A Docker Management API
 // @BasePath  	/api/v1
 
-func NewRouter() *gin.Engine {
+// @securityDefinitions.apikey ApiKeyAuth
+// @in                         header
+// @name                       X-API-Key
+
+// @securityDefinitions.apikey BearerAuth
+// @in                         header
+// @name                       Authorization
+
+const healthPath = "/api/v1/health"
+
+const metricsPath = "/metrics"
+
+// containerMetricsTimeout bounds the container listing of a scrape, so that a slow engine does not hold the scrape up.
+const containerMetricsTimeout = 5 * time.Second
+
+// NewRouter builds the API routes. Background work started for the routes stops when ctx is cancelled,
+// the streaming routes end when streamsCtx is cancelled.
+func NewRouter(ctx context.Context, streamsCtx context.Context) *gin.Engine {
 	Logger().Info("Initializing router..")
 
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
-	router.Use(gin.Recovery())
+	// Image references such as library/nginx are sent with an escaped slash and must stay in a single path segment.
+	router.UseRawPath = true
+
+	if err := setTrustedProxies(router, Config().GetString(SERVER_TRUSTED_PROXIES)); err != nil {
+		Logger().Fatal("Encountered an error while setting the trusted proxies! Error:", zap.Error(err))
+	}
+
+	router.Use(middlewares.Metrics(), middlewares.Tracing(), middlewares.RequestId(), middlewares.AccessLog(), gin.Recovery())
 
 	docs.SwaggerInfo.BasePath = "/api/v1"
 
 	health := controllers.HealthController{}
-	router.GET("api/v1/health", health.Status)
+	router.GET(healthPath, health.Status)
+
+	// Metrics stay open like the health route, for the scrapers that cannot authenticate.
+	if Config().GetBool(METRICS_ENABLED) {
+		registerContainerMetrics()
+		router.GET(metricsPath, gin.WrapH(promhttp.Handler()))
+	}
 
-	v1 := router.Group("api/v1")
+	jobManager := jobs.NewJobManager(Config().GetDuration(JOB_TIMEOUT))
+	auditLog := newAuditLog()
+	containerPolicy := loadContainerPolicy()
+	endOnShutdown := middlewares.EndOnShutdown(streamsCtx)
+
+	// Everything under api/v1 controls the docker host, only the health route registered above stays open.
+	// Auditing comes first so that the requests rejected by authentication or authorization are recorded too.
+	v1 := router.Group("api/v1", append([]gin.HandlerFunc{middlewares.Audit(auditLog)}, authMiddlewares()...)...)
 	{
 		dockerGroup := v1.Group("docker")
 		{
-			dockerController := controllers.NewDockerController()
+			dockerController := controllers.NewDockerController(jobManager, containerPolicy)
+			go dockerController.WatchContainerEvents(ctx)
+
 			dockerGroup.GET("/containers", dockerController.GetAllContainers)
 			dockerGroup.GET("/containers/:id", dockerController.GetDetailedContainer)
 			dockerGroup.POST("/containers", dockerController.CreateContainer)
 			dockerGroup.DELETE("/containers/:id", dockerController.DeleteContainer)
+			dockerGroup.POST("/containers/:id/start", dockerController.StartContainer)
+			dockerGroup.POST("/containers/:id/stop", dockerController.StopContainer)
+			dockerGroup.POST("/containers/:id/restart", dockerController.RestartContainer)
+			dockerGroup.POST("/containers/:id/pause", dockerController.PauseContainer)
+			dockerGroup.POST("/containers/:id/unpause", dockerController.UnpauseContainer)
+			dockerGroup.POST("/containers/:id/kill", dockerController.KillContainer)
+			dockerGroup.GET("/containers/:id/logs", endOnShutdown, dockerController.GetContainerLogs)
+			dockerGroup.GET("/containers/:id/stats", endOnShutdown, dockerController.GetContainerStats)
+			dockerGroup.GET("/stats", dockerController.GetAggregatedStats)
+			dockerGroup.GET("/events", endOnShutdown, dockerController.GetEvents)
+
+			execController := controllers.NewExecController()
+			dockerGroup.POST("/containers/:id/exec", execController.CreateExec)
+			dockerGroup.GET("/exec/:id", execController.GetExec)
+			dockerGroup.GET("/exec/:id/attach", execController.AttachExec)
+			dockerGroup.POST("/exec/:id/resize", execController.ResizeExec)
+
+			imageController := controllers.NewImageController(jobManager, containerPolicy)
+			dockerGroup.GET("/images", imageController.GetAllImages)
+			dockerGroup.GET("/images/:id", imageController.GetDetailedImage)
+			dockerGroup.GET("/images/:id/history", imageController.GetImageHistory)
+			dockerGroup.POST("/images/pull", imageController.PullImage)
+			dockerGroup.POST("/images/prune", imageController.PruneImages)
+			dockerGroup.POST("/images/:id/tag", imageController.TagImage)
+			dockerGroup.DELETE("/images/:id", imageController.DeleteImage)
+
+			volumeController := controllers.NewVolumeController(containerPolicy)
+			dockerGroup.GET("/volumes", volumeController.GetAllVolumes)
+			dockerGroup.GET("/volumes/:name", volumeController.GetVolume)
+			dockerGroup.POST("/volumes", volumeController.CreateVolume)
+			dockerGroup.POST("/volumes/prune", volumeController.PruneVolumes)
+			dockerGroup.DELETE("/volumes/:name", volumeController.DeleteVolume)
+
+			networkController := controllers.NewNetworkController()
+			dockerGroup.GET("/networks", networkController.GetAllNetworks)
+			dockerGroup.GET("/networks/:id", networkController.GetNetwork)
+			dockerGroup.POST("/networks", networkController.CreateNetwork)
+			dockerGroup.POST("/networks/prune", networkController.PruneNetworks)
+			dockerGroup.POST("/networks/:id/connect", networkController.ConnectNetwork)
+			dockerGroup.POST("/networks/:id/disconnect", networkController.DisconnectNetwork)
+			dockerGroup.DELETE("/networks/:id", networkController.DeleteNetwork)
+		}
+
+		stackGroup := v1.Group("stacks")
+		{
+			stackController := controllers.NewStackController(jobManager, containerPolicy)
+			stackGroup.GET("", stackController.GetAllStacks)
+			stackGroup.GET("/:name", stackController.GetStack)
+			stackGroup.POST("", stackController.CreateStack)
+			stackGroup.PUT("/:name", stackController.UpdateStack)
+			stackGroup.DELETE("/:name", stackController.DeleteStack)
 		}
+
+		jobGroup := v1.Group("jobs")
+		{
+			jobController := controllers.NewJobController(jobManager)
+			jobGroup.GET("/:id", jobController.GetJob)
+			jobGroup.GET("/:id/stream", endOnShutdown, jobController.StreamJob)
+		}
+
+		auditController := controllers.NewAuditController(auditLog)
+		v1.GET("/audit", auditController.GetAuditRecords)
 	}
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 
+	// A protected route without an action would be denied to everyone once an RBAC policy is configured.
+	for _, route := range router.Routes() {
+		if strings.HasPrefix(route.Path, v1.BasePath()+"/") && route.Path != healthPath && !middlewares.HasAction(route.Method, route.Path) {
+			Logger().Fatal("Route has no RBAC action", zap.String("Method", route.Method), zap.String("Path", route.Path))
+		}
+	}
+
 	return router
 }
+
+// setTrustedProxies trusts the X-Forwarded-For header only from the comma separated proxy addresses or CIDRs,
+// so that clients cannot forge the source address recorded in the audit and access logs. An empty list trusts no proxy.
+func setTrustedProxies(router *gin.Engine, proxies string) error {
+	var trustedProxies []string
+
+	for _, proxy := range strings.Split(proxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return errors.Wrapf(err, "trusted proxy must be an IP address or a CIDR. TrustedProxies:%s", proxies)
+	}
+
+	return nil
+}
+
+// registerContainerMetrics adds the container counts to the metrics. A router built again keeps the collector registered first.
+func registerContainerMetrics() {
+	err := prometheus.Register(docker.NewContainerStateCollector(docker.NewDockerClient(), containerMetricsTimeout))
+
+	if _, ok := err.(prometheus.AlreadyRegisteredError); err != nil && !ok {
+		Logger().Fatal("Encountered an error while registering the container metrics! Error:", zap.Error(err))
+	}
+}
+
+// loadContainerPolicy loads the container policy file of the config, or returns nil to allow every container when none is set.
+func loadContainerPolicy() *policy.ContainerPolicy {
+	policyFile := Config().GetString(CONTAINER_POLICY_FILE)
+
+	if policyFile == "" {
+		Logger().Warn("No container policy file is configured, any image may be pulled and run with any settings")
+		return nil
+	}
+
+	containerPolicy, err := policy.LoadContainerPolicy(policyFile)
+
+	if err != nil {
+		Logger().Fatal("Encountered an error while loading the container policy! Error:", zap.Error(err))
+	}
+
+	return containerPolicy
+}
+
+// newAuditLog builds the audit log from the comma separated sinks of the config: zap, file and redis.
+func newAuditLog() audit.AuditLog {
+	var sinks []audit.Sink
+
+	for _, name := range strings.Split(Config().GetString(AUDIT_SINKS), ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "zap":
+			sinks = append(sinks, audit.NewZapSink())
+		case "file":
+			fileSink, err := audit.NewFileSink(Config().GetString(AUDIT_FILE))
+
+			if err != nil {
+				Logger().Fatal("Encountered an error while initializing the audit file! Error:", zap.Error(err))
+			}
+
+			sinks = append(sinks, fileSink)
+		case "redis":
+			sinks = append(sinks, audit.NewRedisSink(Config().GetString(REDIS_ADDRESS), Config().GetString(AUDIT_REDIS_STREAM), Config().GetInt64(AUDIT_REDIS_MAX_LEN)))
+		default:
+			Logger().Fatal("Unknown audit sink", zap.String("Sink", name))
+		}
+	}
+
+	if len(sinks) == 0 {
+		Logger().Warn("No audit sink is configured, operations on the docker host are not audited")
+	}
+
+	return audit.NewAuditLog(sinks...)
+}
+
+// authMiddlewares authenticate the requests and, when an RBAC policy file is configured, authorize their actions.
+func authMiddlewares() []gin.HandlerFunc {
+	if !Config().GetBool(AUTH_ENABLED) {
+		Logger().Warn("Authentication is disabled, anyone who can reach the API controls the docker host")
+		return nil
+	}
+
+	authOptions := middlewares.AuthOptions{
+		ApiKeys:          Config().GetString(AUTH_API_KEYS),
+		JwtHmacSecret:    Config().GetString(AUTH_JWT_HMAC_SECRET),
+		JwtPublicKeyFile: Config().GetString(AUTH_JWT_PUBLIC_KEY_FILE),
+		JwtIssuer:        Config().GetString(AUTH_JWT_ISSUER),
+		JwtAudience:      Config().GetString(AUTH_JWT_AUDIENCE),
+	}
+
+	// Client certificates are only verified when the server has a client CA.
+	if Config().GetString(TLS_CLIENT_CA_FILE) != "" {
+		authOptions.ClientCertSubject = Config().GetString(AUTH_CLIENT_CERT_SUBJECT)
+	}
+
+	authenticate, err := middlewares.Authenticate(authOptions)
+
+	if err != nil {
+		Logger().Fatal("Encountered an error while initializing authentication! Error:", zap.Error(err))
+	}
+
+	policyFile := Config().GetString(RBAC_POLICY_FILE)
+
+	if policyFile == "" {
+		Logger().Warn("No RBAC policy file is configured, every authenticated principal may perform every action")
+		return []gin.HandlerFunc{authenticate}
+	}
+
+	policy, err := middlewares.LoadPolicy(policyFile)
+
+	if err != nil {
+		Logger().Fatal("Encountered an error while loading the RBAC policy! Error:", zap.Error(err))
+	}
+
+	return []gin.HandlerFunc{authenticate, middlewares.Authorize(policy, docker.NewDockerClient())}
+}
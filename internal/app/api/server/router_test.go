@@ -0,0 +1,35 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gotest.tools/v3/assert"
+)
+
+func serveClientIp(t *testing.T, trustedProxies string) string {
+	router := gin.New()
+	assert.NilError(t, setTrustedProxies(router, trustedProxies))
+
+	router.GET("/", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, ctx.ClientIP())
+	})
+
+	w := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/", nil)
+	request.RemoteAddr = "192.0.2.10:40000"
+	request.Header.Set("X-Forwarded-For", "203.0.113.7")
+	router.ServeHTTP(w, request)
+
+	return w.Body.String()
+}
+
+func TestTrustedProxies(t *testing.T) {
+	assert.Equal(t, "192.0.2.10", serveClientIp(t, ""))
+	assert.Equal(t, "192.0.2.10", serveClientIp(t, "198.51.100.1"))
+	assert.Equal(t, "203.0.113.7", serveClientIp(t, "198.51.100.1, 192.0.2.0/24"))
+
+	assert.Assert(t, setTrustedProxies(gin.New(), "proxy.local") != nil)
+}
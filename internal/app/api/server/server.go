@@ -5,21 +5,73 @@ import (
 	"fmt"
 	. "godopi/internal/app/configs"
 	. "godopi/internal/pkg/logger"
+	"godopi/internal/pkg/tracing"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
+
+	"go.uber.org/zap"
 )
 
 func Init() {
-	router := NewRouter()
-	serverAddress := Config().GetString(SERVER_ADDRESS)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := SetLevel(Config().GetString(LOG_LEVEL)); err != nil {
+		Logger().Fatal(fmt.Sprintf("Error setting the log level: %v", err))
+	}
+
+	shutdownTracing, err := tracing.Init(ctx, tracing.Options{
+		Exporter:     Config().GetString(TRACING_EXPORTER),
+		ServiceName:  Config().GetString(TRACING_SERVICE_NAME),
+		SampleRatio:  Config().GetFloat64(TRACING_SAMPLE_RATIO),
+		OtlpEndpoint: Config().GetString(TRACING_OTLP_ENDPOINT),
+		OtlpInsecure: Config().GetBool(TRACING_OTLP_INSECURE),
+		File:         Config().GetString(TRACING_FILE),
+	})
+
+	if err != nil {
+		Logger().Fatal(fmt.Sprintf("Error initializing tracing: %v", err))
+	}
+
+	// The streaming routes end as soon as the shutdown starts, the other requests are left to finish.
+	streamsCtx, endStreams := context.WithCancel(context.Background())
+	defer endStreams()
+
+	router := NewRouter(ctx, streamsCtx)
 
 	server := &http.Server{
-		Addr:    serverAddress,
 		Handler: router,
 	}
 
+	server.RegisterOnShutdown(endStreams)
+
+	tlsEnabled := Config().GetString(TLS_CERT_FILE) != "" || Config().GetString(TLS_KEY_FILE) != ""
+
+	if tlsEnabled {
+		reloader, err := newTLSReloader(TLSOptions{
+			CertFile:     Config().GetString(TLS_CERT_FILE),
+			KeyFile:      Config().GetString(TLS_KEY_FILE),
+			ClientCaFile: Config().GetString(TLS_CLIENT_CA_FILE),
+			ClientAuth:   Config().GetString(TLS_CLIENT_AUTH),
+			MinVersion:   Config().GetString(TLS_MIN_VERSION),
+			CipherSuites: Config().GetString(TLS_CIPHER_SUITES),
+		})
+
+		if err != nil {
+			Logger().Fatal(fmt.Sprintf("Error initializing TLS: %v", err))
+		}
+
+		server.TLSConfig = reloader.serverConfig()
+
+		go reloader.watch(ctx)
+	} else {
+		Logger().Warn("TLS is not configured, requests and credentials are sent in plain text")
+	}
+
 	gracefullyClosedChannel := make(chan struct{})
 
 	go func() {
@@ -31,23 +83,63 @@ func Init() {
 
 		Logger().Info(fmt.Sprintf("Received an os signal: %s", receivedSignal.String()))
 
-		// We received an expected os signal, shut down.
-		if err := server.Shutdown(context.Background()); err != nil {
+		// We received an expected os signal, shut down, waiting for the requests in flight no longer than the timeout.
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), Config().GetDuration(SERVER_SHUTDOWN_TIMEOUT))
+		defer shutdownCancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
 			// Error from closing listeners, or context timeout:
 			Logger().Error(fmt.Sprintf("Error received at Godopi server Shutdown: %v", err))
 		}
 
+		// Stop the background work of the routes once no request needs it any more.
+		cancel()
+
 		close(gracefullyClosedChannel)
 	}()
 
-	Logger().Info(fmt.Sprintf("Godopi server listening at: %s", serverAddress))
+	listeners, err := newListeners(ListenerOptions{
+		Address:         Config().GetString(SERVER_ADDRESS),
+		UnixSocket:      Config().GetString(SERVER_UNIX_SOCKET),
+		UnixSocketMode:  Config().GetString(SERVER_UNIX_SOCKET_MODE),
+		UnixSocketGroup: Config().GetString(SERVER_UNIX_SOCKET_GROUP),
+	})
+
+	if err != nil {
+		Logger().Fatal(fmt.Sprintf("Error opening Godopi server listeners: %v", err))
+	}
+
+	serveErrors := make(chan error, len(listeners))
 
-	if err := server.ListenAndServe(); err != http.ErrServerClosed {
-		// Error starting or closing listener:
-		Logger().Fatal(fmt.Sprintf("Error received at Godopi server ListenAndServe: %v", err))
+	for _, listener := range listeners {
+		Logger().Info(fmt.Sprintf("Godopi server listening at: %s", listener.Addr()), zap.String("Network", listener.Addr().Network()), zap.Bool("Tls", tlsEnabled))
+
+		go func(listener net.Listener) {
+			if tlsEnabled {
+				// The certificates come from the TLS config, so that they can be reloaded.
+				serveErrors <- server.ServeTLS(listener, "", "")
+			} else {
+				serveErrors <- server.Serve(listener)
+			}
+		}(listener)
+	}
+
+	for range listeners {
+		if err = <-serveErrors; err != http.ErrServerClosed {
+			// Error serving or closing listener:
+			Logger().Fatal(fmt.Sprintf("Error received at Godopi server Serve: %v", err))
+		}
 	}
 
 	<-gracefullyClosedChannel
 
+	// Export the spans of the last requests before exiting.
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer flushCancel()
+
+	if err = shutdownTracing(flushCtx); err != nil {
+		Logger().Error(fmt.Sprintf("Error flushing traces: %v", err))
+	}
+
 	Logger().Info("Godopi server gracefully closed.")
 }
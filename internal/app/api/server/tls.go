@@ -0,0 +1,217 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	. "godopi/internal/pkg/logger"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// tlsReloadDelay lets the writes of a certificate rotation settle before the files are read again.
+const tlsReloadDelay = 500 * time.Millisecond
+
+// TLSOptions are the raw TLS settings from the config. ClientAuth is optional or require and only applies with a client CA.
+// CipherSuites is a comma separated list of Go cipher suite names, it does not affect TLS 1.3 whose suites are fixed.
+type TLSOptions struct {
+	CertFile     string
+	KeyFile      string
+	ClientCaFile string
+	ClientAuth   string
+	MinVersion   string
+	CipherSuites string
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsReloader serves the TLS config built from the current content of the certificate, key and client CA files.
+type tlsReloader struct {
+	options TLSOptions
+	config  atomic.Value
+}
+
+func newTLSReloader(options TLSOptions) (*tlsReloader, error) {
+	reloader := &tlsReloader{options: options}
+
+	if err := reloader.load(); err != nil {
+		return nil, err
+	}
+
+	return reloader, nil
+}
+
+// serverConfig is the config for the http server. Every handshake picks up the config loaded last.
+func (tr *tlsReloader) serverConfig() *tls.Config {
+	return &tls.Config{
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &tr.current().Certificates[0], nil
+		},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return tr.current(), nil
+		},
+	}
+}
+
+func (tr *tlsReloader) current() *tls.Config {
+	return tr.config.Load().(*tls.Config)
+}
+
+// load reads the files and replaces the current config. The current config is kept when any of them is not valid.
+func (tr *tlsReloader) load() error {
+	certificate, err := tls.LoadX509KeyPair(tr.options.CertFile, tr.options.KeyFile)
+
+	if err != nil {
+		return errors.Wrapf(err, "there is an error while loading the TLS certificate. CertFile:%s KeyFile:%s", tr.options.CertFile, tr.options.KeyFile)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+
+	minVersion := tr.options.MinVersion
+
+	if minVersion == "" {
+		minVersion = "1.2"
+	}
+
+	var ok bool
+
+	if config.MinVersion, ok = tlsVersions[minVersion]; !ok {
+		return errors.Errorf("minimum TLS version must be one of 1.0, 1.1, 1.2 or 1.3. MinVersion:%s", tr.options.MinVersion)
+	}
+
+	if config.CipherSuites, err = parseCipherSuites(tr.options.CipherSuites); err != nil {
+		return err
+	}
+
+	if tr.options.ClientCaFile == "" {
+		tr.config.Store(config)
+		return nil
+	}
+
+	pem, err := os.ReadFile(tr.options.ClientCaFile)
+
+	if err != nil {
+		return errors.Wrapf(err, "there is an error while reading the TLS client CA. File:%s", tr.options.ClientCaFile)
+	}
+
+	config.ClientCAs = x509.NewCertPool()
+
+	if !config.ClientCAs.AppendCertsFromPEM(pem) {
+		return errors.Errorf("TLS client CA has no PEM encoded certificate. File:%s", tr.options.ClientCaFile)
+	}
+
+	switch tr.options.ClientAuth {
+	case "", "optional":
+		// Clients without a certificate may still authenticate with an API key or a token.
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	case "require":
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return errors.Errorf("TLS client auth must be optional or require. ClientAuth:%s", tr.options.ClientAuth)
+	}
+
+	tr.config.Store(config)
+
+	return nil
+}
+
+func parseCipherSuites(names string) ([]uint16, error) {
+	var suites []uint16
+
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+
+		id, ok := cipherSuiteId(name)
+
+		if !ok {
+			return nil, errors.Errorf("cipher suite is not known or not secure. CipherSuite:%s", name)
+		}
+
+		suites = append(suites, id)
+	}
+
+	return suites, nil
+}
+
+func cipherSuiteId(name string) (uint16, bool) {
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == name {
+			return suite.ID, true
+		}
+	}
+
+	return 0, false
+}
+
+// watch reloads the files whenever their directories change until ctx is cancelled.
+// Directories are watched rather than the files, as rotations usually replace the files or swap a symlink to them.
+func (tr *tlsReloader) watch(ctx context.Context) {
+	watcher, err := fsnotify.NewWatcher()
+
+	if err != nil {
+		Logger().Error("Error watching the TLS files, certificates will not be reloaded", zap.Error(err))
+		return
+	}
+
+	defer watcher.Close()
+
+	for _, file := range []string{tr.options.CertFile, tr.options.KeyFile, tr.options.ClientCaFile} {
+		if file == "" {
+			continue
+		}
+
+		if err = watcher.Add(filepath.Dir(file)); err != nil {
+			Logger().Error("Error watching a TLS file, it will not be reloaded", zap.String("File", file), zap.Error(err))
+		}
+	}
+
+	reloadTimer := time.NewTimer(time.Hour)
+	reloadTimer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			reloadTimer.Stop()
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) != 0 {
+				reloadTimer.Reset(tlsReloadDelay)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+
+			Logger().Warn("Error watching the TLS files", zap.Error(err))
+		case <-reloadTimer.C:
+			if err = tr.load(); err != nil {
+				Logger().Error("Error reloading the TLS files, the previous certificates stay in use", zap.Error(err))
+				continue
+			}
+
+			Logger().Info("Reloaded the TLS files")
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+type testCertificateAuthority struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	pem         []byte
+}
+
+func newTestCertificateAuthority(t *testing.T) testCertificateAuthority {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Godopi Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NilError(t, err)
+
+	certificate, err := x509.ParseCertificate(der)
+	assert.NilError(t, err)
+
+	return testCertificateAuthority{certificate: certificate, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns the PEM encoded certificate and key of a leaf certificate with the common name.
+func (ca testCertificateAuthority) issue(t *testing.T, commonName string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+
+	serialNumber, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	assert.NilError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, &key.PublicKey, ca.key)
+	assert.NilError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NilError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+func writeTestFile(t *testing.T, file string, data []byte) {
+	assert.NilError(t, os.WriteFile(file, data, 0600))
+}
+
+// serveTestTLS serves the common name of the verified client certificate, or "anonymous", over TLS with the config of the reloader.
+func serveTestTLS(t *testing.T, reloader *tlsReloader) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(r.TLS.VerifiedChains) == 0 {
+				_, _ = w.Write([]byte("anonymous"))
+				return
+			}
+
+			_, _ = w.Write([]byte(r.TLS.VerifiedChains[0][0].Subject.CommonName))
+		}),
+		TLSConfig: reloader.serverConfig(),
+	}
+
+	go func() {
+		_ = server.ServeTLS(listener, "", "")
+	}()
+
+	t.Cleanup(func() {
+		_ = server.Close()
+	})
+
+	return "https://" + listener.Addr().String()
+}
+
+// getTestTLS opens a new connection for every request, so that each one sees the certificate loaded last.
+func getTestTLS(t *testing.T, url string, ca testCertificateAuthority, clientCertificate *tls.Certificate) (string, string, error) {
+	rootCAs := x509.NewCertPool()
+	rootCAs.AppendCertsFromPEM(ca.pem)
+
+	clientConfig := &tls.Config{RootCAs: rootCAs}
+
+	if clientCertificate != nil {
+		clientConfig.Certificates = []tls.Certificate{*clientCertificate}
+	}
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientConfig, DisableKeepAlives: true}, Timeout: 5 * time.Second}
+	response, err := client.Get(url)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	defer response.Body.Close()
+
+	body := make([]byte, 64)
+	n, _ := response.Body.Read(body)
+
+	return response.TLS.PeerCertificates[0].Subject.CommonName, string(body[:n]), nil
+}
+
+func TestTLSClientCertificates(t *testing.T) {
+	ca := newTestCertificateAuthority(t)
+	directory := t.TempDir()
+	options := TLSOptions{
+		CertFile:     filepath.Join(directory, "server.pem"),
+		KeyFile:      filepath.Join(directory, "server-key.pem"),
+		ClientCaFile: filepath.Join(directory, "client-ca.pem"),
+	}
+
+	serverCert, serverKey := ca.issue(t, "server-1", x509.ExtKeyUsageServerAuth)
+	writeTestFile(t, options.CertFile, serverCert)
+	writeTestFile(t, options.KeyFile, serverKey)
+	writeTestFile(t, options.ClientCaFile, ca.pem)
+
+	clientCert, clientKey := ca.issue(t, "build-agent", x509.ExtKeyUsageClientAuth)
+	clientCertificate, err := tls.X509KeyPair(clientCert, clientKey)
+	assert.NilError(t, err)
+
+	reloader, err := newTLSReloader(options)
+	assert.NilError(t, err)
+
+	url := serveTestTLS(t, reloader)
+
+	_, identity, err := getTestTLS(t, url, ca, &clientCertificate)
+	assert.NilError(t, err)
+	assert.Equal(t, "build-agent", identity)
+
+	_, identity, err = getTestTLS(t, url, ca, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, "anonymous", identity)
+
+	options.ClientAuth = "require"
+	reloader, err = newTLSReloader(options)
+	assert.NilError(t, err)
+
+	_, _, err = getTestTLS(t, serveTestTLS(t, reloader), ca, nil)
+	assert.Assert(t, err != nil)
+}
+
+func TestTLSReloadsChangedCertificates(t *testing.T) {
+	ca := newTestCertificateAuthority(t)
+	directory := t.TempDir()
+	options := TLSOptions{CertFile: filepath.Join(directory, "server.pem"), KeyFile: filepath.Join(directory, "server-key.pem")}
+
+	serverCert, serverKey := ca.issue(t, "server-1", x509.ExtKeyUsageServerAuth)
+	writeTestFile(t, options.CertFile, serverCert)
+	writeTestFile(t, options.KeyFile, serverKey)
+
+	reloader, err := newTLSReloader(options)
+	assert.NilError(t, err)
+
+	url := serveTestTLS(t, reloader)
+
+	// A broken rotation keeps the previous certificate in use.
+	writeTestFile(t, options.KeyFile, []byte("not a key"))
+	assert.Assert(t, reloader.load() != nil)
+
+	serverName, _, err := getTestTLS(t, url, ca, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, "server-1", serverName)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go reloader.watch(ctx)
+
+	serverCert, serverKey = ca.issue(t, "server-2", x509.ExtKeyUsageServerAuth)
+
+	// The files are written again until the watcher, which starts in the background, has picked them up.
+	for i := 0; i < 10 && serverName != "server-2"; i++ {
+		writeTestFile(t, options.CertFile, serverCert)
+		writeTestFile(t, options.KeyFile, serverKey)
+		time.Sleep(2 * tlsReloadDelay)
+
+		serverName, _, err = getTestTLS(t, url, ca, nil)
+		assert.NilError(t, err)
+	}
+
+	assert.Equal(t, "server-2", serverName)
+}
+
+func TestTLSErrorInvalidOptions(t *testing.T) {
+	ca := newTestCertificateAuthority(t)
+	directory := t.TempDir()
+	certFile, keyFile := filepath.Join(directory, "server.pem"), filepath.Join(directory, "server-key.pem")
+
+	serverCert, serverKey := ca.issue(t, "server-1", x509.ExtKeyUsageServerAuth)
+	writeTestFile(t, certFile, serverCert)
+	writeTestFile(t, keyFile, serverKey)
+
+	for name, options := range map[string]TLSOptions{
+		"missing certificate":    {CertFile: filepath.Join(directory, "missing.pem"), KeyFile: keyFile},
+		"unknown min version":    {CertFile: certFile, KeyFile: keyFile, MinVersion: "1.4"},
+		"insecure cipher":        {CertFile: certFile, KeyFile: keyFile, CipherSuites: "TLS_RSA_WITH_RC4_128_SHA"},
+		"client CA not PEM":      {CertFile: certFile, KeyFile: keyFile, ClientCaFile: keyFile},
+		"unknown client auth":    {CertFile: certFile, KeyFile: keyFile, ClientCaFile: certFile, ClientAuth: "always"},
+		"missing client CA":      {CertFile: certFile, KeyFile: keyFile, ClientCaFile: filepath.Join(directory, "missing.pem")},
+		"unknown cipher in list": {CertFile: certFile, KeyFile: keyFile, CipherSuites: "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, TLS_FAKE"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := newTLSReloader(options)
+
+			assert.Assert(t, err != nil)
+		})
+	}
+}
@@ -16,6 +16,8 @@ func init() {
 	setDefaults(config)
 
 	config.AutomaticEnv()
+	// An empty variable switches a setting off, e.g. SERVER_ADDRESS= serves on the unix socket alone.
+	config.AllowEmptyEnv(true)
 	config.SetConfigType("env")
 	config.SetConfigName("config")
 	config.AddConfigPath("../config/")
@@ -39,4 +41,38 @@ func Config() *viper.Viper {
 func setDefaults(config *viper.Viper) {
 	config.SetDefault(SERVER_ADDRESS, "0.0.0.0:8080")
 	config.SetDefault(REDIS_ADDRESS, "localhost:6379")
+	config.SetDefault(JOB_TIMEOUT, "1h")
+	config.SetDefault(SERVER_UNIX_SOCKET, "")
+	config.SetDefault(SERVER_UNIX_SOCKET_MODE, "0660")
+	config.SetDefault(SERVER_UNIX_SOCKET_GROUP, "")
+	config.SetDefault(SERVER_TRUSTED_PROXIES, "")
+	config.SetDefault(SERVER_SHUTDOWN_TIMEOUT, "30s")
+	config.SetDefault(WEBSOCKET_ALLOWED_ORIGINS, "")
+	config.SetDefault(AUTH_ENABLED, true)
+	config.SetDefault(AUTH_API_KEYS, "")
+	config.SetDefault(AUTH_JWT_HMAC_SECRET, "")
+	config.SetDefault(AUTH_JWT_PUBLIC_KEY_FILE, "")
+	config.SetDefault(AUTH_JWT_ISSUER, "")
+	config.SetDefault(AUTH_JWT_AUDIENCE, "")
+	config.SetDefault(AUTH_CLIENT_CERT_SUBJECT, "cn")
+	config.SetDefault(RBAC_POLICY_FILE, "")
+	config.SetDefault(TLS_CERT_FILE, "")
+	config.SetDefault(TLS_KEY_FILE, "")
+	config.SetDefault(TLS_CLIENT_CA_FILE, "")
+	config.SetDefault(TLS_CLIENT_AUTH, "optional")
+	config.SetDefault(TLS_MIN_VERSION, "1.2")
+	config.SetDefault(TLS_CIPHER_SUITES, "")
+	config.SetDefault(CONTAINER_POLICY_FILE, "")
+	config.SetDefault(AUDIT_SINKS, "zap,redis")
+	config.SetDefault(AUDIT_FILE, "audit.jsonl")
+	config.SetDefault(AUDIT_REDIS_STREAM, "godopi:audit")
+	config.SetDefault(AUDIT_REDIS_MAX_LEN, 1000000)
+	config.SetDefault(METRICS_ENABLED, true)
+	config.SetDefault(LOG_LEVEL, "info")
+	config.SetDefault(TRACING_EXPORTER, "none")
+	config.SetDefault(TRACING_SERVICE_NAME, "godopi")
+	config.SetDefault(TRACING_SAMPLE_RATIO, 1.0)
+	config.SetDefault(TRACING_OTLP_ENDPOINT, "")
+	config.SetDefault(TRACING_OTLP_INSECURE, false)
+	config.SetDefault(TRACING_FILE, "")
 }
@@ -3,4 +3,48 @@ package configs
 const (
 	SERVER_ADDRESS = "SERVER_ADDRESS"
 	REDIS_ADDRESS  = "REDIS_ADDRESS"
+	JOB_TIMEOUT    = "JOB_TIMEOUT"
+
+	SERVER_UNIX_SOCKET       = "SERVER_UNIX_SOCKET"
+	SERVER_UNIX_SOCKET_MODE  = "SERVER_UNIX_SOCKET_MODE"
+	SERVER_UNIX_SOCKET_GROUP = "SERVER_UNIX_SOCKET_GROUP"
+	SERVER_TRUSTED_PROXIES   = "SERVER_TRUSTED_PROXIES"
+	SERVER_SHUTDOWN_TIMEOUT  = "SERVER_SHUTDOWN_TIMEOUT"
+
+	WEBSOCKET_ALLOWED_ORIGINS = "WEBSOCKET_ALLOWED_ORIGINS"
+
+	AUTH_ENABLED             = "AUTH_ENABLED"
+	AUTH_API_KEYS            = "AUTH_API_KEYS"
+	AUTH_JWT_HMAC_SECRET     = "AUTH_JWT_HMAC_SECRET"
+	AUTH_JWT_PUBLIC_KEY_FILE = "AUTH_JWT_PUBLIC_KEY_FILE"
+	AUTH_JWT_ISSUER          = "AUTH_JWT_ISSUER"
+	AUTH_JWT_AUDIENCE        = "AUTH_JWT_AUDIENCE"
+	AUTH_CLIENT_CERT_SUBJECT = "AUTH_CLIENT_CERT_SUBJECT"
+
+	RBAC_POLICY_FILE = "RBAC_POLICY_FILE"
+
+	TLS_CERT_FILE      = "TLS_CERT_FILE"
+	TLS_KEY_FILE       = "TLS_KEY_FILE"
+	TLS_CLIENT_CA_FILE = "TLS_CLIENT_CA_FILE"
+	TLS_CLIENT_AUTH    = "TLS_CLIENT_AUTH"
+	TLS_MIN_VERSION    = "TLS_MIN_VERSION"
+	TLS_CIPHER_SUITES  = "TLS_CIPHER_SUITES"
+
+	CONTAINER_POLICY_FILE = "CONTAINER_POLICY_FILE"
+
+	AUDIT_SINKS         = "AUDIT_SINKS"
+	AUDIT_FILE          = "AUDIT_FILE"
+	AUDIT_REDIS_STREAM  = "AUDIT_REDIS_STREAM"
+	AUDIT_REDIS_MAX_LEN = "AUDIT_REDIS_MAX_LEN"
+
+	METRICS_ENABLED = "METRICS_ENABLED"
+
+	LOG_LEVEL = "LOG_LEVEL"
+
+	TRACING_EXPORTER      = "TRACING_EXPORTER"
+	TRACING_SERVICE_NAME  = "TRACING_SERVICE_NAME"
+	TRACING_SAMPLE_RATIO  = "TRACING_SAMPLE_RATIO"
+	TRACING_OTLP_ENDPOINT = "TRACING_OTLP_ENDPOINT"
+	TRACING_OTLP_INSECURE = "TRACING_OTLP_INSECURE"
+	TRACING_FILE          = "TRACING_FILE"
 )
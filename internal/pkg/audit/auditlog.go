@@ -0,0 +1,133 @@
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	. "godopi/internal/pkg/logger"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeDenied  = "denied"
+	OutcomeFailure = "failure"
+)
+
+// Record is the audit trail of a single operation on the docker host.
+// Endpoint is the route pattern and Path the requested path, Target the container, image or other resource the operation acted on.
+type Record struct {
+	Id         string                 `json:"id"`
+	Time       time.Time              `json:"time"`
+	RequestId  string                 `json:"requestId"`
+	Subject    string                 `json:"subject"`
+	AuthMethod string                 `json:"authMethod"`
+	SourceIp   string                 `json:"sourceIp"`
+	Method     string                 `json:"method"`
+	Endpoint   string                 `json:"endpoint"`
+	Path       string                 `json:"path"`
+	Action     string                 `json:"action"`
+	Target     string                 `json:"target"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	Status     int                    `json:"status"`
+	Outcome    string                 `json:"outcome"`
+	ErrorCode  string                 `json:"errorCode,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	DurationMs int64                  `json:"durationMs"`
+}
+
+// Filter selects audit records. Empty fields match every record, Limit caps the number of records returned.
+type Filter struct {
+	Subject string
+	Action  string
+	Target  string
+	Outcome string
+	Since   time.Time
+	Until   time.Time
+	Limit   int
+}
+
+// Matches reports whether the record passes every field of the filter.
+func (f Filter) Matches(record Record) bool {
+	switch {
+	case f.Subject != "" && record.Subject != f.Subject,
+		f.Action != "" && record.Action != f.Action,
+		f.Target != "" && record.Target != f.Target,
+		f.Outcome != "" && record.Outcome != f.Outcome,
+		!f.Since.IsZero() && record.Time.Before(f.Since),
+		!f.Until.IsZero() && record.Time.After(f.Until):
+		return false
+	default:
+		return true
+	}
+}
+
+// Sink stores audit records.
+type Sink interface {
+	Write(ctx context.Context, record Record) error
+}
+
+// QueryableSink is a sink whose records can be read back, newest first.
+type QueryableSink interface {
+	Sink
+	Query(ctx context.Context, filter Filter) ([]Record, error)
+}
+
+// AuditLog writes every record to all of its sinks and answers queries from the first sink that can be queried.
+type AuditLog interface {
+	Record(ctx context.Context, record Record)
+	Query(ctx context.Context, filter Filter) ([]Record, error)
+}
+
+type auditLog struct {
+	sinks []Sink
+}
+
+func NewAuditLog(sinks ...Sink) AuditLog {
+	Logger().Info("Constructing new audit log..")
+
+	return auditLog{sinks: sinks}
+}
+
+// Record assigns an id to the record and writes it to every sink.
+// A failing sink is logged and does not keep the record from the others, nor fail the audited operation.
+func (al auditLog) Record(ctx context.Context, record Record) {
+	if record.Id == "" {
+		record.Id = newRecordId()
+	}
+
+	if record.Time.IsZero() {
+		record.Time = time.Now().UTC()
+	}
+
+	for _, sink := range al.sinks {
+		if err := sink.Write(ctx, record); err != nil {
+			Logger().Error("Error writing audit record", zap.String("RecordId", record.Id), zap.String("RequestId", record.RequestId), zap.Error(err))
+		}
+	}
+}
+
+func (al auditLog) Query(ctx context.Context, filter Filter) ([]Record, error) {
+	for _, sink := range al.sinks {
+		if queryable, ok := sink.(QueryableSink); ok {
+			return queryable.Query(ctx, filter)
+		}
+	}
+
+	return nil, errdefs.NotImplemented(errors.New("no configured audit sink can be queried, enable the file or redis sink"))
+}
+
+func newRecordId() string {
+	bytes := make([]byte, 16)
+
+	if _, err := rand.Read(bytes); err != nil {
+		Logger().Fatal("Encountered an error while generating an audit record id! Error:", zap.Error(err))
+	}
+
+	return hex.EncodeToString(bytes)
+}
@@ -0,0 +1,262 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	. "godopi/internal/pkg/logger"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// maxRecordSize bounds a single line of the audit file.
+const maxRecordSize = 1 << 20
+
+// redisPageSize is how many stream entries are read at once while looking for matching records.
+const redisPageSize = 500
+
+type zapSink struct{}
+
+// NewZapSink writes the records to the application log. It cannot be queried.
+func NewZapSink() Sink {
+	return zapSink{}
+}
+
+func (zs zapSink) Write(ctx context.Context, record Record) error {
+	Logger().Info("Audit",
+		zap.String("RecordId", record.Id),
+		zap.Time("Time", record.Time),
+		zap.String("RequestId", record.RequestId),
+		zap.String("Subject", record.Subject),
+		zap.String("AuthMethod", record.AuthMethod),
+		zap.String("SourceIp", record.SourceIp),
+		zap.String("Method", record.Method),
+		zap.String("Endpoint", record.Endpoint),
+		zap.String("Path", record.Path),
+		zap.String("Action", record.Action),
+		zap.String("Target", record.Target),
+		zap.Any("Parameters", record.Parameters),
+		zap.Int("Status", record.Status),
+		zap.String("Outcome", record.Outcome),
+		zap.String("ErrorCode", record.ErrorCode),
+		zap.String("Error", record.Error),
+		zap.Int64("DurationMs", record.DurationMs),
+	)
+
+	return nil
+}
+
+type fileSink struct {
+	path  string
+	file  *os.File
+	mutex *sync.Mutex
+}
+
+// NewFileSink appends the records to a file as JSON lines. The file is only ever opened for appending,
+// queries read it from the start.
+func NewFileSink(path string) (QueryableSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "there is an error while opening the audit file. File:%s", path)
+	}
+
+	return fileSink{path: path, file: file, mutex: &sync.Mutex{}}, nil
+}
+
+func (fs fileSink) Write(ctx context.Context, record Record) error {
+	line, err := json.Marshal(record)
+
+	if err != nil {
+		return errors.Wrap(err, "there is an error while encoding the audit record")
+	}
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	if _, err = fs.file.Write(append(line, '\n')); err != nil {
+		return errors.Wrapf(err, "there is an error while writing the audit file. File:%s", fs.path)
+	}
+
+	return nil
+}
+
+// Query reads the file through its own handle, up to the size it had when the query started.
+// Records are written whole under the mutex, so that size always ends with a complete line and writes go on during the scan.
+func (fs fileSink) Query(ctx context.Context, filter Filter) ([]Record, error) {
+	fs.mutex.Lock()
+	info, err := fs.file.Stat()
+	fs.mutex.Unlock()
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "there is an error while reading the audit file. File:%s", fs.path)
+	}
+
+	file, err := os.Open(fs.path)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "there is an error while opening the audit file. File:%s", fs.path)
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(io.LimitReader(file, info.Size()))
+	scanner.Buffer(make([]byte, 64*1024), maxRecordSize)
+
+	var records []Record
+
+	for scanner.Scan() {
+		var record Record
+
+		if err = json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, errors.Wrapf(err, "there is an error while decoding the audit file. File:%s", fs.path)
+		}
+
+		if !filter.Matches(record) {
+			continue
+		}
+
+		records = append(records, record)
+
+		// Only the newest matches are returned, older ones are dropped as newer ones are found.
+		if filter.Limit > 0 && len(records) > filter.Limit {
+			records = records[1:]
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "there is an error while reading the audit file. File:%s", fs.path)
+	}
+
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+
+	return records, nil
+}
+
+type redisSink struct {
+	client *redis.Client
+	stream string
+	maxLen int64
+}
+
+// NewRedisSink adds the records to a redis stream, trimmed to about maxLen entries. A non-positive maxLen keeps every entry.
+func NewRedisSink(address string, stream string, maxLen int64) QueryableSink {
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     address,
+		Password: "", // no password set
+		DB:       0,  // use default DB
+	})
+
+	return redisSink{client: redisClient, stream: stream, maxLen: maxLen}
+}
+
+func (rs redisSink) Write(ctx context.Context, record Record) error {
+	data, err := json.Marshal(record)
+
+	if err != nil {
+		return errors.Wrap(err, "there is an error while encoding the audit record")
+	}
+
+	args := &redis.XAddArgs{Stream: rs.stream, Values: map[string]interface{}{"record": data}}
+
+	if rs.maxLen > 0 {
+		args.MaxLen = rs.maxLen
+		args.Approx = true
+	}
+
+	if err = rs.client.XAdd(ctx, args).Err(); err != nil {
+		return errors.Wrapf(err, "there is an error while adding to the audit stream. Stream:%s", rs.stream)
+	}
+
+	return nil
+}
+
+// Query walks the stream backwards from the newest entry. Stream ids start with the time in milliseconds,
+// so the time range of the filter narrows the walk.
+func (rs redisSink) Query(ctx context.Context, filter Filter) ([]Record, error) {
+	start, end := "-", "+"
+
+	if !filter.Since.IsZero() {
+		start = strconv.FormatInt(filter.Since.UnixMilli(), 10)
+	}
+
+	if !filter.Until.IsZero() {
+		end = strconv.FormatInt(filter.Until.UnixMilli(), 10)
+	}
+
+	var records []Record
+
+	for end != "" {
+		messages, err := rs.client.XRevRangeN(ctx, rs.stream, end, start, redisPageSize).Result()
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "there is an error while reading the audit stream. Stream:%s", rs.stream)
+		}
+
+		for _, message := range messages {
+			data, ok := message.Values["record"].(string)
+
+			if !ok {
+				return nil, errors.Errorf("audit stream entry has no record. Stream:%s Id:%s", rs.stream, message.ID)
+			}
+
+			var record Record
+
+			if err = json.Unmarshal([]byte(data), &record); err != nil {
+				return nil, errors.Wrapf(err, "there is an error while decoding the audit stream. Stream:%s Id:%s", rs.stream, message.ID)
+			}
+
+			if !filter.Matches(record) {
+				continue
+			}
+
+			records = append(records, record)
+
+			if filter.Limit > 0 && len(records) == filter.Limit {
+				return records, nil
+			}
+		}
+
+		if len(messages) < redisPageSize {
+			break
+		}
+
+		end = previousStreamId(messages[len(messages)-1].ID)
+	}
+
+	return records, nil
+}
+
+// previousStreamId returns the greatest stream id below id, or an empty string when there is none.
+// Exclusive ranges would avoid this, but they need redis 6.2.
+func previousStreamId(id string) string {
+	parts := strings.SplitN(id, "-", 2)
+
+	if len(parts) != 2 {
+		return ""
+	}
+
+	milliseconds, msErr := strconv.ParseUint(parts[0], 10, 64)
+	sequence, seqErr := strconv.ParseUint(parts[1], 10, 64)
+
+	switch {
+	case msErr != nil || seqErr != nil:
+		return ""
+	case sequence > 0:
+		return parts[0] + "-" + strconv.FormatUint(sequence-1, 10)
+	case milliseconds > 0:
+		return strconv.FormatUint(milliseconds-1, 10) + "-18446744073709551615"
+	default:
+		return ""
+	}
+}
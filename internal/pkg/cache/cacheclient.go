@@ -7,14 +7,33 @@ import (
 	. "godopi/internal/pkg/logger"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 )
 
 const CacheNil = redis.Nil
 
+// cacheLookups counts the Get calls by result, hit, miss or error. The hit ratio is hits over all lookups.
+var cacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "godopi",
+	Subsystem: "cache",
+	Name:      "lookups_total",
+	Help:      "Number of cache lookups by result.",
+}, []string{"result"})
+
+var tracer = otel.Tracer("godopi/internal/pkg/cache")
+
 type CacheClient interface {
 	Get(context.Context, string) (string, error)
 	Set(context.Context, string, interface{}, time.Duration) error
 	Del(context.Context, ...string) error
+	DelByPrefix(context.Context, string) error
 }
 
 type cacheClient struct {
@@ -33,14 +52,85 @@ func NewCacheClient(address string) CacheClient {
 	return cacheClient{client: redisClient}
 }
 
+// startSpan starts the span of a cache operation and returns the function that ends it with the result of the operation,
+// which is logged at debug level with the request logger of ctx. A missing key is not an error.
+func startSpan(ctx context.Context, operation string, attributes ...attribute.KeyValue) (context.Context, func(error)) {
+	attributes = append(attributes, semconv.DBSystemRedis, semconv.DBOperationKey.String(operation))
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "cache."+operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+
+	return ctx, func(err error) {
+		LoggerFromContext(ctx).Debug("Cache call", zap.String("Operation", operation), zap.Duration("Duration", time.Since(start)), zap.Error(err))
+
+		if err != nil && err != CacheNil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+
+		span.End()
+	}
+}
+
 func (cc cacheClient) Get(ctx context.Context, key string) (string, error) {
-	return cc.client.Get(ctx, key).Result()
+	ctx, finish := startSpan(ctx, "GET", attribute.String("cache.key", key))
+
+	value, err := cc.client.Get(ctx, key).Result()
+	finish(err)
+
+	switch {
+	case err == nil:
+		cacheLookups.WithLabelValues("hit").Inc()
+	case err == CacheNil:
+		cacheLookups.WithLabelValues("miss").Inc()
+	default:
+		cacheLookups.WithLabelValues("error").Inc()
+	}
+
+	return value, err
 }
 
-func (cc cacheClient) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+func (cc cacheClient) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) (err error) {
+	ctx, finish := startSpan(ctx, "SET", attribute.String("cache.key", key))
+	defer func() { finish(err) }()
+
 	return cc.client.Set(ctx, key, value, expiration).Err()
 }
 
-func (cc cacheClient) Del(ctx context.Context, keys ...string) error {
+func (cc cacheClient) Del(ctx context.Context, keys ...string) (err error) {
+	ctx, finish := startSpan(ctx, "DEL", attribute.StringSlice("cache.keys", keys))
+	defer func() { finish(err) }()
+
+	return cc.client.Del(ctx, keys...).Err()
+}
+
+// DelByPrefix deletes every key starting with prefix. Keys are looked up incrementally so the storage is not blocked.
+func (cc cacheClient) DelByPrefix(ctx context.Context, prefix string) (err error) {
+	ctx, finish := startSpan(ctx, "DEL_BY_PREFIX", attribute.String("cache.prefix", prefix))
+	defer func() { finish(err) }()
+
+	iterator := cc.client.Scan(ctx, 0, prefix+"*", 100).Iterator()
+
+	var keys []string
+
+	for iterator.Next(ctx) {
+		keys = append(keys, iterator.Val())
+
+		if len(keys) == 100 {
+			if err := cc.client.Del(ctx, keys...).Err(); err != nil {
+				return err
+			}
+
+			keys = keys[:0]
+		}
+	}
+
+	if err := iterator.Err(); err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
 	return cc.client.Del(ctx, keys...).Err()
 }
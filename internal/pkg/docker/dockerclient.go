@@ -5,20 +5,100 @@ import (
 	"encoding/json"
 	. "godopi/internal/pkg/logger"
 	"io"
-	"os"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 type DockerClient interface {
-	GetAllContainersJson(ctx context.Context) (string, error)
-	GetDetailedContainerJson(ctx context.Context, containerId string) (string, error)
-	CreateContainer(ctx context.Context, imageName string, containerName string) (string, error)
+	ListContainers(ctx context.Context, options ListContainersOptions) ([]ContainerSummary, string, error)
+	InspectContainer(ctx context.Context, containerId string) (ContainerDetail, error)
+	CreateContainer(ctx context.Context, options CreateContainerOptions) (string, error)
 	DeleteContainer(ctx context.Context, containerId string) error
+	StartContainer(ctx context.Context, containerId string) error
+	StopContainer(ctx context.Context, containerId string, timeout *time.Duration) error
+	RestartContainer(ctx context.Context, containerId string, timeout *time.Duration) error
+	PauseContainer(ctx context.Context, containerId string) error
+	UnpauseContainer(ctx context.Context, containerId string) error
+	KillContainer(ctx context.Context, containerId string, signal string) error
+	StreamContainerLogs(ctx context.Context, containerId string, options LogOptions, onLine func(LogLine)) error
+	GetContainerStats(ctx context.Context, containerId string) (ContainerStats, error)
+	StreamContainerStats(ctx context.Context, containerId string, onStats func(ContainerStats)) error
+	GetAggregatedStats(ctx context.Context) (AggregatedStats, error)
+	StreamEvents(ctx context.Context, options EventOptions, onEvent func(Event)) error
+	CreateExec(ctx context.Context, containerId string, options ExecOptions) (string, error)
+	AttachExec(ctx context.Context, execId string, tty bool) (ExecStream, error)
+	InspectExec(ctx context.Context, execId string) (ExecState, error)
+	ResizeExec(ctx context.Context, execId string, height uint, width uint) error
+	ListImages(ctx context.Context, all bool, dangling bool) ([]ImageSummary, error)
+	InspectImage(ctx context.Context, imageId string) (ImageDetail, error)
+	PullImage(ctx context.Context, imageReference string, onProgress func(PullProgress)) error
+	TagImage(ctx context.Context, sourceImage string, targetImage string) error
+	DeleteImage(ctx context.Context, imageId string, force bool, pruneChildren bool) ([]ImageDeleteItem, error)
+	GetImageHistory(ctx context.Context, imageId string) ([]ImageHistoryItem, error)
+	PruneImages(ctx context.Context, all bool) (ImagePruneReport, error)
+	ListVolumes(ctx context.Context, options ListVolumesOptions) ([]Volume, error)
+	CreateVolume(ctx context.Context, options CreateVolumeOptions) (Volume, error)
+	InspectVolume(ctx context.Context, volumeName string) (Volume, error)
+	DeleteVolume(ctx context.Context, volumeName string, force bool) error
+	PruneVolumes(ctx context.Context, labels []string) (VolumePruneReport, error)
+	ListNetworks(ctx context.Context, options ListNetworksOptions) ([]Network, error)
+	InspectNetwork(ctx context.Context, networkId string) (Network, error)
+	CreateNetwork(ctx context.Context, options CreateNetworkOptions) (Network, error)
+	DeleteNetwork(ctx context.Context, networkId string) error
+	PruneNetworks(ctx context.Context, labels []string) (NetworkPruneReport, error)
+	ConnectNetwork(ctx context.Context, networkId string, containerId string, options ConnectNetworkOptions) error
+	DisconnectNetwork(ctx context.Context, networkId string, containerId string, force bool) error
+}
+
+type PullPolicy string
+
+const (
+	PullAlways       PullPolicy = "always"
+	PullIfNotPresent PullPolicy = "if-not-present"
+	PullNever        PullPolicy = "never"
+)
+
+// CreateContainerOptions carries everything needed to create a container.
+// Config.Image names the image that is resolved according to PullPolicy before the container is created.
+// OnPullProgress, when it is given, receives the progress of that pull.
+type CreateContainerOptions struct {
+	Name             string
+	Config           *container.Config
+	HostConfig       *container.HostConfig
+	NetworkingConfig *network.NetworkingConfig
+	PullPolicy       PullPolicy
+	AutoStart        bool
+	OnPullProgress   func(PullProgress)
+}
+
+// PullProgress is the decoded progress of a single layer, or of the whole pull when LayerId is empty.
+type PullProgress struct {
+	LayerId string
+	Status  string
+	Current int64
+	Total   int64
+}
+
+// pullMessage is a single entry of the JSON stream the engine emits while pulling an image.
+type pullMessage struct {
+	Id             string `json:"id"`
+	Status         string `json:"status"`
+	ProgressDetail struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
+	ErrorDetail *struct {
+		Message string `json:"message"`
+	} `json:"errorDetail"`
 }
 
 type dockerClient struct {
@@ -34,71 +114,124 @@ func NewDockerClient() DockerClient {
 		Logger().Fatal("Encountered an error while initializing the Docker engine client! Error:", zap.Error(err))
 	}
 
-	return dockerClient{client: client}
+	return instrumentedDockerClient{next: dockerClient{client: client}}
 }
 
-func (dc dockerClient) GetAllContainersJson(ctx context.Context) (string, error) {
-	containers, err := dc.client.ContainerList(ctx, types.ContainerListOptions{})
+func (dc dockerClient) CreateContainer(ctx context.Context, options CreateContainerOptions) (string, error) {
+	imageName := options.Config.Image
 
-	if err != nil {
-		return "", errors.Wrap(err, "there is an error while requesting container list through docker client")
+	// The steps are recorded on the span of the call, to tell a slow pull from a slow create or start.
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.String("docker.image", imageName))
+
+	if err := dc.ensureImage(ctx, imageName, options.PullPolicy, options.OnPullProgress); err != nil {
+		return "", err
 	}
 
-	byteData, err := json.MarshalIndent(containers, "", "")
+	span.AddEvent("image ready")
+
+	// The engine only accepts a single network at creation time, the others are connected afterwards.
+	primaryNetworkingConfig, secondaryEndpoints := splitNetworkingConfig(options.HostConfig, options.NetworkingConfig)
+
+	container, err := dc.client.ContainerCreate(ctx, options.Config, options.HostConfig, primaryNetworkingConfig, nil, options.Name)
 
 	if err != nil {
-		return "", errors.Wrap(err, "there is an error while marshalling container list into json")
+		return "", errors.Wrapf(err, "there is an error while requesting container create through docker client. ImageName:%s", imageName)
 	}
 
-	return string(byteData), nil
-}
+	span.AddEvent("container created", trace.WithAttributes(attribute.String("docker.container_id", container.ID)))
 
-func (dc dockerClient) GetDetailedContainerJson(ctx context.Context, containerId string) (string, error) {
-	containerDetail, err := dc.client.ContainerInspect(ctx, containerId)
+	for networkName, endpointSettings := range secondaryEndpoints {
+		if err = dc.client.NetworkConnect(ctx, networkName, container.ID, endpointSettings); err != nil {
+			return "", errors.Wrapf(err, "there is an error while requesting network connect through docker client. ContainerId:%s Network:%s", container.ID, networkName)
+		}
 
-	if err != nil {
-		return "", errors.Wrapf(err, "there is an error while requesting container inspect through docker client. ContainerId:%s", containerId)
+		span.AddEvent("network connected", trace.WithAttributes(attribute.String("docker.network", networkName)))
 	}
 
-	byteData, err := json.MarshalIndent(containerDetail, "", "")
+	if !options.AutoStart {
+		return container.ID, nil
+	}
 
-	if err != nil {
-		return "", errors.Wrap(err, "there is an error while marshalling container detail into json")
+	if err = dc.client.ContainerStart(ctx, container.ID, types.ContainerStartOptions{}); err != nil {
+		return "", errors.Wrapf(err, "there is an error while requesting container start through docker client. ContainerId:%s", container.ID)
 	}
 
-	return string(byteData), nil
+	return container.ID, nil
 }
 
-func (dc dockerClient) CreateContainer(ctx context.Context, imageName string, containerName string) (string, error) {
-	ioReadCloser, err := dc.client.ImagePull(ctx, imageName, types.ImagePullOptions{})
+// ensureImage makes the image available locally according to the pull policy. An empty policy behaves as PullAlways.
+func (dc dockerClient) ensureImage(ctx context.Context, imageName string, pullPolicy PullPolicy, onProgress func(PullProgress)) error {
+	if pullPolicy == PullIfNotPresent || pullPolicy == PullNever {
+		_, _, err := dc.client.ImageInspectWithRaw(ctx, imageName)
 
-	if err != nil {
-		return "", errors.Wrapf(err, "there is an error while pulling the image. ImageName:%s", imageName)
+		if err == nil {
+			return nil
+		}
+
+		if !client.IsErrNotFound(err) {
+			return errors.Wrapf(err, "there is an error while requesting image inspect through docker client. ImageName:%s", imageName)
+		}
+
+		if pullPolicy == PullNever {
+			return errdefs.NotFound(errors.Errorf("image is not present locally and the pull policy is %s. ImageName:%s", pullPolicy, imageName))
+		}
 	}
 
-	defer ioReadCloser.Close()
+	LoggerFromContext(ctx).Info("Pulling the image of a container", zap.String("ImageName", imageName), zap.String("PullPolicy", string(pullPolicy)))
 
-	_, err = io.Copy(os.Stdout, ioReadCloser)
+	return dc.PullImage(ctx, imageName, onProgress)
+}
 
-	if err != nil {
-		return "", errors.Wrapf(err, "there is an error while copying the image data. ImageName:%s", imageName)
+// drainPullStream consumes the pull progress stream, which must be read to the end for the pull to complete,
+// relays the decoded progress to onProgress when it is given and surfaces the errors the engine reports inside the stream.
+func drainPullStream(reader io.Reader, onProgress func(PullProgress)) error {
+	decoder := json.NewDecoder(reader)
+
+	for {
+		var message pullMessage
+
+		if err := decoder.Decode(&message); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			return errors.Wrap(err, "there is an error while decoding the image pull stream")
+		}
+
+		if message.ErrorDetail != nil {
+			return errors.New(message.ErrorDetail.Message)
+		}
+
+		if onProgress != nil {
+			onProgress(PullProgress{LayerId: message.Id, Status: message.Status, Current: message.ProgressDetail.Current, Total: message.ProgressDetail.Total})
+		}
 	}
+}
 
-	containerConfig := &container.Config{
-		Image: imageName,
+func splitNetworkingConfig(hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig) (*network.NetworkingConfig, map[string]*network.EndpointSettings) {
+	if networkingConfig == nil || len(networkingConfig.EndpointsConfig) <= 1 {
+		return networkingConfig, nil
 	}
 
-	container, err := dc.client.ContainerCreate(ctx, containerConfig, nil, nil, nil, containerName)
+	primaryNetwork := ""
 
-	if err != nil {
-		return "", errors.Wrapf(err, "there is an error while requesting container create through docker client. ImageName:%s", imageName)
+	if hostConfig != nil {
+		primaryNetwork = string(hostConfig.NetworkMode)
 	}
 
-	if err = dc.client.ContainerStart(ctx, container.ID, types.ContainerStartOptions{}); err != nil {
-		return "", errors.Wrapf(err, "there is an error while requesting container start through docker client. ContainerId:%s", container.ID)
+	primary := &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{}}
+	secondary := map[string]*network.EndpointSettings{}
+
+	for networkName, endpointSettings := range networkingConfig.EndpointsConfig {
+		if networkName == primaryNetwork {
+			primary.EndpointsConfig[networkName] = endpointSettings
+		} else {
+			secondary[networkName] = endpointSettings
+		}
 	}
 
-	return container.ID, nil
+	return primary, secondary
 }
 
 func (dc dockerClient) DeleteContainer(ctx context.Context, containerId string) error {
@@ -110,3 +243,51 @@ func (dc dockerClient) DeleteContainer(ctx context.Context, containerId string)
 
 	return nil
 }
+
+func (dc dockerClient) StartContainer(ctx context.Context, containerId string) error {
+	if err := dc.client.ContainerStart(ctx, containerId, types.ContainerStartOptions{}); err != nil {
+		return errors.Wrapf(err, "there is an error while requesting container start through docker client. ContainerId:%s", containerId)
+	}
+
+	return nil
+}
+
+func (dc dockerClient) StopContainer(ctx context.Context, containerId string, timeout *time.Duration) error {
+	if err := dc.client.ContainerStop(ctx, containerId, timeout); err != nil {
+		return errors.Wrapf(err, "there is an error while requesting container stop through docker client. ContainerId:%s", containerId)
+	}
+
+	return nil
+}
+
+func (dc dockerClient) RestartContainer(ctx context.Context, containerId string, timeout *time.Duration) error {
+	if err := dc.client.ContainerRestart(ctx, containerId, timeout); err != nil {
+		return errors.Wrapf(err, "there is an error while requesting container restart through docker client. ContainerId:%s", containerId)
+	}
+
+	return nil
+}
+
+func (dc dockerClient) PauseContainer(ctx context.Context, containerId string) error {
+	if err := dc.client.ContainerPause(ctx, containerId); err != nil {
+		return errors.Wrapf(err, "there is an error while requesting container pause through docker client. ContainerId:%s", containerId)
+	}
+
+	return nil
+}
+
+func (dc dockerClient) UnpauseContainer(ctx context.Context, containerId string) error {
+	if err := dc.client.ContainerUnpause(ctx, containerId); err != nil {
+		return errors.Wrapf(err, "there is an error while requesting container unpause through docker client. ContainerId:%s", containerId)
+	}
+
+	return nil
+}
+
+func (dc dockerClient) KillContainer(ctx context.Context, containerId string, signal string) error {
+	if err := dc.client.ContainerKill(ctx, containerId, signal); err != nil {
+		return errors.Wrapf(err, "there is an error while requesting container kill through docker client. ContainerId:%s Signal:%s", containerId, signal)
+	}
+
+	return nil
+}
@@ -0,0 +1,440 @@
+package docker
+
+import (
+	"context"
+	"encoding/base64"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/go-connections/nat"
+	"github.com/pkg/errors"
+)
+
+// ContainerStatuses are the values accepted by the status filter of the container list.
+var ContainerStatuses = []string{"created", "restarting", "running", "removing", "paused", "exited", "dead"}
+
+// ErrInvalidCursor is the cause of the invalid parameter error returned when a pagination cursor was not issued by ListContainers.
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// ListContainersOptions filters the container list. Without All only running containers are listed.
+// Unlike Name, which matches any part of the name, NamePrefix only keeps the containers whose name starts with it.
+// Limit zero disables pagination, otherwise at most Limit containers are returned after the position encoded in Cursor.
+type ListContainersOptions struct {
+	All        bool
+	Statuses   []string
+	Labels     []string
+	Name       string
+	NamePrefix string
+	Ancestor   string
+	Network    string
+	Limit      int
+	Cursor     string
+}
+
+// ContainerSummary is a container as it appears in the container list.
+type ContainerSummary struct {
+	Id       string            `json:"id"`
+	Name     string            `json:"name"`
+	Image    string            `json:"image"`
+	ImageId  string            `json:"imageId"`
+	Command  string            `json:"command"`
+	Created  time.Time         `json:"created"`
+	State    string            `json:"state"`
+	Status   string            `json:"status"`
+	Ports    []ContainerPort   `json:"ports"`
+	Labels   map[string]string `json:"labels"`
+	Networks []string          `json:"networks"`
+	Mounts   []ContainerMount  `json:"mounts"`
+}
+
+// ContainerDetail is the inspected state and configuration of a single container.
+type ContainerDetail struct {
+	Id           string                      `json:"id"`
+	Name         string                      `json:"name"`
+	Created      time.Time                   `json:"created"`
+	Path         string                      `json:"path"`
+	Args         []string                    `json:"args"`
+	Image        string                      `json:"image"`
+	ImageId      string                      `json:"imageId"`
+	State        ContainerState              `json:"state"`
+	RestartCount int                         `json:"restartCount"`
+	Config       ContainerConfig             `json:"config"`
+	HostConfig   ContainerHostConfig         `json:"hostConfig"`
+	Mounts       []ContainerMount            `json:"mounts"`
+	Networks     map[string]ContainerNetwork `json:"networks"`
+}
+
+type ContainerState struct {
+	Status     string    `json:"status"`
+	Running    bool      `json:"running"`
+	Paused     bool      `json:"paused"`
+	Restarting bool      `json:"restarting"`
+	OomKilled  bool      `json:"oomKilled"`
+	Dead       bool      `json:"dead"`
+	Pid        int       `json:"pid"`
+	ExitCode   int       `json:"exitCode"`
+	Error      string    `json:"error,omitempty"`
+	Health     string    `json:"health,omitempty"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+type ContainerConfig struct {
+	Hostname     string            `json:"hostname"`
+	User         string            `json:"user"`
+	Env          []string          `json:"env"`
+	Cmd          []string          `json:"cmd"`
+	Entrypoint   []string          `json:"entrypoint"`
+	WorkingDir   string            `json:"workingDir"`
+	Labels       map[string]string `json:"labels"`
+	ExposedPorts []string          `json:"exposedPorts"`
+	Tty          bool              `json:"tty"`
+}
+
+type ContainerHostConfig struct {
+	NetworkMode   string                 `json:"networkMode"`
+	RestartPolicy ContainerRestartPolicy `json:"restartPolicy"`
+	PortBindings  []ContainerPort        `json:"portBindings"`
+	Privileged    bool                   `json:"privileged"`
+	NanoCpus      int64                  `json:"nanoCpus"`
+	CpuShares     int64                  `json:"cpuShares"`
+	Memory        int64                  `json:"memory"`
+	MemorySwap    int64                  `json:"memorySwap"`
+}
+
+type ContainerRestartPolicy struct {
+	Name              string `json:"name"`
+	MaximumRetryCount int    `json:"maximumRetryCount"`
+}
+
+type ContainerPort struct {
+	PrivatePort uint16 `json:"privatePort"`
+	PublicPort  uint16 `json:"publicPort,omitempty"`
+	Type        string `json:"type"`
+	Ip          string `json:"ip,omitempty"`
+}
+
+type ContainerMount struct {
+	Type        string `json:"type"`
+	Name        string `json:"name,omitempty"`
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	ReadOnly    bool   `json:"readOnly"`
+}
+
+type ContainerNetwork struct {
+	NetworkId  string   `json:"networkId"`
+	IpAddress  string   `json:"ipAddress"`
+	Gateway    string   `json:"gateway"`
+	MacAddress string   `json:"macAddress"`
+	Aliases    []string `json:"aliases"`
+}
+
+// ListContainers returns a page of containers, newest first, and the cursor of the next page, which is empty on the last page.
+func (dc dockerClient) ListContainers(ctx context.Context, options ListContainersOptions) ([]ContainerSummary, string, error) {
+	containerFilters := filters.NewArgs()
+
+	for _, status := range options.Statuses {
+		containerFilters.Add("status", status)
+	}
+
+	for _, label := range options.Labels {
+		containerFilters.Add("label", label)
+	}
+
+	for filter, value := range map[string]string{"name": options.Name, "ancestor": options.Ancestor, "network": options.Network} {
+		if value != "" {
+			containerFilters.Add(filter, value)
+		}
+	}
+
+	containers, err := dc.client.ContainerList(ctx, types.ContainerListOptions{All: options.All, Filters: containerFilters})
+
+	if err != nil {
+		return nil, "", errors.Wrap(err, "there is an error while requesting container list through docker client")
+	}
+
+	if options.NamePrefix != "" {
+		containers = filterContainersByNamePrefix(containers, options.NamePrefix)
+	}
+
+	page, nextCursor, err := paginateContainers(containers, options.Limit, options.Cursor)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	summaries := make([]ContainerSummary, 0, len(page))
+
+	for _, container := range page {
+		summaries = append(summaries, newContainerSummary(container))
+	}
+
+	return summaries, nextCursor, nil
+}
+
+func (dc dockerClient) InspectContainer(ctx context.Context, containerId string) (ContainerDetail, error) {
+	containerJson, err := dc.client.ContainerInspect(ctx, containerId)
+
+	if err != nil {
+		return ContainerDetail{}, errors.Wrapf(err, "there is an error while requesting container inspect through docker client. ContainerId:%s", containerId)
+	}
+
+	return newContainerDetail(containerJson), nil
+}
+
+func newContainerSummary(container types.Container) ContainerSummary {
+	summary := ContainerSummary{
+		Id:       container.ID,
+		Image:    container.Image,
+		ImageId:  container.ImageID,
+		Command:  container.Command,
+		Created:  time.Unix(container.Created, 0).UTC(),
+		State:    container.State,
+		Status:   container.Status,
+		Ports:    make([]ContainerPort, 0, len(container.Ports)),
+		Labels:   container.Labels,
+		Networks: []string{},
+		Mounts:   newContainerMounts(container.Mounts),
+	}
+
+	if len(container.Names) > 0 {
+		summary.Name = strings.TrimPrefix(container.Names[0], "/")
+	}
+
+	for _, port := range container.Ports {
+		summary.Ports = append(summary.Ports, ContainerPort{PrivatePort: port.PrivatePort, PublicPort: port.PublicPort, Type: port.Type, Ip: port.IP})
+	}
+
+	if container.NetworkSettings != nil {
+		for networkName := range container.NetworkSettings.Networks {
+			summary.Networks = append(summary.Networks, networkName)
+		}
+
+		sort.Strings(summary.Networks)
+	}
+
+	return summary
+}
+
+func newContainerDetail(containerJson types.ContainerJSON) ContainerDetail {
+	detail := ContainerDetail{Mounts: newContainerMounts(containerJson.Mounts), Networks: map[string]ContainerNetwork{}}
+
+	if base := containerJson.ContainerJSONBase; base != nil {
+		detail.Id = base.ID
+		detail.Name = strings.TrimPrefix(base.Name, "/")
+		detail.Created = parseDockerTime(base.Created)
+		detail.Path = base.Path
+		detail.Args = base.Args
+		detail.ImageId = base.Image
+		detail.RestartCount = base.RestartCount
+
+		if state := base.State; state != nil {
+			detail.State = ContainerState{
+				Status:     state.Status,
+				Running:    state.Running,
+				Paused:     state.Paused,
+				Restarting: state.Restarting,
+				OomKilled:  state.OOMKilled,
+				Dead:       state.Dead,
+				Pid:        state.Pid,
+				ExitCode:   state.ExitCode,
+				Error:      state.Error,
+				StartedAt:  parseDockerTime(state.StartedAt),
+				FinishedAt: parseDockerTime(state.FinishedAt),
+			}
+
+			if state.Health != nil {
+				detail.State.Health = state.Health.Status
+			}
+		}
+
+		if hostConfig := base.HostConfig; hostConfig != nil {
+			detail.HostConfig = ContainerHostConfig{
+				NetworkMode:   string(hostConfig.NetworkMode),
+				RestartPolicy: ContainerRestartPolicy{Name: hostConfig.RestartPolicy.Name, MaximumRetryCount: hostConfig.RestartPolicy.MaximumRetryCount},
+				PortBindings:  newPortBindings(hostConfig.PortBindings),
+				Privileged:    hostConfig.Privileged,
+				NanoCpus:      hostConfig.NanoCPUs,
+				CpuShares:     hostConfig.CPUShares,
+				Memory:        hostConfig.Memory,
+				MemorySwap:    hostConfig.MemorySwap,
+			}
+		}
+	}
+
+	if config := containerJson.Config; config != nil {
+		detail.Image = config.Image
+		detail.Config = ContainerConfig{
+			Hostname:     config.Hostname,
+			User:         config.User,
+			Env:          config.Env,
+			Cmd:          config.Cmd,
+			Entrypoint:   config.Entrypoint,
+			WorkingDir:   config.WorkingDir,
+			Labels:       config.Labels,
+			ExposedPorts: newExposedPorts(config.ExposedPorts),
+			Tty:          config.Tty,
+		}
+	}
+
+	if containerJson.NetworkSettings != nil {
+		for networkName, endpoint := range containerJson.NetworkSettings.Networks {
+			if endpoint == nil {
+				continue
+			}
+
+			detail.Networks[networkName] = ContainerNetwork{
+				NetworkId:  endpoint.NetworkID,
+				IpAddress:  endpoint.IPAddress,
+				Gateway:    endpoint.Gateway,
+				MacAddress: endpoint.MacAddress,
+				Aliases:    endpoint.Aliases,
+			}
+		}
+	}
+
+	return detail
+}
+
+func newContainerMounts(mountPoints []types.MountPoint) []ContainerMount {
+	mounts := make([]ContainerMount, 0, len(mountPoints))
+
+	for _, mountPoint := range mountPoints {
+		mounts = append(mounts, ContainerMount{
+			Type:        string(mountPoint.Type),
+			Name:        mountPoint.Name,
+			Source:      mountPoint.Source,
+			Destination: mountPoint.Destination,
+			ReadOnly:    !mountPoint.RW,
+		})
+	}
+
+	return mounts
+}
+
+func newPortBindings(portMap nat.PortMap) []ContainerPort {
+	ports := []ContainerPort{}
+
+	for port, bindings := range portMap {
+		for _, binding := range bindings {
+			publicPort, _ := strconv.ParseUint(binding.HostPort, 10, 16)
+			ports = append(ports, ContainerPort{PrivatePort: uint16(port.Int()), PublicPort: uint16(publicPort), Type: port.Proto(), Ip: binding.HostIP})
+		}
+	}
+
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].PrivatePort != ports[j].PrivatePort {
+			return ports[i].PrivatePort < ports[j].PrivatePort
+		}
+
+		return ports[i].Type < ports[j].Type
+	})
+
+	return ports
+}
+
+func newExposedPorts(portSet nat.PortSet) []string {
+	ports := make([]string, 0, len(portSet))
+
+	for port := range portSet {
+		ports = append(ports, string(port))
+	}
+
+	sort.Strings(ports)
+
+	return ports
+}
+
+// parseDockerTime parses the RFC 3339 timestamps of the engine, which leaves them empty or zero for events that did not happen yet.
+func parseDockerTime(value string) time.Time {
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+
+	if err != nil {
+		return time.Time{}
+	}
+
+	return parsed
+}
+
+// filterContainersByNamePrefix keeps the containers having a name that starts with namePrefix.
+func filterContainersByNamePrefix(containers []types.Container, namePrefix string) []types.Container {
+	filtered := containers[:0]
+
+	for _, container := range containers {
+		for _, name := range container.Names {
+			if strings.HasPrefix(strings.TrimPrefix(name, "/"), namePrefix) {
+				filtered = append(filtered, container)
+				break
+			}
+		}
+	}
+
+	return filtered
+}
+
+// paginateContainers orders the containers by creation time and id so that cursors stay valid while containers come and go.
+func paginateContainers(containers []types.Container, limit int, cursor string) ([]types.Container, string, error) {
+	sort.Slice(containers, func(i, j int) bool {
+		if containers[i].Created != containers[j].Created {
+			return containers[i].Created > containers[j].Created
+		}
+
+		return containers[i].ID < containers[j].ID
+	})
+
+	start := 0
+
+	if cursor != "" {
+		created, id, err := decodeContainerCursor(cursor)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		start = sort.Search(len(containers), func(i int) bool {
+			return containers[i].Created < created || (containers[i].Created == created && containers[i].ID > id)
+		})
+	}
+
+	containers = containers[start:]
+
+	if limit <= 0 || len(containers) <= limit {
+		return containers, "", nil
+	}
+
+	last := containers[limit-1]
+
+	return containers[:limit], encodeContainerCursor(last.Created, last.ID), nil
+}
+
+func encodeContainerCursor(created int64, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(created, 10) + ":" + id))
+}
+
+func decodeContainerCursor(cursor string) (int64, string, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+
+	if err != nil {
+		return 0, "", errdefs.InvalidParameter(errors.Wrapf(ErrInvalidCursor, "cursor is not base64 encoded. Cursor:%s", cursor))
+	}
+
+	parts := strings.SplitN(string(decoded), ":", 2)
+
+	if len(parts) != 2 || parts[1] == "" {
+		return 0, "", errdefs.InvalidParameter(errors.Wrapf(ErrInvalidCursor, "cursor is malformed. Cursor:%s", cursor))
+	}
+
+	created, err := strconv.ParseInt(parts[0], 10, 64)
+
+	if err != nil {
+		return 0, "", errdefs.InvalidParameter(errors.Wrapf(ErrInvalidCursor, "cursor is malformed. Cursor:%s", cursor))
+	}
+
+	return created, parts[1], nil
+}
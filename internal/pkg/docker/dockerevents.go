@@ -0,0 +1,73 @@
+package docker
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/pkg/errors"
+)
+
+// EventTypes are the object types the docker engine reports events for.
+var EventTypes = []string{"container", "image", "volume", "network", "daemon", "plugin", "node", "service", "secret", "config"}
+
+// EventOptions selects the events to stream. Every filter accepts several values, an event matches when any of them matches.
+type EventOptions struct {
+	Types      []string
+	Actions    []string
+	Containers []string
+	Images     []string
+	Labels     []string
+	Since      string
+	Until      string
+}
+
+type Event struct {
+	Type       string            `json:"type"`
+	Action     string            `json:"action"`
+	ActorId    string            `json:"actorId"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Scope      string            `json:"scope,omitempty"`
+	Time       time.Time         `json:"time"`
+}
+
+// StreamEvents calls onEvent for every event of the engine until ctx is cancelled, or until the until time is reached.
+func (dc dockerClient) StreamEvents(ctx context.Context, options EventOptions, onEvent func(Event)) error {
+	eventFilters := filters.NewArgs()
+
+	for filter, values := range map[string][]string{
+		"type":      options.Types,
+		"event":     options.Actions,
+		"container": options.Containers,
+		"image":     options.Images,
+		"label":     options.Labels,
+	} {
+		for _, value := range values {
+			eventFilters.Add(filter, value)
+		}
+	}
+
+	messages, errs := dc.client.Events(ctx, types.EventsOptions{Since: options.Since, Until: options.Until, Filters: eventFilters})
+
+	for {
+		select {
+		case message := <-messages:
+			onEvent(Event{
+				Type:       message.Type,
+				Action:     message.Action,
+				ActorId:    message.Actor.ID,
+				Attributes: message.Actor.Attributes,
+				Scope:      message.Scope,
+				Time:       time.Unix(0, message.TimeNano).UTC(),
+			})
+		case err := <-errs:
+			if err == nil || err == io.EOF || ctx.Err() != nil {
+				return nil
+			}
+
+			return errors.Wrap(err, "there is an error while requesting events through docker client")
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/pkg/errors"
+)
+
+// maxEngineErrorSize bounds how much of an engine error response is read for its message.
+const maxEngineErrorSize = 4096
+
+type ExecOptions struct {
+	Cmd        []string
+	Env        []string
+	User       string
+	WorkingDir string
+	Tty        bool
+}
+
+// ExecState is the inspected state of an exec instance. ExitCode is only meaningful once Running is false.
+// Tty tells whether the instance was created with a TTY, which it must also be attached with.
+type ExecState struct {
+	Id          string `json:"id"`
+	ContainerId string `json:"containerId"`
+	Running     bool   `json:"running"`
+	ExitCode    int    `json:"exitCode"`
+	Pid         int    `json:"pid"`
+	Tty         bool   `json:"tty"`
+}
+
+// execInspectResponse is the exec inspect of the engine API, including the ProcessConfig the engine client leaves out.
+type execInspectResponse struct {
+	ID            string
+	ContainerID   string
+	Running       bool
+	ExitCode      int
+	Pid           int
+	ProcessConfig struct {
+		Tty bool `json:"tty"`
+	}
+}
+
+// ExecStream is an attached exec instance. Writes go to the stdin of the process.
+type ExecStream interface {
+	io.Writer
+	// ReadOutput calls onOutput with every chunk of output until the process closes its output.
+	ReadOutput(onOutput func(stream string, data []byte)) error
+	CloseStdin() error
+	Close()
+}
+
+type execStream struct {
+	hijackedResponse types.HijackedResponse
+	tty              bool
+}
+
+func (dc dockerClient) CreateExec(ctx context.Context, containerId string, options ExecOptions) (string, error) {
+	execConfig := types.ExecConfig{
+		Cmd:          options.Cmd,
+		Env:          options.Env,
+		User:         options.User,
+		WorkingDir:   options.WorkingDir,
+		Tty:          options.Tty,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+	}
+
+	response, err := dc.client.ContainerExecCreate(ctx, containerId, execConfig)
+
+	if err != nil {
+		return "", errors.Wrapf(err, "there is an error while requesting exec create through docker client. ContainerId:%s", containerId)
+	}
+
+	return response.ID, nil
+}
+
+// AttachExec starts the exec instance and attaches to its standard streams.
+func (dc dockerClient) AttachExec(ctx context.Context, execId string, tty bool) (ExecStream, error) {
+	hijackedResponse, err := dc.client.ContainerExecAttach(ctx, execId, types.ExecStartCheck{Tty: tty})
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "there is an error while requesting exec attach through docker client. ExecId:%s", execId)
+	}
+
+	return execStream{hijackedResponse: hijackedResponse, tty: tty}, nil
+}
+
+func (dc dockerClient) InspectExec(ctx context.Context, execId string) (ExecState, error) {
+	var execInspect execInspectResponse
+
+	if err := dc.getEngineJSON(ctx, "/exec/"+url.PathEscape(execId)+"/json", &execInspect); err != nil {
+		return ExecState{}, errors.Wrapf(err, "there is an error while requesting exec inspect through docker client. ExecId:%s", execId)
+	}
+
+	return ExecState{
+		Id:          execInspect.ID,
+		ContainerId: execInspect.ContainerID,
+		Running:     execInspect.Running,
+		ExitCode:    execInspect.ExitCode,
+		Pid:         execInspect.Pid,
+		Tty:         execInspect.ProcessConfig.Tty,
+	}, nil
+}
+
+// getEngineJSON sends a GET request for apiPath to the engine through the connection of the docker client
+// and decodes the JSON response into value. It is meant for the responses the engine client does not fully decode.
+func (dc dockerClient) getEngineJSON(ctx context.Context, apiPath string, value interface{}) error {
+	hostUrl, err := client.ParseHostURL(dc.client.DaemonHost())
+
+	if err != nil {
+		return errors.Wrapf(err, "docker host is not valid. Host:%s", dc.client.DaemonHost())
+	}
+
+	httpClient := dc.client.HTTPClient()
+	requestUrl := url.URL{Scheme: "http", Host: hostUrl.Host, Path: path.Join(hostUrl.Path, apiPath)}
+
+	if version := strings.TrimPrefix(dc.client.ClientVersion(), "v"); version != "" {
+		requestUrl.Path = path.Join(hostUrl.Path, "/v"+version, apiPath)
+	}
+
+	if transport, ok := httpClient.Transport.(*http.Transport); ok && transport.TLSClientConfig != nil {
+		requestUrl.Scheme = "https"
+	}
+
+	// Socket connections always dial the socket, the host of the URL only has to be a valid name.
+	if hostUrl.Scheme == "unix" || hostUrl.Scheme == "npipe" {
+		requestUrl.Host = "docker"
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, requestUrl.String(), nil)
+
+	if err != nil {
+		return errors.Wrapf(err, "there is an error while building the engine request. Path:%s", apiPath)
+	}
+
+	response, err := httpClient.Do(request)
+
+	if err != nil {
+		return errors.Wrapf(err, "there is an error while sending the engine request. Path:%s", apiPath)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		var engineError struct {
+			Message string `json:"message"`
+		}
+
+		_ = json.NewDecoder(io.LimitReader(response.Body, maxEngineErrorSize)).Decode(&engineError)
+
+		return errdefs.FromStatusCode(errors.Errorf("engine request failed. Path:%s Status:%d Message:%s", apiPath, response.StatusCode, engineError.Message), response.StatusCode)
+	}
+
+	if err = json.NewDecoder(response.Body).Decode(value); err != nil {
+		return errors.Wrapf(err, "there is an error while decoding the engine response. Path:%s", apiPath)
+	}
+
+	return nil
+}
+
+func (dc dockerClient) ResizeExec(ctx context.Context, execId string, height uint, width uint) error {
+	if err := dc.client.ContainerExecResize(ctx, execId, types.ResizeOptions{Height: height, Width: width}); err != nil {
+		return errors.Wrapf(err, "there is an error while requesting exec resize through docker client. ExecId:%s", execId)
+	}
+
+	return nil
+}
+
+func (es execStream) Write(p []byte) (int, error) {
+	return es.hijackedResponse.Conn.Write(p)
+}
+
+func (es execStream) ReadOutput(onOutput func(stream string, data []byte)) error {
+	stdoutWriter := execOutputWriter{stream: StreamStdout, onOutput: onOutput}
+	stderrWriter := execOutputWriter{stream: StreamStderr, onOutput: onOutput}
+
+	var err error
+
+	// With a TTY the output is raw, without one stdout and stderr are multiplexed into a single stream.
+	if es.tty {
+		_, err = io.Copy(stdoutWriter, es.hijackedResponse.Reader)
+	} else {
+		_, err = stdcopy.StdCopy(stdoutWriter, stderrWriter, es.hijackedResponse.Reader)
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "there is an error while reading exec output")
+	}
+
+	return nil
+}
+
+func (es execStream) CloseStdin() error {
+	return es.hijackedResponse.CloseWrite()
+}
+
+func (es execStream) Close() {
+	es.hijackedResponse.Close()
+}
+
+type execOutputWriter struct {
+	stream   string
+	onOutput func(stream string, data []byte)
+}
+
+func (ew execOutputWriter) Write(p []byte) (int, error) {
+	// The callback may keep the chunk, while the writer's buffer is reused by the caller.
+	ew.onOutput(ew.stream, append([]byte{}, p...))
+
+	return len(p), nil
+}
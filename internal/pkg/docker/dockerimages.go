@@ -0,0 +1,219 @@
+package docker
+
+import (
+	"context"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/pkg/errors"
+)
+
+// ImageSummary is an image as it appears in the image list.
+type ImageSummary struct {
+	Id          string            `json:"id"`
+	ParentId    string            `json:"parentId"`
+	RepoTags    []string          `json:"repoTags"`
+	RepoDigests []string          `json:"repoDigests"`
+	Created     time.Time         `json:"created"`
+	Size        int64             `json:"size"`
+	Containers  int64             `json:"containers"`
+	Labels      map[string]string `json:"labels"`
+}
+
+// ImageDetail is the inspected metadata and configuration of a single image.
+type ImageDetail struct {
+	Id           string      `json:"id"`
+	RepoTags     []string    `json:"repoTags"`
+	RepoDigests  []string    `json:"repoDigests"`
+	Parent       string      `json:"parent"`
+	Comment      string      `json:"comment"`
+	Created      time.Time   `json:"created"`
+	Author       string      `json:"author"`
+	Architecture string      `json:"architecture"`
+	Os           string      `json:"os"`
+	Size         int64       `json:"size"`
+	Config       ImageConfig `json:"config"`
+	Layers       []string    `json:"layers"`
+}
+
+type ImageConfig struct {
+	User         string            `json:"user"`
+	Env          []string          `json:"env"`
+	Cmd          []string          `json:"cmd"`
+	Entrypoint   []string          `json:"entrypoint"`
+	WorkingDir   string            `json:"workingDir"`
+	ExposedPorts []string          `json:"exposedPorts"`
+	Labels       map[string]string `json:"labels"`
+}
+
+type ImageHistoryItem struct {
+	Id        string    `json:"id"`
+	Created   time.Time `json:"created"`
+	CreatedBy string    `json:"createdBy"`
+	Tags      []string  `json:"tags"`
+	Size      int64     `json:"size"`
+	Comment   string    `json:"comment"`
+}
+
+// ImageDeleteItem is either an untagged reference or a deleted image layer.
+type ImageDeleteItem struct {
+	Untagged string `json:"untagged,omitempty"`
+	Deleted  string `json:"deleted,omitempty"`
+}
+
+type ImagePruneReport struct {
+	ImagesDeleted  []ImageDeleteItem `json:"imagesDeleted"`
+	SpaceReclaimed uint64            `json:"spaceReclaimed"`
+}
+
+func (dc dockerClient) ListImages(ctx context.Context, all bool, dangling bool) ([]ImageSummary, error) {
+	imageFilters := filters.NewArgs()
+
+	if dangling {
+		imageFilters.Add("dangling", "true")
+	}
+
+	images, err := dc.client.ImageList(ctx, types.ImageListOptions{All: all, Filters: imageFilters})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "there is an error while requesting image list through docker client")
+	}
+
+	summaries := make([]ImageSummary, 0, len(images))
+
+	for _, image := range images {
+		summaries = append(summaries, ImageSummary{
+			Id:          image.ID,
+			ParentId:    image.ParentID,
+			RepoTags:    image.RepoTags,
+			RepoDigests: image.RepoDigests,
+			Created:     time.Unix(image.Created, 0).UTC(),
+			Size:        image.Size,
+			Containers:  image.Containers,
+			Labels:      image.Labels,
+		})
+	}
+
+	return summaries, nil
+}
+
+func (dc dockerClient) InspectImage(ctx context.Context, imageId string) (ImageDetail, error) {
+	imageInspect, _, err := dc.client.ImageInspectWithRaw(ctx, imageId)
+
+	if err != nil {
+		return ImageDetail{}, errors.Wrapf(err, "there is an error while requesting image inspect through docker client. ImageId:%s", imageId)
+	}
+
+	detail := ImageDetail{
+		Id:           imageInspect.ID,
+		RepoTags:     imageInspect.RepoTags,
+		RepoDigests:  imageInspect.RepoDigests,
+		Parent:       imageInspect.Parent,
+		Comment:      imageInspect.Comment,
+		Created:      parseDockerTime(imageInspect.Created),
+		Author:       imageInspect.Author,
+		Architecture: imageInspect.Architecture,
+		Os:           imageInspect.Os,
+		Size:         imageInspect.Size,
+		Layers:       imageInspect.RootFS.Layers,
+	}
+
+	if config := imageInspect.Config; config != nil {
+		detail.Config = ImageConfig{
+			User:         config.User,
+			Env:          config.Env,
+			Cmd:          config.Cmd,
+			Entrypoint:   config.Entrypoint,
+			WorkingDir:   config.WorkingDir,
+			ExposedPorts: newExposedPorts(config.ExposedPorts),
+			Labels:       config.Labels,
+		}
+	}
+
+	return detail, nil
+}
+
+func (dc dockerClient) PullImage(ctx context.Context, imageReference string, onProgress func(PullProgress)) error {
+	ioReadCloser, err := dc.client.ImagePull(ctx, imageReference, types.ImagePullOptions{})
+
+	if err != nil {
+		return errors.Wrapf(err, "there is an error while pulling the image. ImageReference:%s", imageReference)
+	}
+
+	defer ioReadCloser.Close()
+
+	if err = drainPullStream(ioReadCloser, onProgress); err != nil {
+		return errors.Wrapf(err, "there is an error while pulling the image. ImageReference:%s", imageReference)
+	}
+
+	return nil
+}
+
+func (dc dockerClient) TagImage(ctx context.Context, sourceImage string, targetImage string) error {
+	if err := dc.client.ImageTag(ctx, sourceImage, targetImage); err != nil {
+		return errors.Wrapf(err, "there is an error while requesting image tag through docker client. SourceImage:%s TargetImage:%s", sourceImage, targetImage)
+	}
+
+	return nil
+}
+
+func (dc dockerClient) DeleteImage(ctx context.Context, imageId string, force bool, pruneChildren bool) ([]ImageDeleteItem, error) {
+	deletedItems, err := dc.client.ImageRemove(ctx, imageId, types.ImageRemoveOptions{Force: force, PruneChildren: pruneChildren})
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "there is an error while requesting image delete through docker client. ImageId:%s", imageId)
+	}
+
+	return newImageDeleteItems(deletedItems), nil
+}
+
+func (dc dockerClient) GetImageHistory(ctx context.Context, imageId string) ([]ImageHistoryItem, error) {
+	history, err := dc.client.ImageHistory(ctx, imageId)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "there is an error while requesting image history through docker client. ImageId:%s", imageId)
+	}
+
+	items := make([]ImageHistoryItem, 0, len(history))
+
+	for _, layer := range history {
+		items = append(items, ImageHistoryItem{
+			Id:        layer.ID,
+			Created:   time.Unix(layer.Created, 0).UTC(),
+			CreatedBy: layer.CreatedBy,
+			Tags:      layer.Tags,
+			Size:      layer.Size,
+			Comment:   layer.Comment,
+		})
+	}
+
+	return items, nil
+}
+
+// PruneImages removes dangling images, or every image without a container when all is true.
+func (dc dockerClient) PruneImages(ctx context.Context, all bool) (ImagePruneReport, error) {
+	pruneFilters := filters.NewArgs()
+
+	if all {
+		pruneFilters.Add("dangling", "false")
+	}
+
+	report, err := dc.client.ImagesPrune(ctx, pruneFilters)
+
+	if err != nil {
+		return ImagePruneReport{}, errors.Wrap(err, "there is an error while requesting image prune through docker client")
+	}
+
+	return ImagePruneReport{ImagesDeleted: newImageDeleteItems(report.ImagesDeleted), SpaceReclaimed: report.SpaceReclaimed}, nil
+}
+
+func newImageDeleteItems(deletedItems []types.ImageDeleteResponseItem) []ImageDeleteItem {
+	items := make([]ImageDeleteItem, 0, len(deletedItems))
+
+	for _, item := range deletedItems {
+		items = append(items, ImageDeleteItem{Untagged: item.Untagged, Deleted: item.Deleted})
+	}
+
+	return items
+}
@@ -0,0 +1,381 @@
+package docker
+
+import (
+	"context"
+	"time"
+
+	. "godopi/internal/pkg/logger"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+var tracer = otel.Tracer("godopi/internal/pkg/docker")
+
+var (
+	dockerCallDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "godopi",
+		Subsystem: "docker",
+		Name:      "call_duration_seconds",
+		Help:      "Latency of the DockerClient calls by method. Streaming calls last as long as the stream.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	dockerCallErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "godopi",
+		Subsystem: "docker",
+		Name:      "call_errors_total",
+		Help:      "Number of DockerClient calls that failed by method. Calls cancelled by their caller are not counted.",
+	}, []string{"method"})
+)
+
+// containerStates are reported even when no container is in them, so that the gauges drop to zero rather than disappearing.
+var containerStates = []string{"created", "restarting", "running", "removing", "paused", "exited", "dead"}
+
+// containerStateCollector counts the containers by state whenever the metrics are scraped.
+type containerStateCollector struct {
+	dockerClient DockerClient
+	timeout      time.Duration
+	description  *prometheus.Desc
+}
+
+// NewContainerStateCollector returns a collector for the number of containers on the docker host by state.
+// The containers are listed at scrape time and the gauges are left out of the scrape when listing them fails.
+func NewContainerStateCollector(dockerClient DockerClient, timeout time.Duration) prometheus.Collector {
+	return containerStateCollector{
+		dockerClient: dockerClient,
+		timeout:      timeout,
+		description:  prometheus.NewDesc("godopi_containers", "Number of containers on the docker host by state.", []string{"state"}, nil),
+	}
+}
+
+func (csc containerStateCollector) Describe(descriptions chan<- *prometheus.Desc) {
+	descriptions <- csc.description
+}
+
+func (csc containerStateCollector) Collect(metrics chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), csc.timeout)
+	defer cancel()
+
+	containers, _, err := csc.dockerClient.ListContainers(ctx, ListContainersOptions{All: true})
+
+	if err != nil {
+		Logger().Warn("Error listing the containers for the container state metrics", zap.Error(err))
+		return
+	}
+
+	counts := make(map[string]int, len(containerStates))
+
+	for _, state := range containerStates {
+		counts[state] = 0
+	}
+
+	for _, container := range containers {
+		counts[container.State]++
+	}
+
+	for state, count := range counts {
+		metrics <- prometheus.MustNewConstMetric(csc.description, prometheus.GaugeValue, float64(count), state)
+	}
+}
+
+// instrumentedDockerClient decorates every DockerClient call with startCall, which times, traces and logs it.
+type instrumentedDockerClient struct {
+	next DockerClient
+}
+
+// startCall is run before every DockerClient call. It returns the context the call runs with, which carries the span
+// of the call, and the function to run with the result of the call once it returns.
+func startCall(ctx context.Context, method string) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "docker."+method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attribute.String("docker.method", method)))
+
+	return ctx, func(err error) {
+		duration := time.Since(start)
+		dockerCallDuration.WithLabelValues(method).Observe(duration.Seconds())
+		LoggerFromContext(ctx).Debug("Docker call", zap.String("Method", method), zap.Duration("Duration", duration), zap.Error(err))
+
+		if err != nil {
+			span.RecordError(err)
+
+			if !errors.Is(err, context.Canceled) {
+				dockerCallErrors.WithLabelValues(method).Inc()
+				span.SetStatus(codes.Error, err.Error())
+			}
+		}
+
+		span.End()
+	}
+}
+
+func (ic instrumentedDockerClient) ListContainers(ctx context.Context, options ListContainersOptions) (_ []ContainerSummary, _ string, err error) {
+	ctx, finish := startCall(ctx, "ListContainers")
+	defer func() { finish(err) }()
+
+	return ic.next.ListContainers(ctx, options)
+}
+
+func (ic instrumentedDockerClient) InspectContainer(ctx context.Context, containerId string) (_ ContainerDetail, err error) {
+	ctx, finish := startCall(ctx, "InspectContainer")
+	defer func() { finish(err) }()
+
+	return ic.next.InspectContainer(ctx, containerId)
+}
+
+func (ic instrumentedDockerClient) CreateContainer(ctx context.Context, options CreateContainerOptions) (_ string, err error) {
+	ctx, finish := startCall(ctx, "CreateContainer")
+	defer func() { finish(err) }()
+
+	return ic.next.CreateContainer(ctx, options)
+}
+
+func (ic instrumentedDockerClient) DeleteContainer(ctx context.Context, containerId string) (err error) {
+	ctx, finish := startCall(ctx, "DeleteContainer")
+	defer func() { finish(err) }()
+
+	return ic.next.DeleteContainer(ctx, containerId)
+}
+
+func (ic instrumentedDockerClient) StartContainer(ctx context.Context, containerId string) (err error) {
+	ctx, finish := startCall(ctx, "StartContainer")
+	defer func() { finish(err) }()
+
+	return ic.next.StartContainer(ctx, containerId)
+}
+
+func (ic instrumentedDockerClient) StopContainer(ctx context.Context, containerId string, timeout *time.Duration) (err error) {
+	ctx, finish := startCall(ctx, "StopContainer")
+	defer func() { finish(err) }()
+
+	return ic.next.StopContainer(ctx, containerId, timeout)
+}
+
+func (ic instrumentedDockerClient) RestartContainer(ctx context.Context, containerId string, timeout *time.Duration) (err error) {
+	ctx, finish := startCall(ctx, "RestartContainer")
+	defer func() { finish(err) }()
+
+	return ic.next.RestartContainer(ctx, containerId, timeout)
+}
+
+func (ic instrumentedDockerClient) PauseContainer(ctx context.Context, containerId string) (err error) {
+	ctx, finish := startCall(ctx, "PauseContainer")
+	defer func() { finish(err) }()
+
+	return ic.next.PauseContainer(ctx, containerId)
+}
+
+func (ic instrumentedDockerClient) UnpauseContainer(ctx context.Context, containerId string) (err error) {
+	ctx, finish := startCall(ctx, "UnpauseContainer")
+	defer func() { finish(err) }()
+
+	return ic.next.UnpauseContainer(ctx, containerId)
+}
+
+func (ic instrumentedDockerClient) KillContainer(ctx context.Context, containerId string, signal string) (err error) {
+	ctx, finish := startCall(ctx, "KillContainer")
+	defer func() { finish(err) }()
+
+	return ic.next.KillContainer(ctx, containerId, signal)
+}
+
+func (ic instrumentedDockerClient) StreamContainerLogs(ctx context.Context, containerId string, options LogOptions, onLine func(LogLine)) (err error) {
+	ctx, finish := startCall(ctx, "StreamContainerLogs")
+	defer func() { finish(err) }()
+
+	return ic.next.StreamContainerLogs(ctx, containerId, options, onLine)
+}
+
+func (ic instrumentedDockerClient) GetContainerStats(ctx context.Context, containerId string) (_ ContainerStats, err error) {
+	ctx, finish := startCall(ctx, "GetContainerStats")
+	defer func() { finish(err) }()
+
+	return ic.next.GetContainerStats(ctx, containerId)
+}
+
+func (ic instrumentedDockerClient) StreamContainerStats(ctx context.Context, containerId string, onStats func(ContainerStats)) (err error) {
+	ctx, finish := startCall(ctx, "StreamContainerStats")
+	defer func() { finish(err) }()
+
+	return ic.next.StreamContainerStats(ctx, containerId, onStats)
+}
+
+func (ic instrumentedDockerClient) GetAggregatedStats(ctx context.Context) (_ AggregatedStats, err error) {
+	ctx, finish := startCall(ctx, "GetAggregatedStats")
+	defer func() { finish(err) }()
+
+	return ic.next.GetAggregatedStats(ctx)
+}
+
+func (ic instrumentedDockerClient) StreamEvents(ctx context.Context, options EventOptions, onEvent func(Event)) (err error) {
+	ctx, finish := startCall(ctx, "StreamEvents")
+	defer func() { finish(err) }()
+
+	return ic.next.StreamEvents(ctx, options, onEvent)
+}
+
+func (ic instrumentedDockerClient) CreateExec(ctx context.Context, containerId string, options ExecOptions) (_ string, err error) {
+	ctx, finish := startCall(ctx, "CreateExec")
+	defer func() { finish(err) }()
+
+	return ic.next.CreateExec(ctx, containerId, options)
+}
+
+func (ic instrumentedDockerClient) AttachExec(ctx context.Context, execId string, tty bool) (_ ExecStream, err error) {
+	ctx, finish := startCall(ctx, "AttachExec")
+	defer func() { finish(err) }()
+
+	return ic.next.AttachExec(ctx, execId, tty)
+}
+
+func (ic instrumentedDockerClient) InspectExec(ctx context.Context, execId string) (_ ExecState, err error) {
+	ctx, finish := startCall(ctx, "InspectExec")
+	defer func() { finish(err) }()
+
+	return ic.next.InspectExec(ctx, execId)
+}
+
+func (ic instrumentedDockerClient) ResizeExec(ctx context.Context, execId string, height uint, width uint) (err error) {
+	ctx, finish := startCall(ctx, "ResizeExec")
+	defer func() { finish(err) }()
+
+	return ic.next.ResizeExec(ctx, execId, height, width)
+}
+
+func (ic instrumentedDockerClient) ListImages(ctx context.Context, all bool, dangling bool) (_ []ImageSummary, err error) {
+	ctx, finish := startCall(ctx, "ListImages")
+	defer func() { finish(err) }()
+
+	return ic.next.ListImages(ctx, all, dangling)
+}
+
+func (ic instrumentedDockerClient) InspectImage(ctx context.Context, imageId string) (_ ImageDetail, err error) {
+	ctx, finish := startCall(ctx, "InspectImage")
+	defer func() { finish(err) }()
+
+	return ic.next.InspectImage(ctx, imageId)
+}
+
+func (ic instrumentedDockerClient) PullImage(ctx context.Context, imageReference string, onProgress func(PullProgress)) (err error) {
+	ctx, finish := startCall(ctx, "PullImage")
+	defer func() { finish(err) }()
+
+	return ic.next.PullImage(ctx, imageReference, onProgress)
+}
+
+func (ic instrumentedDockerClient) TagImage(ctx context.Context, sourceImage string, targetImage string) (err error) {
+	ctx, finish := startCall(ctx, "TagImage")
+	defer func() { finish(err) }()
+
+	return ic.next.TagImage(ctx, sourceImage, targetImage)
+}
+
+func (ic instrumentedDockerClient) DeleteImage(ctx context.Context, imageId string, force bool, pruneChildren bool) (_ []ImageDeleteItem, err error) {
+	ctx, finish := startCall(ctx, "DeleteImage")
+	defer func() { finish(err) }()
+
+	return ic.next.DeleteImage(ctx, imageId, force, pruneChildren)
+}
+
+func (ic instrumentedDockerClient) GetImageHistory(ctx context.Context, imageId string) (_ []ImageHistoryItem, err error) {
+	ctx, finish := startCall(ctx, "GetImageHistory")
+	defer func() { finish(err) }()
+
+	return ic.next.GetImageHistory(ctx, imageId)
+}
+
+func (ic instrumentedDockerClient) PruneImages(ctx context.Context, all bool) (_ ImagePruneReport, err error) {
+	ctx, finish := startCall(ctx, "PruneImages")
+	defer func() { finish(err) }()
+
+	return ic.next.PruneImages(ctx, all)
+}
+
+func (ic instrumentedDockerClient) ListVolumes(ctx context.Context, options ListVolumesOptions) (_ []Volume, err error) {
+	ctx, finish := startCall(ctx, "ListVolumes")
+	defer func() { finish(err) }()
+
+	return ic.next.ListVolumes(ctx, options)
+}
+
+func (ic instrumentedDockerClient) CreateVolume(ctx context.Context, options CreateVolumeOptions) (_ Volume, err error) {
+	ctx, finish := startCall(ctx, "CreateVolume")
+	defer func() { finish(err) }()
+
+	return ic.next.CreateVolume(ctx, options)
+}
+
+func (ic instrumentedDockerClient) InspectVolume(ctx context.Context, volumeName string) (_ Volume, err error) {
+	ctx, finish := startCall(ctx, "InspectVolume")
+	defer func() { finish(err) }()
+
+	return ic.next.InspectVolume(ctx, volumeName)
+}
+
+func (ic instrumentedDockerClient) DeleteVolume(ctx context.Context, volumeName string, force bool) (err error) {
+	ctx, finish := startCall(ctx, "DeleteVolume")
+	defer func() { finish(err) }()
+
+	return ic.next.DeleteVolume(ctx, volumeName, force)
+}
+
+func (ic instrumentedDockerClient) PruneVolumes(ctx context.Context, labels []string) (_ VolumePruneReport, err error) {
+	ctx, finish := startCall(ctx, "PruneVolumes")
+	defer func() { finish(err) }()
+
+	return ic.next.PruneVolumes(ctx, labels)
+}
+
+func (ic instrumentedDockerClient) ListNetworks(ctx context.Context, options ListNetworksOptions) (_ []Network, err error) {
+	ctx, finish := startCall(ctx, "ListNetworks")
+	defer func() { finish(err) }()
+
+	return ic.next.ListNetworks(ctx, options)
+}
+
+func (ic instrumentedDockerClient) InspectNetwork(ctx context.Context, networkId string) (_ Network, err error) {
+	ctx, finish := startCall(ctx, "InspectNetwork")
+	defer func() { finish(err) }()
+
+	return ic.next.InspectNetwork(ctx, networkId)
+}
+
+func (ic instrumentedDockerClient) CreateNetwork(ctx context.Context, options CreateNetworkOptions) (_ Network, err error) {
+	ctx, finish := startCall(ctx, "CreateNetwork")
+	defer func() { finish(err) }()
+
+	return ic.next.CreateNetwork(ctx, options)
+}
+
+func (ic instrumentedDockerClient) DeleteNetwork(ctx context.Context, networkId string) (err error) {
+	ctx, finish := startCall(ctx, "DeleteNetwork")
+	defer func() { finish(err) }()
+
+	return ic.next.DeleteNetwork(ctx, networkId)
+}
+
+func (ic instrumentedDockerClient) PruneNetworks(ctx context.Context, labels []string) (_ NetworkPruneReport, err error) {
+	ctx, finish := startCall(ctx, "PruneNetworks")
+	defer func() { finish(err) }()
+
+	return ic.next.PruneNetworks(ctx, labels)
+}
+
+func (ic instrumentedDockerClient) ConnectNetwork(ctx context.Context, networkId string, containerId string, options ConnectNetworkOptions) (err error) {
+	ctx, finish := startCall(ctx, "ConnectNetwork")
+	defer func() { finish(err) }()
+
+	return ic.next.ConnectNetwork(ctx, networkId, containerId, options)
+}
+
+func (ic instrumentedDockerClient) DisconnectNetwork(ctx context.Context, networkId string, containerId string, force bool) (err error) {
+	ctx, finish := startCall(ctx, "DisconnectNetwork")
+	defer func() { finish(err) }()
+
+	return ic.next.DisconnectNetwork(ctx, networkId, containerId, force)
+}
@@ -0,0 +1,117 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/pkg/errors"
+)
+
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// LogOptions select the logs to read. Tty has to be the tty mode of the container, which decides how the engine frames the logs.
+type LogOptions struct {
+	Stdout     bool
+	Stderr     bool
+	Tail       string
+	Since      string
+	Until      string
+	Timestamps bool
+	Follow     bool
+	Tty        bool
+}
+
+type LogLine struct {
+	Stream    string `json:"stream"`
+	Timestamp string `json:"timestamp,omitempty"`
+	Text      string `json:"text"`
+}
+
+// StreamContainerLogs calls onLine for every log line of the container until the logs end,
+// or until ctx is cancelled when following.
+func (dc dockerClient) StreamContainerLogs(ctx context.Context, containerId string, options LogOptions, onLine func(LogLine)) error {
+	ioReadCloser, err := dc.client.ContainerLogs(ctx, containerId, types.ContainerLogsOptions{
+		ShowStdout: options.Stdout,
+		ShowStderr: options.Stderr,
+		Tail:       options.Tail,
+		Since:      options.Since,
+		Until:      options.Until,
+		Timestamps: options.Timestamps,
+		Follow:     options.Follow,
+	})
+
+	if err != nil {
+		return errors.Wrapf(err, "there is an error while requesting container logs through docker client. ContainerId:%s", containerId)
+	}
+
+	defer ioReadCloser.Close()
+
+	stdoutWriter := &logLineWriter{stream: StreamStdout, timestamps: options.Timestamps, onLine: onLine}
+	stderrWriter := &logLineWriter{stream: StreamStderr, timestamps: options.Timestamps, onLine: onLine}
+
+	// Containers with a TTY send raw output, the others multiplex stdout and stderr into a single stream.
+	if options.Tty {
+		_, err = io.Copy(stdoutWriter, ioReadCloser)
+	} else {
+		_, err = stdcopy.StdCopy(stdoutWriter, stderrWriter, ioReadCloser)
+	}
+
+	stdoutWriter.flush()
+	stderrWriter.flush()
+
+	if err != nil && ctx.Err() == nil {
+		return errors.Wrapf(err, "there is an error while reading container logs. ContainerId:%s", containerId)
+	}
+
+	return nil
+}
+
+// logLineWriter splits what is written to it into lines tagged with the stream they belong to.
+type logLineWriter struct {
+	stream     string
+	timestamps bool
+	onLine     func(LogLine)
+	pending    []byte
+}
+
+func (lw *logLineWriter) Write(p []byte) (int, error) {
+	lw.pending = append(lw.pending, p...)
+
+	for {
+		index := bytes.IndexByte(lw.pending, '\n')
+
+		if index < 0 {
+			return len(p), nil
+		}
+
+		lw.emit(string(lw.pending[:index]))
+		lw.pending = lw.pending[index+1:]
+	}
+}
+
+func (lw *logLineWriter) flush() {
+	if len(lw.pending) > 0 {
+		lw.emit(string(lw.pending))
+		lw.pending = nil
+	}
+}
+
+func (lw *logLineWriter) emit(text string) {
+	line := LogLine{Stream: lw.stream, Text: strings.TrimSuffix(text, "\r")}
+
+	// The engine prefixes every line with an RFC3339Nano timestamp followed by a space when timestamps are requested.
+	if lw.timestamps {
+		if index := strings.IndexByte(line.Text, ' '); index > 0 {
+			line.Timestamp, line.Text = line.Text[:index], line.Text[index+1:]
+		}
+	}
+
+	lw.onLine(line)
+}
@@ -0,0 +1,228 @@
+package docker
+
+import (
+	"context"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/pkg/errors"
+)
+
+// ListNetworksOptions filters the network list. Type selects either the "builtin" or the "custom" networks when it is set.
+type ListNetworksOptions struct {
+	Name   string
+	Driver string
+	Labels []string
+	Type   string
+}
+
+type CreateNetworkOptions struct {
+	Name       string
+	Driver     string
+	Internal   bool
+	Attachable bool
+	Labels     map[string]string
+	Options    map[string]string
+	Ipam       []NetworkIpamConfig
+}
+
+// ConnectNetworkOptions configures the endpoint of a container on a network.
+// A static IPv4Address is only accepted on networks created with a user-defined subnet.
+type ConnectNetworkOptions struct {
+	Aliases     []string
+	IPv4Address string
+}
+
+type Network struct {
+	Id         string                     `json:"id"`
+	Name       string                     `json:"name"`
+	Driver     string                     `json:"driver"`
+	Scope      string                     `json:"scope"`
+	CreatedAt  time.Time                  `json:"createdAt"`
+	Internal   bool                       `json:"internal"`
+	Attachable bool                       `json:"attachable"`
+	Ipam       []NetworkIpamConfig        `json:"ipam"`
+	Labels     map[string]string          `json:"labels"`
+	Options    map[string]string          `json:"options"`
+	Containers map[string]NetworkEndpoint `json:"containers"`
+}
+
+type NetworkIpamConfig struct {
+	Subnet  string `json:"subnet"`
+	Gateway string `json:"gateway,omitempty"`
+	IpRange string `json:"ipRange,omitempty"`
+}
+
+type NetworkEndpoint struct {
+	Name        string `json:"name"`
+	EndpointId  string `json:"endpointId"`
+	MacAddress  string `json:"macAddress"`
+	IPv4Address string `json:"ipv4Address"`
+	IPv6Address string `json:"ipv6Address"`
+}
+
+type NetworkPruneReport struct {
+	NetworksDeleted []string `json:"networksDeleted"`
+}
+
+// ListNetworks lists the networks. The engine does not report the connected containers in the list, only on inspect.
+func (dc dockerClient) ListNetworks(ctx context.Context, options ListNetworksOptions) ([]Network, error) {
+	networkFilters := filters.NewArgs()
+
+	for _, label := range options.Labels {
+		networkFilters.Add("label", label)
+	}
+
+	for filter, value := range map[string]string{"name": options.Name, "driver": options.Driver, "type": options.Type} {
+		if value != "" {
+			networkFilters.Add(filter, value)
+		}
+	}
+
+	networkList, err := dc.client.NetworkList(ctx, types.NetworkListOptions{Filters: networkFilters})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "there is an error while requesting network list through docker client")
+	}
+
+	networks := make([]Network, 0, len(networkList))
+
+	for _, networkResource := range networkList {
+		networks = append(networks, newNetwork(networkResource))
+	}
+
+	return networks, nil
+}
+
+func (dc dockerClient) InspectNetwork(ctx context.Context, networkId string) (Network, error) {
+	networkResource, err := dc.client.NetworkInspect(ctx, networkId, types.NetworkInspectOptions{})
+
+	if err != nil {
+		return Network{}, errors.Wrapf(err, "there is an error while requesting network inspect through docker client. NetworkId:%s", networkId)
+	}
+
+	return newNetwork(networkResource), nil
+}
+
+// CreateNetwork creates a network and returns it as inspected right after creation.
+// Unlike the engine default, a network whose name is already taken is rejected.
+func (dc dockerClient) CreateNetwork(ctx context.Context, options CreateNetworkOptions) (Network, error) {
+	networkCreate := types.NetworkCreate{
+		CheckDuplicate: true,
+		Driver:         options.Driver,
+		Internal:       options.Internal,
+		Attachable:     options.Attachable,
+		Labels:         options.Labels,
+		Options:        options.Options,
+	}
+
+	if len(options.Ipam) > 0 {
+		networkCreate.IPAM = &network.IPAM{}
+
+		for _, ipamConfig := range options.Ipam {
+			networkCreate.IPAM.Config = append(networkCreate.IPAM.Config, network.IPAMConfig{
+				Subnet:  ipamConfig.Subnet,
+				Gateway: ipamConfig.Gateway,
+				IPRange: ipamConfig.IpRange,
+			})
+		}
+	}
+
+	response, err := dc.client.NetworkCreate(ctx, options.Name, networkCreate)
+
+	if err != nil {
+		return Network{}, errors.Wrapf(err, "there is an error while requesting network create through docker client. NetworkName:%s", options.Name)
+	}
+
+	return dc.InspectNetwork(ctx, response.ID)
+}
+
+func (dc dockerClient) DeleteNetwork(ctx context.Context, networkId string) error {
+	if err := dc.client.NetworkRemove(ctx, networkId); err != nil {
+		return errors.Wrapf(err, "there is an error while requesting network delete through docker client. NetworkId:%s", networkId)
+	}
+
+	return nil
+}
+
+// PruneNetworks removes the custom networks that no container is connected to, restricted to the given labels when there are any.
+func (dc dockerClient) PruneNetworks(ctx context.Context, labels []string) (NetworkPruneReport, error) {
+	pruneFilters := filters.NewArgs()
+
+	for _, label := range labels {
+		pruneFilters.Add("label", label)
+	}
+
+	report, err := dc.client.NetworksPrune(ctx, pruneFilters)
+
+	if err != nil {
+		return NetworkPruneReport{}, errors.Wrap(err, "there is an error while requesting network prune through docker client")
+	}
+
+	networksDeleted := report.NetworksDeleted
+
+	if networksDeleted == nil {
+		networksDeleted = []string{}
+	}
+
+	return NetworkPruneReport{NetworksDeleted: networksDeleted}, nil
+}
+
+func (dc dockerClient) ConnectNetwork(ctx context.Context, networkId string, containerId string, options ConnectNetworkOptions) error {
+	endpointSettings := &network.EndpointSettings{Aliases: options.Aliases}
+
+	if options.IPv4Address != "" {
+		endpointSettings.IPAMConfig = &network.EndpointIPAMConfig{IPv4Address: options.IPv4Address}
+	}
+
+	if err := dc.client.NetworkConnect(ctx, networkId, containerId, endpointSettings); err != nil {
+		return errors.Wrapf(err, "there is an error while requesting network connect through docker client. NetworkId:%s ContainerId:%s", networkId, containerId)
+	}
+
+	return nil
+}
+
+// DisconnectNetwork disconnects a container from a network. With force the endpoint is removed even if the container is not running.
+func (dc dockerClient) DisconnectNetwork(ctx context.Context, networkId string, containerId string, force bool) error {
+	if err := dc.client.NetworkDisconnect(ctx, networkId, containerId, force); err != nil {
+		return errors.Wrapf(err, "there is an error while requesting network disconnect through docker client. NetworkId:%s ContainerId:%s", networkId, containerId)
+	}
+
+	return nil
+}
+
+func newNetwork(networkResource types.NetworkResource) Network {
+	ipam := make([]NetworkIpamConfig, 0, len(networkResource.IPAM.Config))
+
+	for _, ipamConfig := range networkResource.IPAM.Config {
+		ipam = append(ipam, NetworkIpamConfig{Subnet: ipamConfig.Subnet, Gateway: ipamConfig.Gateway, IpRange: ipamConfig.IPRange})
+	}
+
+	containers := make(map[string]NetworkEndpoint, len(networkResource.Containers))
+
+	for containerId, endpoint := range networkResource.Containers {
+		containers[containerId] = NetworkEndpoint{
+			Name:        endpoint.Name,
+			EndpointId:  endpoint.EndpointID,
+			MacAddress:  endpoint.MacAddress,
+			IPv4Address: endpoint.IPv4Address,
+			IPv6Address: endpoint.IPv6Address,
+		}
+	}
+
+	return Network{
+		Id:         networkResource.ID,
+		Name:       networkResource.Name,
+		Driver:     networkResource.Driver,
+		Scope:      networkResource.Scope,
+		CreatedAt:  networkResource.Created,
+		Internal:   networkResource.Internal,
+		Attachable: networkResource.Attachable,
+		Ipam:       ipam,
+		Labels:     networkResource.Labels,
+		Options:    networkResource.Options,
+		Containers: containers,
+	}
+}
@@ -0,0 +1,192 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/pkg/errors"
+)
+
+// maxConcurrentStats bounds the stats calls the aggregation has in flight, each of which takes a second or two to sample.
+const maxConcurrentStats = 12
+
+// ContainerStats is a resource usage sample of a container computed from the raw stats of the docker engine.
+type ContainerStats struct {
+	ContainerId   string    `json:"containerId"`
+	Name          string    `json:"name"`
+	Read          time.Time `json:"read"`
+	CpuPercent    float64   `json:"cpuPercent"`
+	MemoryUsage   uint64    `json:"memoryUsage"`
+	MemoryLimit   uint64    `json:"memoryLimit"`
+	MemoryPercent float64   `json:"memoryPercent"`
+	NetworkRx     uint64    `json:"networkRx"`
+	NetworkTx     uint64    `json:"networkTx"`
+	BlockRead     uint64    `json:"blockRead"`
+	BlockWrite    uint64    `json:"blockWrite"`
+	Pids          uint64    `json:"pids"`
+}
+
+type AggregatedStats struct {
+	Containers    []ContainerStats `json:"containers"`
+	CpuPercent    float64          `json:"cpuPercent"`
+	MemoryUsage   uint64           `json:"memoryUsage"`
+	NetworkRx     uint64           `json:"networkRx"`
+	NetworkTx     uint64           `json:"networkTx"`
+	BlockRead     uint64           `json:"blockRead"`
+	BlockWrite    uint64           `json:"blockWrite"`
+	Pids          uint64           `json:"pids"`
+	FailedToFetch []string         `json:"failedToFetch,omitempty"`
+}
+
+// GetContainerStats returns a single sample. The engine waits for a second sample internally so that the CPU usage can be computed.
+func (dc dockerClient) GetContainerStats(ctx context.Context, containerId string) (ContainerStats, error) {
+	stats, err := dc.client.ContainerStats(ctx, containerId, false)
+
+	if err != nil {
+		return ContainerStats{}, errors.Wrapf(err, "there is an error while requesting container stats through docker client. ContainerId:%s", containerId)
+	}
+
+	defer stats.Body.Close()
+
+	var rawStats types.StatsJSON
+
+	if err = json.NewDecoder(stats.Body).Decode(&rawStats); err != nil {
+		return ContainerStats{}, errors.Wrapf(err, "there is an error while decoding container stats. ContainerId:%s", containerId)
+	}
+
+	return computeContainerStats(rawStats), nil
+}
+
+// StreamContainerStats calls onStats with every sample the engine sends, roughly once a second, until ctx is cancelled.
+func (dc dockerClient) StreamContainerStats(ctx context.Context, containerId string, onStats func(ContainerStats)) error {
+	stats, err := dc.client.ContainerStats(ctx, containerId, true)
+
+	if err != nil {
+		return errors.Wrapf(err, "there is an error while requesting container stats through docker client. ContainerId:%s", containerId)
+	}
+
+	defer stats.Body.Close()
+
+	decoder := json.NewDecoder(stats.Body)
+
+	for {
+		var rawStats types.StatsJSON
+
+		if err = decoder.Decode(&rawStats); err != nil {
+			if err == io.EOF || ctx.Err() != nil {
+				return nil
+			}
+
+			return errors.Wrapf(err, "there is an error while decoding container stats. ContainerId:%s", containerId)
+		}
+
+		onStats(computeContainerStats(rawStats))
+	}
+}
+
+// GetAggregatedStats samples the running containers, up to maxConcurrentStats at a time, and sums up their usage.
+func (dc dockerClient) GetAggregatedStats(ctx context.Context) (AggregatedStats, error) {
+	containers, err := dc.client.ContainerList(ctx, types.ContainerListOptions{})
+
+	if err != nil {
+		return AggregatedStats{}, errors.Wrap(err, "there is an error while requesting container list through docker client")
+	}
+
+	aggregatedStats := AggregatedStats{Containers: []ContainerStats{}}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	semaphore := make(chan struct{}, maxConcurrentStats)
+
+	for _, c := range containers {
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func(containerId string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			stats, err := dc.GetContainerStats(ctx, containerId)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			// Containers may stop between listing and sampling, they are reported instead of failing the whole request.
+			if err != nil {
+				aggregatedStats.FailedToFetch = append(aggregatedStats.FailedToFetch, containerId)
+				return
+			}
+
+			aggregatedStats.Containers = append(aggregatedStats.Containers, stats)
+			aggregatedStats.CpuPercent += stats.CpuPercent
+			aggregatedStats.MemoryUsage += stats.MemoryUsage
+			aggregatedStats.NetworkRx += stats.NetworkRx
+			aggregatedStats.NetworkTx += stats.NetworkTx
+			aggregatedStats.BlockRead += stats.BlockRead
+			aggregatedStats.BlockWrite += stats.BlockWrite
+			aggregatedStats.Pids += stats.Pids
+		}(c.ID)
+	}
+
+	wg.Wait()
+
+	return aggregatedStats, nil
+}
+
+// computeContainerStats derives the figures shown by the docker cli from the raw cgroup counters.
+func computeContainerStats(rawStats types.StatsJSON) ContainerStats {
+	stats := ContainerStats{
+		ContainerId: rawStats.ID,
+		Name:        strings.TrimPrefix(rawStats.Name, "/"),
+		Read:        rawStats.Read,
+		MemoryLimit: rawStats.MemoryStats.Limit,
+		Pids:        rawStats.PidsStats.Current,
+	}
+
+	cpuDelta := float64(rawStats.CPUStats.CPUUsage.TotalUsage) - float64(rawStats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(rawStats.CPUStats.SystemUsage) - float64(rawStats.PreCPUStats.SystemUsage)
+	onlineCpus := float64(rawStats.CPUStats.OnlineCPUs)
+
+	if onlineCpus == 0 {
+		onlineCpus = float64(len(rawStats.CPUStats.CPUUsage.PercpuUsage))
+	}
+
+	if cpuDelta > 0 && systemDelta > 0 {
+		stats.CpuPercent = cpuDelta / systemDelta * onlineCpus * 100
+	}
+
+	// Inactive page cache is reclaimable, so it is not counted as used memory, the same way the docker cli does.
+	stats.MemoryUsage = rawStats.MemoryStats.Usage
+
+	if cache, ok := rawStats.MemoryStats.Stats["total_inactive_file"]; ok && cache < stats.MemoryUsage {
+		stats.MemoryUsage -= cache
+	} else if cache, ok := rawStats.MemoryStats.Stats["inactive_file"]; ok && cache < stats.MemoryUsage {
+		stats.MemoryUsage -= cache
+	}
+
+	if stats.MemoryLimit > 0 {
+		stats.MemoryPercent = float64(stats.MemoryUsage) / float64(stats.MemoryLimit) * 100
+	}
+
+	for _, networkStats := range rawStats.Networks {
+		stats.NetworkRx += networkStats.RxBytes
+		stats.NetworkTx += networkStats.TxBytes
+	}
+
+	for _, entry := range rawStats.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			stats.BlockRead += entry.Value
+		case "write":
+			stats.BlockWrite += entry.Value
+		}
+	}
+
+	return stats
+}
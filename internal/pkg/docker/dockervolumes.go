@@ -0,0 +1,145 @@
+package docker
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	volumetypes "github.com/docker/docker/api/types/volume"
+	"github.com/pkg/errors"
+)
+
+// ListVolumesOptions filters the volume list. Dangling selects the volumes that are, or are not, used by any container when it is set.
+type ListVolumesOptions struct {
+	Name     string
+	Driver   string
+	Labels   []string
+	Dangling *bool
+}
+
+type CreateVolumeOptions struct {
+	Name       string
+	Driver     string
+	DriverOpts map[string]string
+	Labels     map[string]string
+}
+
+type Volume struct {
+	Name       string            `json:"name"`
+	Driver     string            `json:"driver"`
+	Mountpoint string            `json:"mountpoint"`
+	Scope      string            `json:"scope"`
+	CreatedAt  time.Time         `json:"createdAt"`
+	Labels     map[string]string `json:"labels"`
+	Options    map[string]string `json:"options"`
+}
+
+type VolumePruneReport struct {
+	VolumesDeleted []string `json:"volumesDeleted"`
+	SpaceReclaimed uint64   `json:"spaceReclaimed"`
+}
+
+func (dc dockerClient) ListVolumes(ctx context.Context, options ListVolumesOptions) ([]Volume, error) {
+	volumeFilters := filters.NewArgs()
+
+	for _, label := range options.Labels {
+		volumeFilters.Add("label", label)
+	}
+
+	for filter, value := range map[string]string{"name": options.Name, "driver": options.Driver} {
+		if value != "" {
+			volumeFilters.Add(filter, value)
+		}
+	}
+
+	if options.Dangling != nil {
+		volumeFilters.Add("dangling", strconv.FormatBool(*options.Dangling))
+	}
+
+	volumeList, err := dc.client.VolumeList(ctx, volumeFilters)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "there is an error while requesting volume list through docker client")
+	}
+
+	volumes := make([]Volume, 0, len(volumeList.Volumes))
+
+	for _, volume := range volumeList.Volumes {
+		if volume != nil {
+			volumes = append(volumes, newVolume(*volume))
+		}
+	}
+
+	return volumes, nil
+}
+
+func (dc dockerClient) CreateVolume(ctx context.Context, options CreateVolumeOptions) (Volume, error) {
+	volume, err := dc.client.VolumeCreate(ctx, volumetypes.VolumeCreateBody{
+		Name:       options.Name,
+		Driver:     options.Driver,
+		DriverOpts: options.DriverOpts,
+		Labels:     options.Labels,
+	})
+
+	if err != nil {
+		return Volume{}, errors.Wrapf(err, "there is an error while requesting volume create through docker client. VolumeName:%s", options.Name)
+	}
+
+	return newVolume(volume), nil
+}
+
+func (dc dockerClient) InspectVolume(ctx context.Context, volumeName string) (Volume, error) {
+	volume, err := dc.client.VolumeInspect(ctx, volumeName)
+
+	if err != nil {
+		return Volume{}, errors.Wrapf(err, "there is an error while requesting volume inspect through docker client. VolumeName:%s", volumeName)
+	}
+
+	return newVolume(volume), nil
+}
+
+// DeleteVolume removes a volume. The engine refuses to remove a volume that is in use unless force is true.
+func (dc dockerClient) DeleteVolume(ctx context.Context, volumeName string, force bool) error {
+	if err := dc.client.VolumeRemove(ctx, volumeName, force); err != nil {
+		return errors.Wrapf(err, "there is an error while requesting volume delete through docker client. VolumeName:%s", volumeName)
+	}
+
+	return nil
+}
+
+// PruneVolumes removes the local volumes that are not used by any container, restricted to the given labels when there are any.
+func (dc dockerClient) PruneVolumes(ctx context.Context, labels []string) (VolumePruneReport, error) {
+	pruneFilters := filters.NewArgs()
+
+	for _, label := range labels {
+		pruneFilters.Add("label", label)
+	}
+
+	report, err := dc.client.VolumesPrune(ctx, pruneFilters)
+
+	if err != nil {
+		return VolumePruneReport{}, errors.Wrap(err, "there is an error while requesting volume prune through docker client")
+	}
+
+	volumesDeleted := report.VolumesDeleted
+
+	if volumesDeleted == nil {
+		volumesDeleted = []string{}
+	}
+
+	return VolumePruneReport{VolumesDeleted: volumesDeleted, SpaceReclaimed: report.SpaceReclaimed}, nil
+}
+
+func newVolume(volume types.Volume) Volume {
+	return Volume{
+		Name:       volume.Name,
+		Driver:     volume.Driver,
+		Mountpoint: volume.Mountpoint,
+		Scope:      volume.Scope,
+		CreatedAt:  parseDockerTime(volume.CreatedAt),
+		Labels:     volume.Labels,
+		Options:    volume.Options,
+	}
+}
@@ -0,0 +1,202 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	. "godopi/internal/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+type Status string
+
+const (
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// finishedJobRetention is how long a finished job stays queryable before it is purged.
+const finishedJobRetention = time.Hour
+
+// Progress is the latest known state of a single unit of work of a job, e.g. an image layer.
+type Progress struct {
+	Id      string `json:"id"`
+	Status  string `json:"status"`
+	Current int64  `json:"current,omitempty"`
+	Total   int64  `json:"total,omitempty"`
+}
+
+// Snapshot is the state of a job. Owner is the subject of the principal that started it, empty without authentication.
+type Snapshot struct {
+	Id         string     `json:"id"`
+	Type       string     `json:"type"`
+	Target     string     `json:"target"`
+	Owner      string     `json:"owner,omitempty"`
+	Status     Status     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	Progress   []Progress `json:"progress"`
+}
+
+// RunFunc does the work of a job and reports its progress through report.
+type RunFunc func(ctx context.Context, report func(Progress)) error
+
+type JobManager interface {
+	Start(jobType string, target string, owner string, run RunFunc) Snapshot
+	Get(id string) (*Job, bool)
+}
+
+type jobManager struct {
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	timeout time.Duration
+}
+
+type Job struct {
+	mu          sync.Mutex
+	snapshot    Snapshot
+	progress    map[string]int
+	subscribers map[chan Progress]struct{}
+	done        chan struct{}
+}
+
+// NewJobManager returns an in-memory job manager. Every job is cancelled once it runs longer than timeout.
+func NewJobManager(timeout time.Duration) JobManager {
+	Logger().Info("Constructing new job manager..")
+
+	return &jobManager{jobs: map[string]*Job{}, timeout: timeout}
+}
+
+func (jm *jobManager) Start(jobType string, target string, owner string, run RunFunc) Snapshot {
+	job := &Job{
+		snapshot:    Snapshot{Id: newJobId(), Type: jobType, Target: target, Owner: owner, Status: StatusRunning, CreatedAt: time.Now().UTC(), Progress: []Progress{}},
+		progress:    map[string]int{},
+		subscribers: map[chan Progress]struct{}{},
+		done:        make(chan struct{}),
+	}
+
+	jm.mu.Lock()
+	jm.purgeFinishedJobs()
+	jm.jobs[job.snapshot.Id] = job
+	jm.mu.Unlock()
+
+	go func() {
+		// Jobs outlive the request that started them, so they get their own context.
+		ctx, cancel := context.WithTimeout(context.Background(), jm.timeout)
+		defer cancel()
+
+		err := run(ctx, job.report)
+
+		if err != nil {
+			Logger().Error("Job failed", zap.String("JobId", job.snapshot.Id), zap.String("Type", jobType), zap.Error(err))
+		}
+
+		job.finish(err)
+	}()
+
+	return job.Snapshot()
+}
+
+func (jm *jobManager) Get(id string) (*Job, bool) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	job, ok := jm.jobs[id]
+
+	return job, ok
+}
+
+func (jm *jobManager) purgeFinishedJobs() {
+	for id, job := range jm.jobs {
+		snapshot := job.Snapshot()
+
+		if snapshot.FinishedAt != nil && time.Since(*snapshot.FinishedAt) > finishedJobRetention {
+			delete(jm.jobs, id)
+		}
+	}
+}
+
+func (j *Job) Snapshot() Snapshot {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	snapshot := j.snapshot
+	snapshot.Progress = append([]Progress{}, j.snapshot.Progress...)
+
+	return snapshot
+}
+
+// Done is closed once the job has finished.
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Subscribe returns the progress known so far and a channel receiving further progress until the job finishes.
+// Slow subscribers miss intermediate progress rather than blocking the job. The returned func must be called to unsubscribe.
+func (j *Job) Subscribe() ([]Progress, <-chan Progress, func()) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	updates := make(chan Progress, 64)
+	j.subscribers[updates] = struct{}{}
+
+	unsubscribe := func() {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+
+		delete(j.subscribers, updates)
+	}
+
+	return append([]Progress{}, j.snapshot.Progress...), updates, unsubscribe
+}
+
+func (j *Job) report(progress Progress) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if index, ok := j.progress[progress.Id]; ok {
+		j.snapshot.Progress[index] = progress
+	} else {
+		j.progress[progress.Id] = len(j.snapshot.Progress)
+		j.snapshot.Progress = append(j.snapshot.Progress, progress)
+	}
+
+	for subscriber := range j.subscribers {
+		select {
+		case subscriber <- progress:
+		default:
+		}
+	}
+}
+
+func (j *Job) finish(err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	finishedAt := time.Now().UTC()
+	j.snapshot.FinishedAt = &finishedAt
+	j.snapshot.Status = StatusSucceeded
+
+	if err != nil {
+		j.snapshot.Status = StatusFailed
+		j.snapshot.Error = err.Error()
+	}
+
+	close(j.done)
+}
+
+func newJobId() string {
+	bytes := make([]byte, 16)
+
+	if _, err := rand.Read(bytes); err != nil {
+		Logger().Fatal("Encountered an error while generating a job id! Error:", zap.Error(err))
+	}
+
+	return hex.EncodeToString(bytes)
+}
@@ -1,15 +1,23 @@
 package logger
 
 import (
+	"context"
+
 	"go.uber.org/zap"
 )
 
 var logger *zap.Logger
 
+var level zap.AtomicLevel
+
+type loggerKey struct{}
+
 func init() {
 	cfg := zap.NewProductionConfig()
 	cfg.DisableStacktrace = false
 
+	level = cfg.Level
+
 	var err error
 
 	if logger, err = cfg.Build(); err != nil {
@@ -20,3 +28,22 @@ func init() {
 func Logger() *zap.Logger {
 	return logger
 }
+
+// SetLevel changes the minimum level of every logger, e.g. debug, info or warn.
+func SetLevel(name string) error {
+	return level.UnmarshalText([]byte(name))
+}
+
+// WithLogger returns a copy of ctx carrying the logger, usually one with the fields identifying a request.
+func WithLogger(ctx context.Context, requestLogger *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, requestLogger)
+}
+
+// LoggerFromContext returns the logger carried by ctx, or the global logger when there is none.
+func LoggerFromContext(ctx context.Context) *zap.Logger {
+	if requestLogger, ok := ctx.Value(loggerKey{}).(*zap.Logger); ok {
+		return requestLogger
+	}
+
+	return logger
+}
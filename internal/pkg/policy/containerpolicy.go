@@ -0,0 +1,353 @@
+package policy
+
+import (
+	"fmt"
+	"godopi/internal/pkg/docker"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	RuleImageReference = "image-reference"
+	RuleImageAllowed   = "image-allowed"
+	RuleImageDenied    = "image-denied"
+	RuleImageDigest    = "image-digest"
+	RulePrivileged     = "privileged"
+	RuleHostNetwork    = "host-network"
+	RuleHostPathMount  = "host-path-mount"
+	RuleRequiredLabel  = "required-label"
+	RuleMaxCpus        = "max-cpus"
+	RuleMaxMemory      = "max-memory"
+)
+
+// hostNetworkName is the network of the docker host, attaching to it shares the network stack of the host.
+const hostNetworkName = "host"
+
+// ContainerPolicy restricts the containers that may be created, both directly and by stacks. It is loaded from a YAML file like
+//
+//	images:
+//	  allow: [docker.io/library/*, ghcr.io/acme/]
+//	  deny: [docker.io/library/ubuntu]
+//	  requireDigest: true
+//	allowedHostPaths: [/srv/data]
+//	requiredLabels: [team]
+//	maxResources:
+//	  cpus: 2
+//	  memoryBytes: 1073741824
+//
+// Privileged containers, the host network and bind mounts outside of allowedHostPaths are forbidden unless allowed explicitly.
+// Bind mounts include the volumes of the local driver whose options bind a host path as their device.
+type ContainerPolicy struct {
+	Images           ImageRules      `yaml:"images"`
+	AllowPrivileged  bool            `yaml:"allowPrivileged"`
+	AllowHostNetwork bool            `yaml:"allowHostNetwork"`
+	AllowedHostPaths []string        `yaml:"allowedHostPaths"`
+	RequiredLabels   []string        `yaml:"requiredLabels"`
+	MaxResources     *ResourceLimits `yaml:"maxResources"`
+}
+
+// ImageRules match the fully qualified repository of an image, e.g. docker.io/library/nginx.
+// A pattern ending with a slash matches every repository below it, any other pattern is a glob as in path.Match.
+// Deny takes precedence over Allow, an empty Allow allows every image that is not denied.
+type ImageRules struct {
+	Allow         []string `yaml:"allow"`
+	Deny          []string `yaml:"deny"`
+	RequireDigest bool     `yaml:"requireDigest"`
+}
+
+// ResourceLimits are the largest limits a container may have. A container without a limit exceeds every maximum.
+type ResourceLimits struct {
+	Cpus        float64 `yaml:"cpus"`
+	MemoryBytes int64   `yaml:"memoryBytes"`
+}
+
+// Violation is a rule of the policy that a container breaks.
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ViolationError lists every rule a container breaks.
+type ViolationError struct {
+	Violations []Violation
+}
+
+func (ve ViolationError) Error() string {
+	messages := make([]string, 0, len(ve.Violations))
+
+	for _, violation := range ve.Violations {
+		messages = append(messages, violation.Rule+": "+violation.Message)
+	}
+
+	return "container policy is violated. " + strings.Join(messages, "; ")
+}
+
+// LoadContainerPolicy reads a policy file and checks its patterns, paths and limits.
+func LoadContainerPolicy(policyFile string) (*ContainerPolicy, error) {
+	data, err := os.ReadFile(policyFile)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "there is an error while reading the container policy file. File:%s", policyFile)
+	}
+
+	var policy ContainerPolicy
+
+	if err = yaml.UnmarshalStrict(data, &policy); err != nil {
+		return nil, errors.Wrapf(err, "there is an error while parsing the container policy file. File:%s", policyFile)
+	}
+
+	for _, pattern := range append(append([]string{}, policy.Images.Allow...), policy.Images.Deny...) {
+		if _, err = path.Match(pattern, ""); err != nil || pattern == "" {
+			return nil, errors.Errorf("image pattern is not valid. Pattern:%s", pattern)
+		}
+	}
+
+	for _, hostPath := range policy.AllowedHostPaths {
+		if !path.IsAbs(hostPath) {
+			return nil, errors.Errorf("allowed host path must be absolute. Path:%s", hostPath)
+		}
+	}
+
+	for _, label := range policy.RequiredLabels {
+		if strings.SplitN(label, "=", 2)[0] == "" {
+			return nil, errors.Errorf("required label must be given as key or key=value. Label:%s", label)
+		}
+	}
+
+	if policy.MaxResources != nil && (policy.MaxResources.Cpus < 0 || policy.MaxResources.MemoryBytes < 0) {
+		return nil, errors.New("maximum resources must not be negative")
+	}
+
+	return &policy, nil
+}
+
+// Check returns a ViolationError when the container breaks any rule of the policy. A nil policy allows every container.
+func (p *ContainerPolicy) Check(options docker.CreateContainerOptions) error {
+	if p == nil {
+		return nil
+	}
+
+	var violations []Violation
+
+	if options.Config != nil {
+		violations = append(violations, p.imageViolations(options.Config.Image, p.Images.RequireDigest)...)
+		violations = append(violations, p.labelViolations(options.Config.Labels)...)
+	}
+
+	if options.HostConfig != nil {
+		violations = append(violations, p.hostViolations(options)...)
+	}
+
+	return violationError(violations)
+}
+
+// CheckPull returns a ViolationError when the image is not allowed. Digests are only required when containers are created.
+func (p *ContainerPolicy) CheckPull(imageReference string) error {
+	if p == nil {
+		return nil
+	}
+
+	return violationError(p.imageViolations(imageReference, false))
+}
+
+// CheckTag returns a ViolationError when the target reference is not allowed, or when none of the references the source image
+// already has would be allowed to create a container, so that an image cannot be moved into an allowed repository by tagging it.
+func (p *ContainerPolicy) CheckTag(sourceImage string, sourceReferences []string, targetImage string) error {
+	if p == nil {
+		return nil
+	}
+
+	violations := p.imageViolations(targetImage, false)
+
+	for _, sourceReference := range sourceReferences {
+		if len(p.imageViolations(sourceReference, p.Images.RequireDigest)) == 0 {
+			return violationError(violations)
+		}
+	}
+
+	if p.Images.RequireDigest {
+		violations = append(violations, Violation{Rule: RuleImageDigest, Message: fmt.Sprintf("source image must have been pulled by digest from an allowed repository. Image:%s", sourceImage)})
+	} else {
+		violations = append(violations, Violation{Rule: RuleImageAllowed, Message: fmt.Sprintf("source image has no reference in an allowed repository. Image:%s", sourceImage)})
+	}
+
+	return violationError(violations)
+}
+
+// CheckVolume returns a ViolationError when the volume would bind a host path outside of the allowed host paths.
+func (p *ContainerPolicy) CheckVolume(options docker.CreateVolumeOptions) error {
+	if p == nil {
+		return nil
+	}
+
+	return violationError(p.volumeViolations(options.Driver, options.DriverOpts))
+}
+
+func violationError(violations []Violation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return ViolationError{Violations: violations}
+}
+
+func (p *ContainerPolicy) imageViolations(image string, requireDigest bool) []Violation {
+	named, err := reference.ParseNormalizedNamed(image)
+
+	if err != nil {
+		return []Violation{{Rule: RuleImageReference, Message: fmt.Sprintf("image reference cannot be checked. Image:%s", image)}}
+	}
+
+	var violations []Violation
+
+	repository := named.Name()
+
+	if matchesAny(p.Images.Deny, repository) {
+		violations = append(violations, Violation{Rule: RuleImageDenied, Message: fmt.Sprintf("image repository is denied. Repository:%s", repository)})
+	} else if len(p.Images.Allow) > 0 && !matchesAny(p.Images.Allow, repository) {
+		violations = append(violations, Violation{Rule: RuleImageAllowed, Message: fmt.Sprintf("image repository is not allowed. Repository:%s", repository)})
+	}
+
+	if _, ok := named.(reference.Canonical); requireDigest && !ok {
+		violations = append(violations, Violation{Rule: RuleImageDigest, Message: fmt.Sprintf("image must be pinned by digest. Image:%s", image)})
+	}
+
+	return violations
+}
+
+func matchesAny(patterns []string, repository string) bool {
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "/") {
+			if strings.HasPrefix(repository, pattern) {
+				return true
+			}
+
+			continue
+		}
+
+		if matched, _ := path.Match(pattern, repository); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *ContainerPolicy) labelViolations(labels map[string]string) []Violation {
+	var violations []Violation
+
+	for _, label := range p.RequiredLabels {
+		parts := strings.SplitN(label, "=", 2)
+		value, ok := labels[parts[0]]
+
+		if !ok || (len(parts) == 2 && value != parts[1]) {
+			violations = append(violations, Violation{Rule: RuleRequiredLabel, Message: fmt.Sprintf("container must have the label. Label:%s", label)})
+		}
+	}
+
+	return violations
+}
+
+func (p *ContainerPolicy) hostViolations(options docker.CreateContainerOptions) []Violation {
+	var violations []Violation
+
+	hostConfig := options.HostConfig
+
+	if hostConfig.Privileged && !p.AllowPrivileged {
+		violations = append(violations, Violation{Rule: RulePrivileged, Message: "container must not be privileged"})
+	}
+
+	if usesHostNetwork(options) && !p.AllowHostNetwork {
+		violations = append(violations, Violation{Rule: RuleHostNetwork, Message: "container must not use the host network"})
+	}
+
+	for _, m := range hostConfig.Mounts {
+		if m.Type == mount.TypeBind && !p.allowsHostPath(m.Source) {
+			violations = append(violations, Violation{Rule: RuleHostPathMount, Message: fmt.Sprintf("host path must not be mounted. Source:%s", m.Source)})
+		}
+
+		// A volume mount may create its volume with driver options of its own.
+		if m.Type == mount.TypeVolume && m.VolumeOptions != nil && m.VolumeOptions.DriverConfig != nil {
+			violations = append(violations, p.volumeViolations(m.VolumeOptions.DriverConfig.Name, m.VolumeOptions.DriverConfig.Options)...)
+		}
+	}
+
+	for _, bind := range hostConfig.Binds {
+		if source := strings.SplitN(bind, ":", 2)[0]; path.IsAbs(source) && !p.allowsHostPath(source) {
+			violations = append(violations, Violation{Rule: RuleHostPathMount, Message: fmt.Sprintf("host path must not be mounted. Source:%s", source)})
+		}
+	}
+
+	if p.MaxResources == nil {
+		return violations
+	}
+
+	if maxNanoCpus := int64(p.MaxResources.Cpus * 1e9); maxNanoCpus > 0 && (hostConfig.NanoCPUs == 0 || hostConfig.NanoCPUs > maxNanoCpus) {
+		violations = append(violations, Violation{Rule: RuleMaxCpus, Message: fmt.Sprintf("container must be limited to at most %g cpus", p.MaxResources.Cpus)})
+	}
+
+	if maxMemory := p.MaxResources.MemoryBytes; maxMemory > 0 && (hostConfig.Memory == 0 || hostConfig.Memory > maxMemory) {
+		violations = append(violations, Violation{Rule: RuleMaxMemory, Message: fmt.Sprintf("container must be limited to at most %d bytes of memory", maxMemory)})
+	}
+
+	return violations
+}
+
+// volumeViolations checks the device of a local volume whose mount options contain bind, as the local driver then
+// bind mounts the device like a bind mount of the container would. Other drivers are not known to bind host paths.
+func (p *ContainerPolicy) volumeViolations(driver string, driverOpts map[string]string) []Violation {
+	if driver != "" && driver != "local" {
+		return nil
+	}
+
+	bindsDevice := false
+
+	for _, option := range strings.Split(driverOpts["o"], ",") {
+		if option = strings.TrimSpace(option); option == "bind" || option == "rbind" {
+			bindsDevice = true
+		}
+	}
+
+	device := driverOpts["device"]
+
+	if !bindsDevice || (path.IsAbs(device) && p.allowsHostPath(device)) {
+		return nil
+	}
+
+	return []Violation{{Rule: RuleHostPathMount, Message: fmt.Sprintf("volume must not bind a host path. Device:%s", device)}}
+}
+
+func usesHostNetwork(options docker.CreateContainerOptions) bool {
+	if options.HostConfig.NetworkMode.IsHost() {
+		return true
+	}
+
+	if options.NetworkingConfig == nil {
+		return false
+	}
+
+	_, ok := options.NetworkingConfig.EndpointsConfig[hostNetworkName]
+
+	return ok
+}
+
+// allowsHostPath reports whether the source is one of the allowed host paths or lies below one.
+func (p *ContainerPolicy) allowsHostPath(source string) bool {
+	source = path.Clean(source)
+
+	for _, allowed := range p.AllowedHostPaths {
+		allowed = path.Clean(allowed)
+
+		if source == allowed || strings.HasPrefix(source, strings.TrimSuffix(allowed, "/")+"/") {
+			return true
+		}
+	}
+
+	return false
+}
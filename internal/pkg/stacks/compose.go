@@ -0,0 +1,481 @@
+package stacks
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// ComposeFile is the subset of the compose specification godopi deploys. Keys it does not know are rejected,
+// as deploying without them would run the services with other settings than requested. The version and name keys
+// and the x- extensions are the exception, they change nothing and are ignored.
+type ComposeFile struct {
+	Version    interface{}                `yaml:"version"`
+	Name       string                     `yaml:"name"`
+	Services   map[string]ComposeService  `yaml:"services"`
+	Networks   map[string]*ComposeNetwork `yaml:"networks"`
+	Volumes    map[string]*ComposeVolume  `yaml:"volumes"`
+	Extensions map[string]interface{}     `yaml:",inline"`
+}
+
+type ComposeService struct {
+	Image         string                 `yaml:"image"`
+	Build         interface{}            `yaml:"build"`
+	PullPolicy    string                 `yaml:"pull_policy"`
+	ContainerName string                 `yaml:"container_name"`
+	Command       composeCommand         `yaml:"command"`
+	Entrypoint    composeCommand         `yaml:"entrypoint"`
+	Environment   composeMapping         `yaml:"environment"`
+	Labels        composeMapping         `yaml:"labels"`
+	Ports         []composePort          `yaml:"ports"`
+	Volumes       []composeServiceMount  `yaml:"volumes"`
+	DependsOn     composeDependencies    `yaml:"depends_on"`
+	Networks      composeServiceNetworks `yaml:"networks"`
+	Restart       string                 `yaml:"restart"`
+	WorkingDir    string                 `yaml:"working_dir"`
+	User          string                 `yaml:"user"`
+	Hostname      string                 `yaml:"hostname"`
+	Extensions    map[string]interface{} `yaml:",inline"`
+}
+
+type ComposeNetwork struct {
+	Name       string            `yaml:"name"`
+	Driver     string            `yaml:"driver"`
+	DriverOpts map[string]string `yaml:"driver_opts"`
+	Internal   bool              `yaml:"internal"`
+	Attachable bool              `yaml:"attachable"`
+	External   bool              `yaml:"external"`
+	Labels     composeMapping    `yaml:"labels"`
+	Ipam       struct {
+		Config []struct {
+			Subnet  string `yaml:"subnet"`
+			Gateway string `yaml:"gateway"`
+			IpRange string `yaml:"ip_range"`
+		} `yaml:"config"`
+	} `yaml:"ipam"`
+}
+
+type ComposeVolume struct {
+	Name       string            `yaml:"name"`
+	Driver     string            `yaml:"driver"`
+	DriverOpts map[string]string `yaml:"driver_opts"`
+	External   bool              `yaml:"external"`
+	Labels     composeMapping    `yaml:"labels"`
+}
+
+type ComposeServiceNetwork struct {
+	Aliases     []string `yaml:"aliases"`
+	IPv4Address string   `yaml:"ipv4_address"`
+}
+
+// composeCommand is a command given either as a list or as a single string that is split like a shell would.
+type composeCommand []string
+
+// composeMapping is a string map given either as a map or as a list of key=value entries.
+type composeMapping map[string]string
+
+// composeDependencies are the names of the services a service depends on, given either as a list or as a map of conditions.
+type composeDependencies []string
+
+// composeServiceNetworks are the networks of a service, given either as a list of names or as a map of endpoint settings.
+type composeServiceNetworks map[string]ComposeServiceNetwork
+
+// composePort is a port published in the short ip:public:private/proto syntax, which the long syntax is converted to.
+type composePort string
+
+// composeServiceMount is a volume of a service in either the short source:target:mode or the long syntax.
+type composeServiceMount struct {
+	Type     string `yaml:"type"`
+	Source   string `yaml:"source"`
+	Target   string `yaml:"target"`
+	ReadOnly bool   `yaml:"read_only"`
+}
+
+// ParseCompose decodes a compose file and checks the references between its services, networks and volumes.
+func ParseCompose(data []byte) (ComposeFile, error) {
+	var composeFile ComposeFile
+
+	if err := yaml.UnmarshalStrict(data, &composeFile); err != nil {
+		return ComposeFile{}, errdefs.InvalidParameter(errors.Wrap(err, "compose file is not valid"))
+	}
+
+	if err := composeFile.validate(); err != nil {
+		return ComposeFile{}, errdefs.InvalidParameter(err)
+	}
+
+	return composeFile, nil
+}
+
+func (cf ComposeFile) validate() error {
+	if len(cf.Services) == 0 {
+		return errors.New("compose file does not define any service")
+	}
+
+	if key := unsupportedKey(cf.Extensions); key != "" {
+		return errors.Errorf("compose file key is not supported. Key:%s", key)
+	}
+
+	for serviceName, service := range cf.Services {
+		if key := unsupportedKey(service.Extensions); key != "" {
+			return errors.Errorf("service key is not supported. Service:%s Key:%s", serviceName, key)
+		}
+		if service.Image == "" {
+			if service.Build != nil {
+				return errors.Errorf("service has no image and building images is not supported. Service:%s", serviceName)
+			}
+
+			return errors.Errorf("service has no image. Service:%s", serviceName)
+		}
+
+		for _, dependency := range service.DependsOn {
+			if _, ok := cf.Services[dependency]; !ok {
+				return errors.Errorf("service depends on an undefined service. Service:%s DependsOn:%s", serviceName, dependency)
+			}
+		}
+
+		for networkName := range service.Networks {
+			if _, ok := cf.Networks[networkName]; !ok && networkName != defaultNetwork {
+				return errors.Errorf("service uses an undefined network. Service:%s Network:%s", serviceName, networkName)
+			}
+		}
+
+		for _, mount := range service.Volumes {
+			if mount.Type == "bind" && !path.IsAbs(mount.Source) {
+				return errors.Errorf("bind mount source must be an absolute path on the docker host. Service:%s Source:%s", serviceName, mount.Source)
+			}
+
+			if mount.Type != "volume" || mount.Source == "" {
+				continue
+			}
+
+			if _, ok := cf.Volumes[mount.Source]; !ok {
+				return errors.Errorf("service uses an undefined volume. Service:%s Volume:%s", serviceName, mount.Source)
+			}
+		}
+	}
+
+	_, err := cf.serviceOrder()
+
+	return err
+}
+
+// unsupportedKey returns the first of the keys left over by decoding that is not an x- extension, or an empty string when there is none.
+func unsupportedKey(keys map[string]interface{}) string {
+	for _, key := range sortedKeys(keys) {
+		if !strings.HasPrefix(key, "x-") {
+			return key
+		}
+	}
+
+	return ""
+}
+
+// serviceOrder sorts the services so that every service comes after the services it depends on.
+// Services that do not depend on each other are sorted by name to keep deployments reproducible.
+func (cf ComposeFile) serviceOrder() ([]string, error) {
+	serviceNames := make([]string, 0, len(cf.Services))
+
+	for serviceName := range cf.Services {
+		serviceNames = append(serviceNames, serviceName)
+	}
+
+	sort.Strings(serviceNames)
+
+	ordered := make([]string, 0, len(serviceNames))
+	placed := map[string]bool{}
+
+	for len(ordered) < len(serviceNames) {
+		progressed := false
+
+		for _, serviceName := range serviceNames {
+			if placed[serviceName] || !cf.dependenciesPlaced(serviceName, placed) {
+				continue
+			}
+
+			ordered = append(ordered, serviceName)
+			placed[serviceName] = true
+			progressed = true
+		}
+
+		if !progressed {
+			var cyclic []string
+
+			for _, serviceName := range serviceNames {
+				if !placed[serviceName] {
+					cyclic = append(cyclic, serviceName)
+				}
+			}
+
+			return nil, errors.Errorf("services have cyclic dependencies. Services:%s", strings.Join(cyclic, ","))
+		}
+	}
+
+	return ordered, nil
+}
+
+func (cf ComposeFile) dependenciesPlaced(serviceName string, placed map[string]bool) bool {
+	for _, dependency := range cf.Services[serviceName].DependsOn {
+		if !placed[dependency] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *composeCommand) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var command string
+
+	if err := unmarshal(&command); err == nil {
+		words, err := splitCommand(command)
+		*c = words
+		return err
+	}
+
+	var words []string
+
+	if err := unmarshal(&words); err != nil {
+		return err
+	}
+
+	*c = words
+	return nil
+}
+
+func (m *composeMapping) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	mapping := composeMapping{}
+
+	var entries []string
+
+	if err := unmarshal(&entries); err == nil {
+		for _, entry := range entries {
+			parts := strings.SplitN(entry, "=", 2)
+
+			if len(parts) == 1 {
+				mapping[parts[0]] = ""
+				continue
+			}
+
+			mapping[parts[0]] = parts[1]
+		}
+
+		*m = mapping
+		return nil
+	}
+
+	var values map[string]interface{}
+
+	if err := unmarshal(&values); err != nil {
+		return err
+	}
+
+	for key, value := range values {
+		if value == nil {
+			mapping[key] = ""
+			continue
+		}
+
+		mapping[key] = fmt.Sprint(value)
+	}
+
+	*m = mapping
+	return nil
+}
+
+func (d *composeDependencies) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var dependencies []string
+
+	if err := unmarshal(&dependencies); err == nil {
+		*d = dependencies
+		return nil
+	}
+
+	var conditions map[string]interface{}
+
+	if err := unmarshal(&conditions); err != nil {
+		return err
+	}
+
+	for dependency := range conditions {
+		dependencies = append(dependencies, dependency)
+	}
+
+	sort.Strings(dependencies)
+
+	*d = dependencies
+	return nil
+}
+
+func (n *composeServiceNetworks) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	networks := composeServiceNetworks{}
+
+	var networkNames []string
+
+	if err := unmarshal(&networkNames); err == nil {
+		for _, networkName := range networkNames {
+			networks[networkName] = ComposeServiceNetwork{}
+		}
+
+		*n = networks
+		return nil
+	}
+
+	var settings map[string]*ComposeServiceNetwork
+
+	if err := unmarshal(&settings); err != nil {
+		return err
+	}
+
+	for networkName, setting := range settings {
+		if setting == nil {
+			setting = &ComposeServiceNetwork{}
+		}
+
+		networks[networkName] = *setting
+	}
+
+	*n = networks
+	return nil
+}
+
+func (p *composePort) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var port interface{}
+
+	if err := unmarshal(&port); err != nil {
+		return err
+	}
+
+	if _, ok := port.(map[interface{}]interface{}); !ok {
+		*p = composePort(fmt.Sprint(port))
+		return nil
+	}
+
+	var longSyntax struct {
+		Target    int    `yaml:"target"`
+		Published string `yaml:"published"`
+		Protocol  string `yaml:"protocol"`
+		HostIp    string `yaml:"host_ip"`
+		// Mode only matters to swarm, ports are always published on the host.
+		Mode string `yaml:"mode"`
+	}
+
+	if err := unmarshal(&longSyntax); err != nil {
+		return err
+	}
+
+	spec := fmt.Sprint(longSyntax.Target)
+
+	if longSyntax.Published != "" {
+		spec = longSyntax.Published + ":" + spec
+	}
+
+	if longSyntax.HostIp != "" {
+		spec = longSyntax.HostIp + ":" + spec
+	}
+
+	if longSyntax.Protocol != "" {
+		spec += "/" + longSyntax.Protocol
+	}
+
+	*p = composePort(spec)
+	return nil
+}
+
+// UnmarshalYAML reads the short syntax as source:target:mode, where a source that is a path makes a bind mount,
+// a source that is a name makes a volume mount and a missing source makes an anonymous volume.
+func (m *composeServiceMount) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var shortSyntax string
+
+	if err := unmarshal(&shortSyntax); err != nil {
+		type longSyntax composeServiceMount
+
+		if err := unmarshal((*longSyntax)(m)); err != nil {
+			return err
+		}
+
+		if m.Type == "" {
+			m.Type = "volume"
+		}
+
+		return nil
+	}
+
+	parts := strings.Split(shortSyntax, ":")
+
+	switch len(parts) {
+	case 1:
+		*m = composeServiceMount{Type: "volume", Target: parts[0]}
+		return nil
+	case 2, 3:
+		*m = composeServiceMount{Type: "volume", Source: parts[0], Target: parts[1]}
+	default:
+		return errors.Errorf("volume is not in the source:target:mode form. Volume:%s", shortSyntax)
+	}
+
+	if strings.HasPrefix(m.Source, "/") || strings.HasPrefix(m.Source, ".") || strings.HasPrefix(m.Source, "~") {
+		m.Type = "bind"
+	}
+
+	if len(parts) == 3 {
+		for _, mode := range strings.Split(parts[2], ",") {
+			switch mode {
+			case "ro":
+				m.ReadOnly = true
+			case "rw", "z", "Z":
+			default:
+				return errors.Errorf("volume mode is not supported. Volume:%s Mode:%s", shortSyntax, mode)
+			}
+		}
+	}
+
+	return nil
+}
+
+// splitCommand splits a command string into words like a POSIX shell would, honouring quotes and backslash escapes.
+func splitCommand(command string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+
+	inWord, escaped := false, false
+	var quote rune
+
+	for _, r := range command {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, errors.Errorf("command has an unterminated quote or escape. Command:%s", command)
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
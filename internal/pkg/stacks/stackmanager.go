@@ -0,0 +1,828 @@
+package stacks
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"godopi/internal/pkg/docker"
+	"godopi/internal/pkg/policy"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	. "godopi/internal/pkg/logger"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/go-connections/nat"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Every resource of a stack carries STACK_LABEL with the stack name, which is how the stack is found again.
+// Containers and networks carry STACK_CONFIG_HASH_LABEL with the hash of their configuration, which tells an update what changed.
+const (
+	STACK_LABEL             = "godopi.stack"
+	STACK_SERVICE_LABEL     = "godopi.stack.service"
+	STACK_NETWORK_LABEL     = "godopi.stack.network"
+	STACK_VOLUME_LABEL      = "godopi.stack.volume"
+	STACK_CONFIG_HASH_LABEL = "godopi.stack.config-hash"
+)
+
+// defaultNetwork is the network services are attached to when they do not name any.
+const defaultNetwork = "default"
+
+var stackNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+type Stack struct {
+	Name     string         `json:"name"`
+	Services []StackService `json:"services"`
+	Networks []string       `json:"networks"`
+	Volumes  []string       `json:"volumes"`
+}
+
+type StackService struct {
+	Name          string `json:"name"`
+	ContainerId   string `json:"containerId"`
+	ContainerName string `json:"containerName"`
+	Image         string `json:"image"`
+	State         string `json:"state"`
+	Status        string `json:"status"`
+}
+
+// DeployProgress reports what happened to a single network, volume or container of a stack during a deployment.
+type DeployProgress struct {
+	Resource string
+	Status   string
+}
+
+type StackManager interface {
+	ListStacks(ctx context.Context) ([]Stack, error)
+	GetStack(ctx context.Context, stackName string) (Stack, error)
+	CreateStack(ctx context.Context, stackName string, composeFile ComposeFile, onProgress func(DeployProgress)) (Stack, error)
+	UpdateStack(ctx context.Context, stackName string, composeFile ComposeFile, onProgress func(DeployProgress)) (Stack, error)
+	CheckPolicy(stackName string, composeFile ComposeFile) error
+	DeleteStack(ctx context.Context, stackName string, removeVolumes bool) error
+}
+
+type stackManager struct {
+	dockerClient    docker.DockerClient
+	containerPolicy *policy.ContainerPolicy
+}
+
+// stackResources are the docker resources labelled as part of one or more stacks.
+type stackResources struct {
+	containers []docker.ContainerSummary
+	networks   []docker.Network
+	volumes    []docker.Volume
+}
+
+// deploymentPlan is a compose file resolved into the docker resources of a stack.
+type deploymentPlan struct {
+	networks     map[string]docker.CreateNetworkOptions
+	volumes      map[string]docker.CreateVolumeOptions
+	services     map[string]docker.CreateContainerOptions
+	serviceOrder []string
+}
+
+func NewStackManager(dockerClient docker.DockerClient, containerPolicy *policy.ContainerPolicy) StackManager {
+	Logger().Info("Constructing new stack manager..")
+
+	return stackManager{dockerClient: dockerClient, containerPolicy: containerPolicy}
+}
+
+func (sm stackManager) ListStacks(ctx context.Context) ([]Stack, error) {
+	resources, err := sm.listResources(ctx, STACK_LABEL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stacksByName := map[string]*Stack{}
+
+	stackOf := func(labels map[string]string) *Stack {
+		stackName := labels[STACK_LABEL]
+
+		if _, ok := stacksByName[stackName]; !ok {
+			stacksByName[stackName] = &Stack{Name: stackName}
+		}
+
+		return stacksByName[stackName]
+	}
+
+	for _, container := range resources.containers {
+		stack := stackOf(container.Labels)
+		stack.Services = append(stack.Services, newStackService(container))
+	}
+
+	for _, network := range resources.networks {
+		stack := stackOf(network.Labels)
+		stack.Networks = append(stack.Networks, network.Name)
+	}
+
+	for _, volume := range resources.volumes {
+		stack := stackOf(volume.Labels)
+		stack.Volumes = append(stack.Volumes, volume.Name)
+	}
+
+	stacks := make([]Stack, 0, len(stacksByName))
+
+	for _, stack := range stacksByName {
+		stacks = append(stacks, sortStack(*stack))
+	}
+
+	sort.Slice(stacks, func(i, j int) bool { return stacks[i].Name < stacks[j].Name })
+
+	return stacks, nil
+}
+
+func (sm stackManager) GetStack(ctx context.Context, stackName string) (Stack, error) {
+	if err := ValidateStackName(stackName); err != nil {
+		return Stack{}, err
+	}
+
+	resources, err := sm.listResources(ctx, STACK_LABEL+"="+stackName)
+
+	if err != nil {
+		return Stack{}, err
+	}
+
+	if resources.empty() {
+		return Stack{}, errdefs.NotFound(errors.Errorf("stack does not exist. Stack:%s", stackName))
+	}
+
+	stack := Stack{Name: stackName}
+
+	for _, container := range resources.containers {
+		stack.Services = append(stack.Services, newStackService(container))
+	}
+
+	for _, network := range resources.networks {
+		stack.Networks = append(stack.Networks, network.Name)
+	}
+
+	for _, volume := range resources.volumes {
+		stack.Volumes = append(stack.Volumes, volume.Name)
+	}
+
+	return sortStack(stack), nil
+}
+
+// CreateStack deploys a compose file as a new stack. It fails with a conflict when the stack already has resources.
+func (sm stackManager) CreateStack(ctx context.Context, stackName string, composeFile ComposeFile, onProgress func(DeployProgress)) (Stack, error) {
+	if err := ValidateStackName(stackName); err != nil {
+		return Stack{}, err
+	}
+
+	resources, err := sm.listResources(ctx, STACK_LABEL+"="+stackName)
+
+	if err != nil {
+		return Stack{}, err
+	}
+
+	if !resources.empty() {
+		return Stack{}, errdefs.Conflict(errors.Errorf("stack already exists. Stack:%s", stackName))
+	}
+
+	return sm.deploy(ctx, stackName, composeFile, resources, onProgress)
+}
+
+// UpdateStack brings an existing stack in line with a compose file. Services and networks whose configuration changed are recreated,
+// together with the containers attached to such a network. Services, networks and containers that are no longer in the compose file
+// are removed, volumes are always kept.
+func (sm stackManager) UpdateStack(ctx context.Context, stackName string, composeFile ComposeFile, onProgress func(DeployProgress)) (Stack, error) {
+	if err := ValidateStackName(stackName); err != nil {
+		return Stack{}, err
+	}
+
+	resources, err := sm.listResources(ctx, STACK_LABEL+"="+stackName)
+
+	if err != nil {
+		return Stack{}, err
+	}
+
+	if resources.empty() {
+		return Stack{}, errdefs.NotFound(errors.Errorf("stack does not exist. Stack:%s", stackName))
+	}
+
+	return sm.deploy(ctx, stackName, composeFile, resources, onProgress)
+}
+
+// DeleteStack removes the containers and networks of a stack, and its volumes when removeVolumes is true.
+func (sm stackManager) DeleteStack(ctx context.Context, stackName string, removeVolumes bool) error {
+	if err := ValidateStackName(stackName); err != nil {
+		return err
+	}
+
+	resources, err := sm.listResources(ctx, STACK_LABEL+"="+stackName)
+
+	if err != nil {
+		return err
+	}
+
+	if resources.empty() {
+		return errdefs.NotFound(errors.Errorf("stack does not exist. Stack:%s", stackName))
+	}
+
+	for _, container := range resources.containers {
+		if err = sm.dockerClient.DeleteContainer(ctx, container.Id); err != nil {
+			return errors.Wrapf(err, "there is an error while deleting stack container. Stack:%s ContainerId:%s", stackName, container.Id)
+		}
+	}
+
+	for _, network := range resources.networks {
+		if err = sm.dockerClient.DeleteNetwork(ctx, network.Id); err != nil {
+			return errors.Wrapf(err, "there is an error while deleting stack network. Stack:%s Network:%s", stackName, network.Name)
+		}
+	}
+
+	if !removeVolumes {
+		return nil
+	}
+
+	for _, volume := range resources.volumes {
+		if err = sm.dockerClient.DeleteVolume(ctx, volume.Name, false); err != nil {
+			return errors.Wrapf(err, "there is an error while deleting stack volume. Stack:%s Volume:%s", stackName, volume.Name)
+		}
+	}
+
+	return nil
+}
+
+// deploy creates the missing networks and volumes of a stack, then creates or recreates its services in dependency order
+// and finally removes the containers and networks the compose file no longer defines.
+// A failed deployment leaves the resources created so far in place, so that it can be retried with an update.
+func (sm stackManager) deploy(ctx context.Context, stackName string, composeFile ComposeFile, existing stackResources, onProgress func(DeployProgress)) (Stack, error) {
+	plan, err := newDeploymentPlan(stackName, composeFile)
+
+	if err != nil {
+		return Stack{}, errdefs.InvalidParameter(err)
+	}
+
+	if err = sm.checkPolicy(plan); err != nil {
+		return Stack{}, err
+	}
+
+	report := func(resource string, status string) {
+		if onProgress != nil {
+			onProgress(DeployProgress{Resource: resource, Status: status})
+		}
+	}
+
+	existingContainers := map[string]docker.ContainerSummary{}
+
+	for _, container := range existing.containers {
+		existingContainers[container.Labels[STACK_SERVICE_LABEL]] = container
+	}
+
+	// The services whose container was deleted to recreate a network, they are created again below.
+	detachedServices := map[string]bool{}
+
+	existingNetworks := map[string]docker.Network{}
+
+	for _, network := range existing.networks {
+		existingNetworks[network.Labels[STACK_NETWORK_LABEL]] = network
+	}
+
+	for _, networkKey := range sortedKeys(plan.networks) {
+		options := plan.networks[networkKey]
+		status := "Created"
+
+		if network, ok := existingNetworks[networkKey]; ok {
+			if network.Labels[STACK_CONFIG_HASH_LABEL] == options.Labels[STACK_CONFIG_HASH_LABEL] {
+				report(options.Name, "Unchanged")
+				continue
+			}
+
+			// The engine cannot change a network in place, nor remove it while containers are attached.
+			for _, serviceName := range sortedKeys(existingContainers) {
+				container := existingContainers[serviceName]
+
+				if !containsString(container.Networks, network.Name) {
+					continue
+				}
+
+				if err = sm.dockerClient.DeleteContainer(ctx, container.Id); err != nil {
+					return Stack{}, errors.Wrapf(err, "there is an error while deleting stack container attached to a changed network. Stack:%s Service:%s Network:%s", stackName, serviceName, network.Name)
+				}
+
+				delete(existingContainers, serviceName)
+
+				if _, ok := plan.services[serviceName]; ok {
+					detachedServices[serviceName] = true
+				} else {
+					report(container.Name, "Removed")
+				}
+			}
+
+			if err = sm.dockerClient.DeleteNetwork(ctx, network.Id); err != nil {
+				return Stack{}, errors.Wrapf(err, "there is an error while deleting changed stack network. Stack:%s Network:%s", stackName, network.Name)
+			}
+
+			status = "Recreated"
+		}
+
+		if _, err = sm.dockerClient.CreateNetwork(ctx, options); err != nil {
+			return Stack{}, errors.Wrapf(err, "there is an error while creating stack network. Stack:%s Network:%s", stackName, options.Name)
+		}
+
+		report(options.Name, status)
+	}
+
+	existingVolumes := map[string]bool{}
+
+	for _, volume := range existing.volumes {
+		existingVolumes[volume.Labels[STACK_VOLUME_LABEL]] = true
+	}
+
+	for _, volumeKey := range sortedKeys(plan.volumes) {
+		options := plan.volumes[volumeKey]
+
+		if existingVolumes[volumeKey] {
+			report(options.Name, "Unchanged")
+			continue
+		}
+
+		if _, err = sm.dockerClient.CreateVolume(ctx, options); err != nil {
+			return Stack{}, errors.Wrapf(err, "there is an error while creating stack volume. Stack:%s Volume:%s", stackName, options.Name)
+		}
+
+		report(options.Name, "Created")
+	}
+
+	for _, serviceName := range plan.serviceOrder {
+		options := plan.services[serviceName]
+		status := "Created"
+
+		if detachedServices[serviceName] {
+			status = "Recreated"
+		}
+
+		if container, ok := existingContainers[serviceName]; ok {
+			if container.Labels[STACK_CONFIG_HASH_LABEL] == options.Config.Labels[STACK_CONFIG_HASH_LABEL] {
+				report(options.Name, "Unchanged")
+				continue
+			}
+
+			if err = sm.dockerClient.DeleteContainer(ctx, container.Id); err != nil {
+				return Stack{}, errors.Wrapf(err, "there is an error while deleting outdated stack container. Stack:%s Service:%s", stackName, serviceName)
+			}
+
+			status = "Recreated"
+		}
+
+		if _, err = sm.dockerClient.CreateContainer(ctx, options); err != nil {
+			return Stack{}, errors.Wrapf(err, "there is an error while creating stack container. Stack:%s Service:%s", stackName, serviceName)
+		}
+
+		report(options.Name, status)
+	}
+
+	for serviceName, container := range existingContainers {
+		if _, ok := plan.services[serviceName]; ok {
+			continue
+		}
+
+		if err = sm.dockerClient.DeleteContainer(ctx, container.Id); err != nil {
+			return Stack{}, errors.Wrapf(err, "there is an error while deleting removed stack container. Stack:%s Service:%s", stackName, serviceName)
+		}
+
+		report(container.Name, "Removed")
+	}
+
+	for networkKey, network := range existingNetworks {
+		if _, ok := plan.networks[networkKey]; ok {
+			continue
+		}
+
+		if err = sm.dockerClient.DeleteNetwork(ctx, network.Id); err != nil {
+			return Stack{}, errors.Wrapf(err, "there is an error while deleting removed stack network. Stack:%s Network:%s", stackName, network.Name)
+		}
+
+		report(network.Name, "Removed")
+	}
+
+	LoggerFromContext(ctx).Info("Deployed stack", zap.String("Stack", stackName), zap.Strings("Services", plan.serviceOrder))
+
+	return sm.GetStack(ctx, stackName)
+}
+
+func (sm stackManager) listResources(ctx context.Context, label string) (stackResources, error) {
+	containers, _, err := sm.dockerClient.ListContainers(ctx, docker.ListContainersOptions{All: true, Labels: []string{label}})
+
+	if err != nil {
+		return stackResources{}, errors.Wrap(err, "there is an error while listing stack containers")
+	}
+
+	networks, err := sm.dockerClient.ListNetworks(ctx, docker.ListNetworksOptions{Labels: []string{label}})
+
+	if err != nil {
+		return stackResources{}, errors.Wrap(err, "there is an error while listing stack networks")
+	}
+
+	volumes, err := sm.dockerClient.ListVolumes(ctx, docker.ListVolumesOptions{Labels: []string{label}})
+
+	if err != nil {
+		return stackResources{}, errors.Wrap(err, "there is an error while listing stack volumes")
+	}
+
+	return stackResources{containers: containers, networks: networks, volumes: volumes}, nil
+}
+
+func (sr stackResources) empty() bool {
+	return len(sr.containers) == 0 && len(sr.networks) == 0 && len(sr.volumes) == 0
+}
+
+// CheckPolicy checks the volumes and services of a compose file against the container policy without deploying anything,
+// so that violations can be reported before a deployment starts in the background.
+func (sm stackManager) CheckPolicy(stackName string, composeFile ComposeFile) error {
+	plan, err := newDeploymentPlan(stackName, composeFile)
+
+	if err != nil {
+		return errdefs.InvalidParameter(err)
+	}
+
+	return sm.checkPolicy(plan)
+}
+
+// checkPolicy collects the violations of every volume and service, naming the volume or the service in each message.
+func (sm stackManager) checkPolicy(plan deploymentPlan) error {
+	var violations []policy.Violation
+
+	for _, volumeKey := range sortedKeys(plan.volumes) {
+		if violationErr, ok := sm.containerPolicy.CheckVolume(plan.volumes[volumeKey]).(policy.ViolationError); ok {
+			for _, violation := range violationErr.Violations {
+				violation.Message += " Volume:" + volumeKey
+				violations = append(violations, violation)
+			}
+		}
+	}
+
+	for _, serviceName := range plan.serviceOrder {
+		if violationErr, ok := sm.containerPolicy.Check(plan.services[serviceName]).(policy.ViolationError); ok {
+			for _, violation := range violationErr.Violations {
+				violation.Message += " Service:" + serviceName
+				violations = append(violations, violation)
+			}
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return policy.ViolationError{Violations: violations}
+}
+
+// newDeploymentPlan resolves the names of the networks and volumes of a stack and translates its services into container configs.
+// External networks and volumes are referenced by name and are neither created nor removed.
+func newDeploymentPlan(stackName string, composeFile ComposeFile) (deploymentPlan, error) {
+	plan := deploymentPlan{
+		networks: map[string]docker.CreateNetworkOptions{},
+		volumes:  map[string]docker.CreateVolumeOptions{},
+		services: map[string]docker.CreateContainerOptions{},
+	}
+
+	networkNames := map[string]string{}
+
+	for networkKey, composeNetwork := range composeFile.Networks {
+		if composeNetwork == nil {
+			composeNetwork = &ComposeNetwork{}
+		}
+
+		networkNames[networkKey] = resourceName(stackName, networkKey, composeNetwork.Name, composeNetwork.External)
+
+		if composeNetwork.External {
+			continue
+		}
+
+		options := docker.CreateNetworkOptions{
+			Name:       networkNames[networkKey],
+			Driver:     composeNetwork.Driver,
+			Internal:   composeNetwork.Internal,
+			Attachable: composeNetwork.Attachable,
+			Options:    composeNetwork.DriverOpts,
+			Labels:     stackLabels(composeNetwork.Labels, stackName, STACK_NETWORK_LABEL, networkKey),
+		}
+
+		for _, ipamConfig := range composeNetwork.Ipam.Config {
+			options.Ipam = append(options.Ipam, docker.NetworkIpamConfig{Subnet: ipamConfig.Subnet, Gateway: ipamConfig.Gateway, IpRange: ipamConfig.IpRange})
+		}
+
+		plan.networks[networkKey] = options
+	}
+
+	for volumeKey, composeVolume := range composeFile.Volumes {
+		if composeVolume == nil {
+			composeVolume = &ComposeVolume{}
+		}
+
+		if composeVolume.External {
+			continue
+		}
+
+		plan.volumes[volumeKey] = docker.CreateVolumeOptions{
+			Name:       resourceName(stackName, volumeKey, composeVolume.Name, false),
+			Driver:     composeVolume.Driver,
+			DriverOpts: composeVolume.DriverOpts,
+			Labels:     stackLabels(composeVolume.Labels, stackName, STACK_VOLUME_LABEL, volumeKey),
+		}
+	}
+
+	for serviceName, service := range composeFile.Services {
+		if len(service.Networks) == 0 {
+			service.Networks = composeServiceNetworks{defaultNetwork: {}}
+		}
+
+		if _, ok := service.Networks[defaultNetwork]; ok {
+			if _, declared := networkNames[defaultNetwork]; !declared {
+				networkNames[defaultNetwork] = resourceName(stackName, defaultNetwork, "", false)
+				plan.networks[defaultNetwork] = docker.CreateNetworkOptions{
+					Name:   networkNames[defaultNetwork],
+					Labels: stackLabels(nil, stackName, STACK_NETWORK_LABEL, defaultNetwork),
+				}
+			}
+		}
+
+		options, err := newContainerOptions(stackName, serviceName, service, networkNames, composeFile.Volumes)
+
+		if err != nil {
+			return deploymentPlan{}, err
+		}
+
+		plan.services[serviceName] = options
+	}
+
+	serviceOrder, err := composeFile.serviceOrder()
+
+	if err != nil {
+		return deploymentPlan{}, err
+	}
+
+	plan.serviceOrder = serviceOrder
+
+	for networkKey, options := range plan.networks {
+		hash, err := configHash(options)
+
+		if err != nil {
+			return deploymentPlan{}, errors.Wrapf(err, "there is an error while hashing network config. Network:%s", networkKey)
+		}
+
+		options.Labels[STACK_CONFIG_HASH_LABEL] = hash
+	}
+
+	return plan, nil
+}
+
+// newContainerOptions translates a service into the container configs of the engine.
+// The configs are hashed into STACK_CONFIG_HASH_LABEL so that an update can tell which services changed.
+func newContainerOptions(stackName string, serviceName string, service ComposeService, networkNames map[string]string, volumes map[string]*ComposeVolume) (docker.CreateContainerOptions, error) {
+	pullPolicy, err := newPullPolicy(service.PullPolicy)
+
+	if err != nil {
+		return docker.CreateContainerOptions{}, errors.Wrapf(err, "service is not valid. Service:%s", serviceName)
+	}
+
+	var portSpecs []string
+
+	for _, port := range service.Ports {
+		portSpecs = append(portSpecs, string(port))
+	}
+
+	exposedPorts, portBindings, err := nat.ParsePortSpecs(portSpecs)
+
+	if err != nil {
+		return docker.CreateContainerOptions{}, errors.Wrapf(err, "service has an invalid port. Service:%s", serviceName)
+	}
+
+	restartPolicy, err := newRestartPolicy(service.Restart)
+
+	if err != nil {
+		return docker.CreateContainerOptions{}, errors.Wrapf(err, "service is not valid. Service:%s", serviceName)
+	}
+
+	env := make([]string, 0, len(service.Environment))
+
+	for key, value := range service.Environment {
+		env = append(env, key+"="+value)
+	}
+
+	sort.Strings(env)
+
+	containerName := service.ContainerName
+
+	if containerName == "" {
+		containerName = stackName + "-" + serviceName
+	}
+
+	config := &container.Config{
+		Image:        service.Image,
+		Cmd:          []string(service.Command),
+		Entrypoint:   []string(service.Entrypoint),
+		Env:          env,
+		ExposedPorts: exposedPorts,
+		WorkingDir:   service.WorkingDir,
+		User:         service.User,
+		Hostname:     service.Hostname,
+		Labels:       stackLabels(service.Labels, stackName, STACK_SERVICE_LABEL, serviceName),
+	}
+
+	hostConfig := &container.HostConfig{PortBindings: portBindings, RestartPolicy: restartPolicy}
+
+	for _, serviceMount := range service.Volumes {
+		source := serviceMount.Source
+
+		if serviceMount.Type == "volume" && source != "" {
+			composeVolume := volumes[source]
+
+			if composeVolume == nil {
+				composeVolume = &ComposeVolume{}
+			}
+
+			source = resourceName(stackName, source, composeVolume.Name, composeVolume.External)
+		}
+
+		hostConfig.Mounts = append(hostConfig.Mounts, mount.Mount{Type: mount.Type(serviceMount.Type), Source: source, Target: serviceMount.Target, ReadOnly: serviceMount.ReadOnly})
+	}
+
+	networkingConfig := &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{}}
+
+	for i, networkKey := range sortedKeys(service.Networks) {
+		serviceNetwork := service.Networks[networkKey]
+		endpointSettings := &network.EndpointSettings{Aliases: append([]string{serviceName}, serviceNetwork.Aliases...)}
+
+		if serviceNetwork.IPv4Address != "" {
+			endpointSettings.IPAMConfig = &network.EndpointIPAMConfig{IPv4Address: serviceNetwork.IPv4Address}
+		}
+
+		networkingConfig.EndpointsConfig[networkNames[networkKey]] = endpointSettings
+
+		// The first network becomes the primary one, the rest are connected after creation.
+		if i == 0 {
+			hostConfig.NetworkMode = container.NetworkMode(networkNames[networkKey])
+		}
+	}
+
+	hash, err := configHash(config, hostConfig, networkingConfig, pullPolicy)
+
+	if err != nil {
+		return docker.CreateContainerOptions{}, errors.Wrapf(err, "there is an error while hashing service config. Service:%s", serviceName)
+	}
+
+	config.Labels[STACK_CONFIG_HASH_LABEL] = hash
+
+	return docker.CreateContainerOptions{
+		Name:             containerName,
+		Config:           config,
+		HostConfig:       hostConfig,
+		NetworkingConfig: networkingConfig,
+		PullPolicy:       pullPolicy,
+		AutoStart:        true,
+	}, nil
+}
+
+// configHash hashes the configuration of a resource, given as the values it is created from.
+func configHash(values ...interface{}) (string, error) {
+	data, err := json.Marshal(values)
+
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(data)
+
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// newPullPolicy maps the compose pull policies to the docker ones. Images are only pulled when missing by default, as compose does.
+func newPullPolicy(pullPolicy string) (docker.PullPolicy, error) {
+	switch pullPolicy {
+	case "", "missing", "if_not_present":
+		return docker.PullIfNotPresent, nil
+	case "always":
+		return docker.PullAlways, nil
+	case "never":
+		return docker.PullNever, nil
+	}
+
+	return "", errors.Errorf("pull policy is not supported. PullPolicy:%s", pullPolicy)
+}
+
+func newRestartPolicy(restart string) (container.RestartPolicy, error) {
+	parts := strings.SplitN(restart, ":", 2)
+
+	switch parts[0] {
+	case "", "no":
+		return container.RestartPolicy{}, nil
+	case "always", "unless-stopped":
+		if len(parts) == 1 {
+			return container.RestartPolicy{Name: parts[0]}, nil
+		}
+	case "on-failure":
+		if len(parts) == 1 {
+			return container.RestartPolicy{Name: "on-failure"}, nil
+		}
+
+		maximumRetryCount, err := strconv.Atoi(parts[1])
+
+		if err == nil && maximumRetryCount >= 0 {
+			return container.RestartPolicy{Name: "on-failure", MaximumRetryCount: maximumRetryCount}, nil
+		}
+	}
+
+	return container.RestartPolicy{}, errors.Errorf("restart policy is not supported. Restart:%s", restart)
+}
+
+// resourceName is the docker name of a network or volume of a stack, which is prefixed with the stack name unless it is given explicitly.
+func resourceName(stackName string, key string, name string, external bool) string {
+	if name != "" {
+		return name
+	}
+
+	if external {
+		return key
+	}
+
+	return stackName + "_" + key
+}
+
+func stackLabels(labels map[string]string, stackName string, resourceLabel string, resourceKey string) map[string]string {
+	stackLabels := map[string]string{STACK_LABEL: stackName, resourceLabel: resourceKey}
+
+	for key, value := range labels {
+		if _, reserved := stackLabels[key]; !reserved {
+			stackLabels[key] = value
+		}
+	}
+
+	return stackLabels
+}
+
+// ValidateStackName checks that a stack name can prefix the names of docker resources, like a compose project name.
+func ValidateStackName(stackName string) error {
+	if !stackNamePattern.MatchString(stackName) {
+		return errdefs.InvalidParameter(errors.Errorf("stack name must start with a lowercase letter or digit followed by lowercase letters, digits, dashes or underscores. Stack:%s", stackName))
+	}
+
+	return nil
+}
+
+func newStackService(container docker.ContainerSummary) StackService {
+	return StackService{
+		Name:          container.Labels[STACK_SERVICE_LABEL],
+		ContainerId:   container.Id,
+		ContainerName: container.Name,
+		Image:         container.Image,
+		State:         container.State,
+		Status:        container.Status,
+	}
+}
+
+func sortStack(stack Stack) Stack {
+	if stack.Services == nil {
+		stack.Services = []StackService{}
+	}
+
+	if stack.Networks == nil {
+		stack.Networks = []string{}
+	}
+
+	if stack.Volumes == nil {
+		stack.Volumes = []string{}
+	}
+
+	sort.Slice(stack.Services, func(i, j int) bool { return stack.Services[i].Name < stack.Services[j].Name })
+	sort.Strings(stack.Networks)
+	sort.Strings(stack.Volumes)
+
+	return stack
+}
+
+// sortedKeys returns the keys of a map with string keys in order.
+func sortedKeys(m interface{}) []string {
+	mapKeys := reflect.ValueOf(m).MapKeys()
+	keys := make([]string, 0, len(mapKeys))
+
+	for _, key := range mapKeys {
+		keys = append(keys, key.String())
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+
+	return false
+}
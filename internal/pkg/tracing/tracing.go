@@ -0,0 +1,122 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"os"
+
+	. "godopi/internal/pkg/logger"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.uber.org/zap"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterOtlp   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// Options are the raw tracing settings from the config. OtlpEndpoint is the host:port of an OTLP/HTTP collector,
+// the OTEL_EXPORTER_OTLP_* environment variables apply when it is empty. File is where the stdout exporter writes,
+// standard output when it is empty.
+type Options struct {
+	Exporter     string
+	ServiceName  string
+	SampleRatio  float64
+	OtlpEndpoint string
+	OtlpInsecure bool
+	File         string
+}
+
+// Init installs the W3C trace context and baggage propagators and, unless the exporter is none, a tracer provider
+// exporting the sampled spans. The returned function flushes the pending spans and stops the exporter.
+func Init(ctx context.Context, options Options) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if options.SampleRatio < 0 || options.SampleRatio > 1 {
+		return nil, errors.Errorf("tracing sample ratio must be between 0 and 1. SampleRatio:%v", options.SampleRatio)
+	}
+
+	exporter, err := newExporter(ctx, options)
+
+	if err != nil || exporter == nil {
+		return func(context.Context) error { return nil }, err
+	}
+
+	serviceResource, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(options.ServiceName)))
+
+	if err != nil {
+		return nil, errors.Wrap(err, "there is an error while building the tracing resource")
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(serviceResource),
+		// Requests that come with a sampled trace context are always traced, so that the traces of callers are complete.
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(options.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		Logger().Warn("Error exporting traces", zap.Error(err))
+	}))
+
+	Logger().Info("Tracing initialized", zap.String("Exporter", options.Exporter), zap.Float64("SampleRatio", options.SampleRatio))
+
+	return tracerProvider.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, options Options) (sdktrace.SpanExporter, error) {
+	switch options.Exporter {
+	case "", ExporterNone:
+		return nil, nil
+	case ExporterOtlp:
+		clientOptions := []otlptracehttp.Option{}
+
+		if options.OtlpEndpoint != "" {
+			clientOptions = append(clientOptions, otlptracehttp.WithEndpoint(options.OtlpEndpoint))
+		}
+
+		if options.OtlpInsecure {
+			clientOptions = append(clientOptions, otlptracehttp.WithInsecure())
+		}
+
+		exporter, err := otlptracehttp.New(ctx, clientOptions...)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "there is an error while creating the OTLP trace exporter. Endpoint:%s", options.OtlpEndpoint)
+		}
+
+		return exporter, nil
+	case ExporterStdout:
+		var writer io.Writer = os.Stdout
+
+		if options.File != "" {
+			file, err := os.OpenFile(options.File, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+
+			if err != nil {
+				return nil, errors.Wrapf(err, "there is an error while opening the trace file. File:%s", options.File)
+			}
+
+			writer = file
+		}
+
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(writer))
+
+		if err != nil {
+			return nil, errors.Wrap(err, "there is an error while creating the stdout trace exporter")
+		}
+
+		return exporter, nil
+	default:
+		return nil, errors.Errorf("tracing exporter must be none, otlp or stdout. Exporter:%s", options.Exporter)
+	}
+}